@@ -37,6 +37,29 @@ func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return "\"" + sl.Value + "\"" }
 
+// InterpolatedString は ${...} を含む補間文字列リテラルを表すノード
+// Parts は文字列部分（StringLiteral）と埋め込まれた式を順に並べたもの
+type InterpolatedString struct {
+	Token token.Token // INTERP_STRING トークン
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+	out.WriteString("\"")
+	for _, part := range is.Parts {
+		if str, ok := part.(*StringLiteral); ok {
+			out.WriteString(str.Value)
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+	out.WriteString("\"")
+	return out.String()
+}
+
 // BooleanLiteral は真偽値リテラルを表すノード
 type BooleanLiteral struct {
 	Token token.Token // true または false トークン
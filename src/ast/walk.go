@@ -0,0 +1,249 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Visitor は Walk で AST を走査するときに各ノードで呼び出される
+// Visit が返した Visitor でそのノードの子を走査し、nil を返した場合は子を走査しない
+// 子をすべて走査した後、Visit(nil) が呼び出される
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// isNil は nil のインターフェースと、インターフェースに入った nil のポインタのどちらも nil として扱う
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// Walk は node から深さ優先で AST を走査する
+// 子はフィールドの宣言順（ソース上の出現順）に走査し、値のない子は飛ばす
+func Walk(v Visitor, node Node) {
+	if isNil(node) {
+		return
+	}
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkList(v, n.Statements)
+
+	// 文
+	case *ExpressionStatement:
+		Walk(v, n.Expression)
+	case *AssignStatement:
+		Walk(v, n.Left)
+		Walk(v, n.Value)
+	case *PipeStatement:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *BlockStatement:
+		walkList(v, n.Statements)
+	case *GlobalStatement:
+		Walk(v, n.Name)
+	case *CaseStatement:
+		Walk(v, n.Condition)
+		Walk(v, n.Consequence)
+		Walk(v, n.Body)
+	case *DefaultCaseStatement:
+		Walk(v, n.Body)
+
+	// 式
+	case *Identifier:
+	case *PrefixExpression:
+		Walk(v, n.Right)
+	case *InfixExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *CallExpression:
+		Walk(v, n.Function)
+		walkList(v, n.Arguments)
+	case *PropertyAccessExpression:
+		Walk(v, n.Object)
+		Walk(v, n.Property)
+	case *IndexExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Index)
+	case *RangeExpression:
+		Walk(v, n.Start)
+		Walk(v, n.End)
+	case *BlockExpression:
+		Walk(v, n.Block)
+	case *FunctionLiteral:
+		Walk(v, n.Name)
+		walkList(v, n.Parameters)
+		Walk(v, n.Condition)
+		Walk(v, n.Body)
+		walkList(v, n.Cases)
+
+	// リテラル
+	case *IntegerLiteral, *FloatLiteral, *StringLiteral, *BooleanLiteral, *PizzaLiteral, *PooLiteral:
+	case *InterpolatedString:
+		walkList(v, n.Parts)
+	case *ArrayLiteral:
+		walkList(v, n.Elements)
+	case *ClassLiteral:
+		Walk(v, n.Name)
+		Walk(v, n.Extends)
+		walkList(v, n.Properties)
+		walkList(v, n.Methods)
+	case *PropertyDefinition:
+		Walk(v, n.Name)
+	case *EnumLiteral:
+		Walk(v, n.Name)
+		walkList(v, n.Values)
+
+	default:
+		// 新しいノードの型を追加したときに、その子が黙って走査から漏れないようにする
+		panic(fmt.Sprintf("ast.Walk: 未対応のノード %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkList[N Node](v Visitor, list []N) {
+	for _, node := range list {
+		Walk(v, node)
+	}
+}
+
+// inspector は関数を Visitor として使えるようにする
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect は node から深さ優先で AST を走査し、各ノードで f(node) を呼び出す
+// f が false を返した場合はそのノードの子を走査しない。子をすべて走査した後に f(nil) が呼び出される
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Rewrite は node から深さ優先で AST を走査し、子を書き換えた後に各ノードを f(node) の戻り値に置き換える
+// ノードのフィールドはその場で書き換え、最後に f(node) の戻り値を返す
+// f は置き換えないノードをそのまま返す。nil を返すと、フィールドは空になり、並びからは取り除かれる
+// 置き換え先の位置に置けない型（式の位置に文など）を返した場合は panic する
+func Rewrite(node Node, f func(Node) Node) Node {
+	if isNil(node) {
+		return node
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		n.Statements = rewriteList(n.Statements, f)
+
+	// 文
+	case *ExpressionStatement:
+		n.Expression = rewrite(n.Expression, f)
+	case *AssignStatement:
+		n.Left = rewrite(n.Left, f)
+		n.Value = rewrite(n.Value, f)
+	case *PipeStatement:
+		n.Left = rewrite(n.Left, f)
+		n.Right = rewrite(n.Right, f)
+	case *BlockStatement:
+		n.Statements = rewriteList(n.Statements, f)
+	case *GlobalStatement:
+		n.Name = rewrite(n.Name, f)
+	case *CaseStatement:
+		n.Condition = rewrite(n.Condition, f)
+		n.Consequence = rewrite(n.Consequence, f)
+		n.Body = rewrite(n.Body, f)
+	case *DefaultCaseStatement:
+		n.Body = rewrite(n.Body, f)
+
+	// 式
+	case *Identifier:
+	case *PrefixExpression:
+		n.Right = rewrite(n.Right, f)
+	case *InfixExpression:
+		n.Left = rewrite(n.Left, f)
+		n.Right = rewrite(n.Right, f)
+	case *CallExpression:
+		n.Function = rewrite(n.Function, f)
+		n.Arguments = rewriteList(n.Arguments, f)
+	case *PropertyAccessExpression:
+		n.Object = rewrite(n.Object, f)
+		n.Property = rewrite(n.Property, f)
+	case *IndexExpression:
+		n.Left = rewrite(n.Left, f)
+		n.Index = rewrite(n.Index, f)
+	case *RangeExpression:
+		n.Start = rewrite(n.Start, f)
+		n.End = rewrite(n.End, f)
+	case *BlockExpression:
+		n.Block = rewrite(n.Block, f)
+	case *FunctionLiteral:
+		n.Name = rewrite(n.Name, f)
+		n.Parameters = rewriteList(n.Parameters, f)
+		n.Condition = rewrite(n.Condition, f)
+		n.Body = rewrite(n.Body, f)
+		n.Cases = rewriteList(n.Cases, f)
+
+	// リテラル
+	case *IntegerLiteral, *FloatLiteral, *StringLiteral, *BooleanLiteral, *PizzaLiteral, *PooLiteral:
+	case *InterpolatedString:
+		n.Parts = rewriteList(n.Parts, f)
+	case *ArrayLiteral:
+		n.Elements = rewriteList(n.Elements, f)
+	case *ClassLiteral:
+		n.Name = rewrite(n.Name, f)
+		n.Extends = rewrite(n.Extends, f)
+		n.Properties = rewriteList(n.Properties, f)
+		n.Methods = rewriteList(n.Methods, f)
+	case *PropertyDefinition:
+		n.Name = rewrite(n.Name, f)
+	case *EnumLiteral:
+		n.Name = rewrite(n.Name, f)
+		n.Values = rewriteList(n.Values, f)
+
+	default:
+		panic(fmt.Sprintf("ast.Rewrite: 未対応のノード %T", n))
+	}
+
+	return f(node)
+}
+
+// rewrite は子ノードを書き換え、フィールドの型 N に合わせて返す
+func rewrite[N Node](node N, f func(Node) Node) N {
+	var zero N
+	if isNil(node) {
+		return node
+	}
+	replaced := Rewrite(node, f)
+	if isNil(replaced) {
+		return zero
+	}
+	r, ok := replaced.(N)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: %T を %s の位置に置くことはできません", replaced, reflect.TypeOf(&zero).Elem()))
+	}
+	return r
+}
+
+// rewriteList は並びの各ノードを書き換え、nil に置き換えられたノードを取り除く
+func rewriteList[N Node](list []N, f func(Node) Node) []N {
+	if list == nil {
+		return nil
+	}
+	result := list[:0]
+	for _, node := range list {
+		r := rewrite(node, f)
+		if isNil(r) && !isNil(node) {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
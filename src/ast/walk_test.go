@@ -0,0 +1,117 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/uncode/token"
+)
+
+// program は 3 |> { def f() { 🍕 >> 💩; } }; に相当する AST を作る
+func program() *Program {
+	fn := &FunctionLiteral{
+		Token: token.Token{Literal: "def"},
+		Name:  &Identifier{Value: "f"},
+		Body: &BlockStatement{Statements: []Statement{
+			&AssignStatement{Left: &PizzaLiteral{}, Value: &PooLiteral{}},
+		}},
+	}
+	return &Program{Statements: []Statement{
+		&ExpressionStatement{Expression: &InfixExpression{
+			Left:     &IntegerLiteral{Value: 3},
+			Operator: "|>",
+			Right: &BlockExpression{Block: &BlockStatement{Statements: []Statement{
+				&ExpressionStatement{Expression: fn},
+			}}},
+		}},
+	}}
+}
+
+// kinds はノードの型名を並べる
+func kinds(nodes []Node) string {
+	names := make([]string, len(nodes))
+	for i, n := range nodes {
+		names[i] = strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")
+	}
+	return strings.Join(names, " ")
+}
+
+// TestInspect は子をソース上の順に深さ優先で走査し、値のない子を飛ばすことをテストする
+func TestInspect(t *testing.T) {
+	var visited []Node
+	Inspect(program(), func(n Node) bool {
+		if n != nil {
+			visited = append(visited, n)
+		}
+		return true
+	})
+	expected := "Program ExpressionStatement InfixExpression IntegerLiteral BlockExpression BlockStatement " +
+		"ExpressionStatement FunctionLiteral Identifier BlockStatement AssignStatement PizzaLiteral PooLiteral"
+	if got := kinds(visited); got != expected {
+		t.Errorf("wrong order.\nexpected: %s\ngot:      %s", expected, got)
+	}
+
+	// false を返したノードの子は走査しない
+	visited = nil
+	Inspect(program(), func(n Node) bool {
+		if n != nil {
+			visited = append(visited, n)
+		}
+		_, isFunction := n.(*FunctionLiteral)
+		return !isFunction
+	})
+	if got := kinds(visited); strings.Contains(got, "AssignStatement") {
+		t.Errorf("children of the function should be skipped. got=%s", got)
+	}
+}
+
+// TestRewrite はノードの置き換えと、並びからの取り除きをテストする
+func TestRewrite(t *testing.T) {
+	p := Rewrite(program(), func(n Node) Node {
+		switch n := n.(type) {
+		case *PizzaLiteral:
+			return &IntegerLiteral{Token: token.Token{Literal: "42"}, Value: 42}
+		case *IntegerLiteral:
+			if n.Value == 3 {
+				return &StringLiteral{Value: "three"}
+			}
+		}
+		return n
+	}).(*Program)
+	if got := p.String(); !strings.Contains(got, `"three" |>`) || !strings.Contains(got, "42 >> 💩") {
+		t.Errorf("nodes should be replaced. got=%s", got)
+	}
+
+	p = Rewrite(program(), func(n Node) Node {
+		if _, ok := n.(*AssignStatement); ok {
+			return nil
+		}
+		return n
+	}).(*Program)
+	var assigns int
+	Inspect(p, func(n Node) bool {
+		if _, ok := n.(*AssignStatement); ok {
+			assigns++
+		}
+		return true
+	})
+	if assigns != 0 {
+		t.Errorf("statements replaced with nil should be removed. got=%d", assigns)
+	}
+}
+
+// TestRewriteWrongType は式の位置に文を置こうとすると panic することをテストする
+func TestRewriteWrongType(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "ast.Expression") {
+			t.Errorf("should panic with the expected type. got=%v", r)
+		}
+	}()
+	Rewrite(program(), func(n Node) Node {
+		if _, ok := n.(*IntegerLiteral); ok {
+			return &BlockStatement{}
+		}
+		return n
+	})
+}
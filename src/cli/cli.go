@@ -0,0 +1,387 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/uncode/ast"
+	"github.com/uncode/config"
+	"github.com/uncode/coverage"
+	"github.com/uncode/evaluator"
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+	"github.com/uncode/profile"
+	"github.com/uncode/runtime"
+	"github.com/uncode/trace"
+)
+
+// Run はコマンドライン引数（プログラム名を除く）に従ってサブコマンドを実行し、終了コードを返す
+func Run(args []string) int {
+	// コマンドラインと設定ファイル・環境変数のパース
+	cmd, err := config.ParseCommandLine(args)
+	if err != nil {
+		if errors.Is(err, config.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, "使用方法は 'uncode help' で確認できます。")
+		return runtime.ExitUsageError
+	}
+
+	switch cmd.Name {
+	case "version":
+		fmt.Printf("uncode %s\n", config.Version)
+		return 0
+	case "config":
+		config.PrintSettings(os.Stdout, &config.GlobalConfig)
+		return 0
+	}
+
+	// ロガーの設定
+	if err := config.SetupLogger(); err != nil {
+		fmt.Fprintf(os.Stderr, "ロガーの初期化エラー: %s\n", err)
+		return runtime.ExitUsageError
+	}
+
+	// Go のパニックで終了する場合も、直前の様子がわかるようにフライトレコーダーの内容を書き出す
+	defer func() {
+		if r := recover(); r != nil {
+			logger.DumpFlightRecorder(os.Stderr)
+			panic(r)
+		}
+	}()
+
+	switch cmd.Name {
+	case "check":
+		return runCheck(cmd.Args)
+	case "fmt":
+		return runFormat(cmd.Args, cmd.Write)
+	case "dump":
+		return runDump(cmd.Args[0], cmd.Dump, cmd.Format)
+	case "dap":
+		return runDAP()
+	}
+
+	// プログラムの出力先の設定
+	closeOutput, err := setupOutput()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "出力ファイルの初期化エラー: %s\n", err)
+		return runtime.ExitUsageError
+	}
+	defer closeOutput()
+
+	setupDebugLevels()
+
+	switch cmd.Name {
+	case "repl":
+		return runREPL(os.Stdin, os.Stdout)
+	case "test":
+		return runTests(cmd.Args)
+	case "debug":
+		return runDebug(config.GlobalConfig.SourceFile)
+	default:
+		return runScript()
+	}
+}
+
+// runScript は -e のソースコード、標準入力、ソースファイルのいずれかを実行する
+func runScript() int {
+	// バージョン情報のログ
+	logger.Info("PooCode インタプリタ バージョン %s", config.Version)
+	logger.Debug("デバッグモード: %v", config.GlobalConfig.DebugMode)
+	logger.Debug("ログレベル: %s", logger.LevelNames[config.GlobalConfig.LogLevel])
+	logger.Debug("ソースファイル: %s", config.GlobalConfig.SourceFile)
+	if config.GlobalConfig.ConfigFile != "" {
+		logger.Debug("設定ファイル: %s", config.GlobalConfig.ConfigFile)
+	}
+
+	// プロファイラとトレースは指定された場合だけ登録する（登録しなければ評価器の負荷は増えない）
+	var prof *profile.Profile
+	var profilers multiProfiler
+	if config.GlobalConfig.ProfileFile != "" {
+		prof = profile.New(sourceName())
+		profilers = append(profilers, prof)
+	}
+	var timeline *trace.Chrome
+	var timelineFile *os.File
+	if config.GlobalConfig.TraceFile != "" {
+		f, err := os.Create(config.GlobalConfig.TraceFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "トレースを書き込めませんでした: %s\n", err)
+			return runtime.ExitUsageError
+		}
+		timelineFile = f
+		timeline = trace.NewChrome(f, sourceName())
+		profilers = append(profilers, timeline)
+	}
+	if len(profilers) > 0 {
+		evaluator.SetProfiler(profilers)
+	}
+	var hooks multiHook
+	var sequence *trace.Mermaid
+	if config.GlobalConfig.TraceMermaidFile != "" {
+		sequence = trace.NewMermaid(sourceName())
+		hooks = append(hooks, sequence)
+	}
+	var cov *coverage.Coverage
+	if config.GlobalConfig.CoverageFile != "" {
+		cov = coverage.New()
+		hooks = append(hooks, cov)
+	}
+	if len(hooks) > 0 {
+		evaluator.SetHook(hooks)
+	}
+
+	// テキスト形式のエラーはruntime内でログ出力されるので、JSON の場合だけここで出力する
+	// 正常終了の場合も exit 関数や💩で終了コードが指定されていればそれを使う
+	result, _ := executeSource()
+	if config.GlobalConfig.Diagnostics == config.DiagnosticsJSON {
+		runtime.WriteDiagnosticsJSON(os.Stderr, result.Diagnostics)
+	}
+	if isRuntimeFailure(result) {
+		logger.DumpFlightRecorder(os.Stderr)
+	}
+
+	// 書き出しに失敗した場合は、プログラムが正常に終了していても使い方のエラーとして終了する
+	exitCode := result.ExitCode
+	writeFailed := func(what string, err error) {
+		fmt.Fprintf(os.Stderr, "%sを書き込めませんでした: %s\n", what, err)
+		if exitCode == runtime.ExitOK {
+			exitCode = runtime.ExitUsageError
+		}
+	}
+	evaluator.SetProfiler(nil)
+	if timeline != nil {
+		err := timeline.Close()
+		if closeErr := timelineFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			writeFailed("トレース", err)
+		}
+	}
+	if prof != nil {
+		prof.Stop()
+		if err := writeProfile(prof, config.GlobalConfig.ProfileFile, config.GlobalConfig.ProfileFormat); err != nil {
+			writeFailed("プロファイル", err)
+		}
+	}
+	evaluator.SetHook(nil)
+	if sequence != nil {
+		if err := writeMermaid(sequence, config.GlobalConfig.TraceMermaidFile); err != nil {
+			writeFailed("シーケンス図", err)
+		}
+	}
+	if cov != nil {
+		cov.Add(sourceName(), result.Source, result.Program)
+		if err := writeCoverage(cov, config.GlobalConfig.CoverageFile); err != nil {
+			writeFailed("カバレッジ", err)
+		}
+	}
+	return exitCode
+}
+
+// isRuntimeFailure は実行時のエラーで終了したかどうかを返す
+// ソースファイルの読み込みや字句解析・構文解析のエラーはプログラムを実行していないので含めない
+func isRuntimeFailure(result *runtime.SourceCodeResult) bool {
+	if len(result.Diagnostics) == 0 {
+		return false
+	}
+	switch result.ExitCode {
+	case runtime.ExitUsageError, runtime.ExitLexError, runtime.ExitParseError:
+		return false
+	}
+	return true
+}
+
+// sourceName は実行するソースコードの診断メッセージ上の名前を返す
+func sourceName() string {
+	if config.GlobalConfig.SourceFile == config.StdinSourceFile {
+		return config.StdinSourceName
+	}
+	return config.GlobalConfig.SourceFile
+}
+
+// multiProfiler はプロファイルとトレースを同時に記録するために、複数のプロファイラに順に通知する
+type multiProfiler []evaluator.Profiler
+
+func (m multiProfiler) Enter(frame evaluator.ProfileFrame, input object.Object) {
+	for _, p := range m {
+		p.Enter(frame, input)
+	}
+}
+
+func (m multiProfiler) Exit(result object.Object) {
+	for _, p := range m {
+		p.Exit(result)
+	}
+}
+
+// multiHook はシーケンス図とカバレッジを同時に記録するために、複数のフックに順に通知する
+type multiHook []evaluator.Hook
+
+func (m multiHook) BeforeEval(node ast.Node, env *object.Environment) {
+	for _, h := range m {
+		h.BeforeEval(node, env)
+	}
+}
+
+func (m multiHook) BeforeStage(node *ast.InfixExpression, input object.Object, env *object.Environment) {
+	for _, h := range m {
+		h.BeforeStage(node, input, env)
+	}
+}
+
+func (m multiHook) AfterStage(node *ast.InfixExpression, result object.Object) {
+	for _, h := range m {
+		h.AfterStage(node, result)
+	}
+}
+
+func (m multiHook) EnterFunction(fn *object.Function, env *object.Environment) {
+	for _, h := range m {
+		h.EnterFunction(fn, env)
+	}
+}
+
+func (m multiHook) ExitFunction(fn *object.Function, result object.Object) {
+	for _, h := range m {
+		h.ExitFunction(fn, result)
+	}
+}
+
+func (m multiHook) SelectCase(arm ast.Statement, env *object.Environment) {
+	for _, h := range m {
+		h.SelectCase(arm, env)
+	}
+}
+
+// writeProfile はプロファイルを指定した形式でファイルに書き出す
+func writeProfile(prof *profile.Profile, path string, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if format == config.ProfileFormatFolded {
+		err = prof.WriteFolded(f)
+	} else {
+		err = prof.WritePprof(f)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeMermaid はシーケンス図をファイルに書き出す
+func writeMermaid(sequence *trace.Mermaid, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = sequence.Write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeCoverage はカバレッジを lcov 形式で path に、HTML のレポートを path に .html を付けたファイルに書き出す
+func writeCoverage(cov *coverage.Coverage, path string) error {
+	for _, out := range []struct {
+		path  string
+		write func(io.Writer) error
+	}{
+		{path, cov.WriteLCOV},
+		{path + ".html", cov.WriteHTML},
+	} {
+		f, err := os.Create(out.path)
+		if err != nil {
+			return err
+		}
+		err = out.write(f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executeSource は設定に従って実行するソースコードを選ぶ
+func executeSource() (*runtime.SourceCodeResult, error) {
+	if config.GlobalConfig.InlineCode != "" {
+		return runtime.ExecuteSource(config.InlineSourceName, config.GlobalConfig.InlineCode)
+	}
+
+	if config.GlobalConfig.SourceFile == config.StdinSourceFile {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			logger.Error("標準入力を読み込めませんでした: %s\n", err)
+			d := runtime.NewDiagnostic(runtime.CodeUsageError, config.StdinSourceName, fmt.Sprintf("標準入力を読み込めませんでした: %s", err))
+			return &runtime.SourceCodeResult{ExitCode: runtime.ExitUsageError, Diagnostics: []runtime.Diagnostic{d}}, err
+		}
+		return runtime.ExecuteSource(config.StdinSourceName, string(content))
+	}
+
+	return runtime.ExecuteSourceFile(config.GlobalConfig.SourceFile)
+}
+
+// setupDebugLevels は設定に従って評価器の各処理のデバッグレベルを設定する
+func setupDebugLevels() {
+	// 組み込み関数のログレベルを設定
+	if config.GlobalConfig.ShowBuiltinDebug {
+		evaluator.SetBuiltinLogLevel(logger.LevelDebug)
+	} else {
+		evaluator.SetBuiltinLogLevel(logger.LevelInfo)
+	}
+
+	// パイプライン処理のデバッグレベルを設定
+	if config.GlobalConfig.ShowPipelineDebug {
+		evaluator.SetPipeDebugLevel(logger.LevelDebug)
+		logger.Debug("パイプライン処理のデバッグ出力を有効化しました")
+	} else {
+		evaluator.SetPipeDebugLevel(logger.LevelOff)
+	}
+
+	// map/filter演算子のデバッグレベルを設定
+	if config.GlobalConfig.ShowMapFilterDebug {
+		evaluator.SetMapFilterDebugLevel(logger.LevelDebug)
+		logger.Debug("map/filter演算子のデバッグ出力を有効化しました")
+	} else {
+		evaluator.SetMapFilterDebugLevel(logger.LevelOff)
+	}
+
+	// 条件式評価のデバッグレベルを設定
+	if config.GlobalConfig.ShowConditionDebug {
+		evaluator.SetConditionDebugLevel(logger.LevelDebug)
+		logger.Debug("条件式評価のデバッグ出力を有効化しました")
+	} else {
+		evaluator.SetConditionDebugLevel(logger.LevelOff)
+	}
+}
+
+// setupOutput はプログラムの出力先を設定する
+// --quiet なら標準出力には表示せず、-output が指定されていれば出力をファイルにも書き込む
+func setupOutput() (func(), error) {
+	var stdout io.Writer = os.Stdout
+	if config.GlobalConfig.Quiet {
+		stdout = io.Discard
+	}
+
+	if config.GlobalConfig.OutputFile == "" {
+		evaluator.SetOutput(stdout)
+		return func() {}, nil
+	}
+
+	f, err := os.Create(config.GlobalConfig.OutputFile)
+	if err != nil {
+		return nil, err
+	}
+	evaluator.SetOutput(io.MultiWriter(stdout, f))
+	return func() { f.Close() }, nil
+}
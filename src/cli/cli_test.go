@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/uncode/evaluator"
+	"github.com/uncode/logger"
+)
+
+// TestFormatSource はインデントと空白の整形をテストする
+func TestFormatSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"def f() {\n🍕 * 2 >> 💩   \n      }\n\n\n\n3 |> f |> print;",
+			"def f() {\n  🍕 * 2 >> 💩\n}\n\n3 |> f |> print;\n",
+		},
+		// 文字列やコメントの中の括弧は数えない
+		{
+			"\n\n\"{ not a brace\" |> print; // {\n  1 |> print;\n",
+			"\"{ not a brace\" |> print; // {\n1 |> print;\n",
+		},
+		// 複数行の文字列の中身は変更しない
+		{
+			"\"a\n    b {\" |> print;\n    1;",
+			"\"a\n    b {\" |> print;\n1;\n",
+		},
+		{
+			"#!/usr/bin/env uncode  \ndef g(): int -> str {\ncase 🍕 > 0: {\n\"positive\" >> 💩\n}\n}",
+			"#!/usr/bin/env uncode\ndef g(): int -> str {\n  case 🍕 > 0: {\n    \"positive\" >> 💩\n  }\n}\n",
+		},
+		{"\n\n", ""},
+	}
+
+	for _, tt := range tests {
+		got := formatSource(tt.input)
+		if got != tt.expected {
+			t.Errorf("formatSource(%q)\nexpected=%q\ngot     =%q", tt.input, tt.expected, got)
+		}
+		// 整形済みのコードは変わらない
+		if again := formatSource(got); again != got {
+			t.Errorf("formatSource should be idempotent. got=%q", again)
+		}
+	}
+}
+
+// TestREPL は REPL が入力を評価し、定義を引き継いで結果を表示することをテストする
+func TestREPL(t *testing.T) {
+	logger.SetLevel(logger.LevelOff)
+	var programOutput bytes.Buffer
+	evaluator.SetOutput(&programOutput)
+	t.Cleanup(func() {
+		evaluator.SetOutput(os.Stdout)
+		evaluator.SetInput(os.Stdin)
+	})
+
+	input := strings.Join([]string{
+		"1 + 2",
+		"def sq() {",
+		"  🍕 * 🍕 >> 💩",
+		"}",
+		"4 |> sq",
+		`"hi" |> print;`,
+		"exit 3",
+		"1 + 1",
+	}, "\n")
+	var out bytes.Buffer
+	code := runREPL(strings.NewReader(input), &out)
+
+	if code != 3 {
+		t.Errorf("exit should end the REPL with its code. got=%d", code)
+	}
+	lines := strings.Split(out.String(), "\n")
+	expected := []string{
+		"PooCode 0.1.0 (:quit または Ctrl-D で終了)",
+		"poo> 3",
+		"poo> ...> ...> function with 0 params",
+		"poo> 16",
+		"poo> hi",
+		"poo> ",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("wrong output. got=%q", out.String())
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("line %d wrong. expected=%q, got=%q", i, expected[i], lines[i])
+		}
+	}
+	if programOutput.String() != "hi\n" {
+		t.Errorf("print should write to the program output. got=%q", programOutput.String())
+	}
+
+	if !needsMoreInput("def f() {\n") || !needsMoreInput("\"abc\n") || needsMoreInput("\"{\" |> print;\n") {
+		t.Errorf("needsMoreInput wrong")
+	}
+}
+
+// TestDebugger はステップ実行、条件付きブレークポイント、🍕と呼び出し履歴の表示をテストする
+func TestDebugger(t *testing.T) {
+	logger.SetLevel(logger.LevelOff)
+	var programOutput bytes.Buffer
+	evaluator.SetOutput(&programOutput)
+	t.Cleanup(func() {
+		evaluator.SetOutput(os.Stdout)
+		evaluator.SetInput(os.Stdin)
+	})
+
+	source := strings.Join([]string{
+		"def sq(): int -> int {",
+		"  🍕 * 🍕 >> 💩;",
+		"}",
+		"def add1(): int -> int {",
+		"  🍕 + 1 >> 💩;",
+		"}",
+		"3 >> x;",
+		"x |> sq |> add1 >> y;",
+		"y |> add1 |> print;",
+	}, "\n")
+	commands := strings.Join([]string{
+		"step",
+		"step",
+		"step",
+		"vars",
+		"next",
+		"break 5 if 🍕 == 10",
+		"continue",
+		"bt",
+		"print",
+		"finish",
+		"p x * 2",
+		"c",
+	}, "\n")
+	var out bytes.Buffer
+	code := newDebugger("main.poo", source, strings.NewReader(commands), &out).run()
+
+	if code != 0 {
+		t.Errorf("wrong exit code. got=%d", code)
+	}
+	// 期待する出力が順に現れることを確認する
+	expected := []string{
+		"main.poo:7 (<メイン>)",
+		"main.poo:8 (<メイン>)",
+		"main.poo:8 (<メイン>) 段 |> sq (🍕 = 3)",
+		"main.poo:2 (sq)",
+		"x = 3\n🍕 = 3",
+		"main.poo:8 (<メイン>) 段 |> add1 (🍕 = 9)",
+		"ブレークポイント 1 を main.poo:5 に設定しました",
+		// 1回目の add1 (🍕 = 9) では条件を満たさないので停止しない
+		"ブレークポイント 1\nmain.poo:5 (add1)",
+		"#0 add1 (main.poo:5)\n#1 <メイン> (main.poo:9)",
+		"🍕 = 10\n💩 = (未設定)",
+		"add1 の戻り値 (💩): 11\nmain.poo:9 (<メイン>) 段 |> print (🍕 = 11)",
+		"(poo) 6\n",
+		"プログラムが終了しました (終了コード 0)",
+	}
+	rest := out.String()
+	for _, e := range expected {
+		i := strings.Index(rest, e)
+		if i < 0 {
+			t.Fatalf("output should contain %q after the previous lines. got=%q", e, out.String())
+		}
+		rest = rest[i+len(e):]
+	}
+	if programOutput.String() != "11\n" {
+		t.Errorf("print should write to the program output. got=%q", programOutput.String())
+	}
+}
+
+// TestDebuggerFinishInMap は +> の要素ごとに呼ばれる関数から finish すると、次の停止位置で停止することをテストする
+func TestDebuggerFinishInMap(t *testing.T) {
+	logger.SetLevel(logger.LevelOff)
+	var programOutput bytes.Buffer
+	evaluator.SetOutput(&programOutput)
+	t.Cleanup(func() {
+		evaluator.SetOutput(os.Stdout)
+		evaluator.SetInput(os.Stdin)
+	})
+
+	source := strings.Join([]string{
+		"def double(): int -> int {",
+		"  🍕 * 2 >> 💩;",
+		"};",
+		"[1, 2, 3] +> double |> print;",
+	}, "\n")
+	commands := strings.Join([]string{
+		"break 2",
+		"continue",
+		"finish",
+		"print",
+		"finish",
+		"c",
+	}, "\n")
+	var out bytes.Buffer
+	code := newDebugger("main.poo", source, strings.NewReader(commands), &out).run()
+
+	if code != 0 {
+		t.Errorf("wrong exit code. got=%d", code)
+	}
+	expected := []string{
+		"ブレークポイント 1\nmain.poo:2 (double)",
+		// 1つ目の要素の呼び出しから戻ると、2つ目の要素の呼び出しの中で停止する
+		"double の戻り値 (💩): 2\nmain.poo:2 (double)",
+		"🍕 = 2",
+		"double の戻り値 (💩): 4\nmain.poo:2 (double)",
+		"プログラムが終了しました (終了コード 0)",
+	}
+	rest := out.String()
+	for _, e := range expected {
+		i := strings.Index(rest, e)
+		if i < 0 {
+			t.Fatalf("output should contain %q after the previous lines. got=%q", e, out.String())
+		}
+		rest = rest[i+len(e):]
+	}
+	if programOutput.String() != "[2, 4, 6]\n" {
+		t.Errorf("wrong program output. got=%q", programOutput.String())
+	}
+}
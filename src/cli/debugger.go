@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/uncode/dap"
+	"github.com/uncode/debug"
+	"github.com/uncode/evaluator"
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+	"github.com/uncode/runtime"
+)
+
+// デバッガのプロンプトと表示
+const debugPrompt = "(poo) "
+
+// debugHelp は help コマンドで表示するコマンドの一覧
+const debugHelp = `コマンド:
+  break <行> [if <条件式>]  ブレークポイントを設定する（引数なしで一覧を表示） (b)
+  delete <番号>             ブレークポイントを削除する (d)
+  continue                  次のブレークポイントまで実行する (c)
+  step                      次の文またはパイプラインの段まで実行する。関数の中にも入る (s)
+  next                      関数の中には入らずに、次の文または段まで実行する (n)
+  finish                    現在の関数から戻るまで実行する (f, out)
+  print [式]                式の値を表示する。省略すると🍕と💩を表示する (p)
+  vars                      現在の環境の変数を表示する (v, locals)
+  backtrace                 関数の呼び出し履歴を表示する (bt)
+  list                      現在の行の前後を表示する (l)
+  quit                      デバッグを終了する (q)
+空行を入力すると直前のコマンドを繰り返す`
+
+// quitDebugger は quit コマンドで評価を打ち切るための panic の値
+type quitDebugger struct{}
+
+// debugger は debug サブコマンドの対話的なデバッガ
+// debug.Session のフロントエンドとして、停止した位置でコマンドを受け付ける
+type debugger struct {
+	name        string
+	source      string
+	lines       []string
+	in          *bufio.Reader
+	out         io.Writer
+	session     *debug.Session
+	breakpoints []*debug.Breakpoint
+	nextID      int
+	lastCommand string
+}
+
+// newDebugger はデバッガを作成する。最初の文で停止した状態から始まる
+func newDebugger(name string, source string, in io.Reader, out io.Writer) *debugger {
+	d := &debugger{
+		name:   name,
+		source: source,
+		lines:  strings.Split(strings.TrimSuffix(source, "\n"), "\n"),
+		in:     bufio.NewReader(in),
+		out:    out,
+		nextID: 1,
+	}
+	d.session = debug.NewSession(d, true)
+	return d
+}
+
+// runDebug は debug サブコマンドを実行する
+func runDebug(path string) int {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return writeDiagnostics([]runtime.Diagnostic{readError(path, err)})
+	}
+	return newDebugger(path, string(content), os.Stdin, os.Stdout).run()
+}
+
+// runDAP は dap サブコマンドを実行する
+// 標準入出力はプロトコルに使うため、ログは標準エラー出力に書き出す
+func runDAP() int {
+	logger.SetOutput(os.Stderr)
+	if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "DAP サーバーのエラー: %s\n", err)
+		return runtime.ExitRuntimeError
+	}
+	return runtime.ExitOK
+}
+
+// run はデバッガを登録してプログラムを実行し、終了コードを返す
+func (d *debugger) run() (exitCode int) {
+	// input や stdin_lines もデバッガと同じ入力から読み込む
+	evaluator.SetInput(d.in)
+	evaluator.SetHook(d.session)
+	defer evaluator.SetHook(nil)
+
+	fmt.Fprintf(d.out, "PooCode デバッガ: %s (help でコマンドの一覧を表示)\n", d.name)
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(quitDebugger); !ok {
+				panic(r)
+			}
+			// 評価器の状態は元に戻らないが、プロセスはこのまま終了する
+			fmt.Fprintln(d.out, "デバッグを終了しました")
+			exitCode = runtime.ExitOK
+		}
+	}()
+
+	result, _ := runtime.ExecuteSource(d.name, d.source)
+	fmt.Fprintf(d.out, "プログラムが終了しました (終了コード %d)\n", result.ExitCode)
+	return result.ExitCode
+}
+
+// PauseRequested は常に false を返す（実行中に一時停止を要求する手段はない）
+func (d *debugger) PauseRequested() bool {
+	return false
+}
+
+// BreakpointsAt は指定した行のブレークポイントを返す
+func (d *debugger) BreakpointsAt(line int) []*debug.Breakpoint {
+	var hits []*debug.Breakpoint
+	for _, bp := range d.breakpoints {
+		if bp.Line == line {
+			hits = append(hits, bp)
+		}
+	}
+	return hits
+}
+
+// Message はデバッガからのメッセージを表示する
+func (d *debugger) Message(format string, args ...interface{}) {
+	fmt.Fprintf(d.out, format, args...)
+}
+
+// Stop は停止した位置を表示し、実行を再開するコマンドが入力されるまでコマンドを受け付ける
+func (d *debugger) Stop(reason string, hits []*debug.Breakpoint) {
+	for _, bp := range hits {
+		fmt.Fprintf(d.out, "ブレークポイント %d\n", bp.ID)
+	}
+	d.prompt(d.session.Top())
+}
+
+// prompt は現在の位置を表示し、実行を再開するコマンドが入力されるまでコマンドを受け付ける
+func (d *debugger) prompt(f *debug.Frame) {
+	d.printLocation(f)
+	for {
+		fmt.Fprint(d.out, debugPrompt)
+		line, err := d.in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(d.out)
+			panic(quitDebugger{})
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			line = d.lastCommand
+		}
+		d.lastCommand = line
+		if d.execute(line, f) {
+			return
+		}
+	}
+}
+
+// execute はコマンドを1つ実行し、実行を再開する場合は true を返す
+func (d *debugger) execute(line string, f *debug.Frame) bool {
+	command, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch command {
+	case "":
+	case "c", "continue":
+		d.session.Resume(debug.ModeContinue)
+		return true
+	case "s", "step":
+		d.session.Resume(debug.ModeStep)
+		return true
+	case "n", "next":
+		d.session.Resume(debug.ModeNext)
+		return true
+	case "f", "finish", "out":
+		if len(d.session.Frames) == 1 {
+			fmt.Fprintln(d.out, "関数の中ではありません")
+			return false
+		}
+		d.session.Resume(debug.ModeFinish)
+		return true
+	case "b", "break":
+		d.setBreakpoint(arg)
+	case "d", "delete":
+		d.deleteBreakpoint(arg)
+	case "p", "print":
+		d.print(arg, f)
+	case "v", "vars", "locals":
+		d.printVariables(f.Env)
+	case "bt", "backtrace":
+		d.printBacktrace()
+	case "l", "list":
+		d.printSource(f.Line)
+	case "h", "help":
+		fmt.Fprintln(d.out, debugHelp)
+	case "q", "quit":
+		panic(quitDebugger{})
+	default:
+		fmt.Fprintf(d.out, "不明なコマンドです: %s (help でコマンドの一覧を表示)\n", command)
+	}
+	return false
+}
+
+// printLocation は停止した位置を表示する
+func (d *debugger) printLocation(f *debug.Frame) {
+	if f.Stage != nil {
+		fmt.Fprintf(d.out, "%s:%d (%s) 段 %s %s (🍕 = %s)\n",
+			d.name, f.Line, f.Function, f.Stage.Operator, f.Stage.Right.String(), debug.Inspect(f.StageInput))
+	} else {
+		fmt.Fprintf(d.out, "%s:%d (%s)\n", d.name, f.Line, f.Function)
+	}
+	if f.Line >= 1 && f.Line <= len(d.lines) {
+		fmt.Fprintf(d.out, "%5d | %s\n", f.Line, strings.TrimRight(d.lines[f.Line-1], " \t\r"))
+	}
+}
+
+// setBreakpoint は "行 [if 条件式]" の形式でブレークポイントを設定する。引数がなければ一覧を表示する
+func (d *debugger) setBreakpoint(arg string) {
+	if arg == "" {
+		if len(d.breakpoints) == 0 {
+			fmt.Fprintln(d.out, "ブレークポイントはありません")
+		}
+		for _, bp := range d.breakpoints {
+			if bp.Condition != "" {
+				fmt.Fprintf(d.out, "%d: %s:%d if %s\n", bp.ID, d.name, bp.Line, bp.Condition)
+			} else {
+				fmt.Fprintf(d.out, "%d: %s:%d\n", bp.ID, d.name, bp.Line)
+			}
+		}
+		return
+	}
+
+	lineArg, condition, _ := strings.Cut(arg, " ")
+	line, err := strconv.Atoi(lineArg)
+	if err != nil || line < 1 || line > len(d.lines) {
+		fmt.Fprintf(d.out, "行番号が正しくありません: %s\n", lineArg)
+		return
+	}
+	source := ""
+	if condition = strings.TrimSpace(condition); condition != "" {
+		var ok bool
+		if source, ok = strings.CutPrefix(condition, "if "); !ok {
+			fmt.Fprintln(d.out, "条件は 'break <行> if <条件式>' の形式で指定してください")
+			return
+		}
+	}
+	bp, err := debug.NewBreakpoint(d.nextID, line, source)
+	if err != nil {
+		fmt.Fprintf(d.out, "条件式を解析できませんでした: %s\n", err)
+		return
+	}
+
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, bp)
+	fmt.Fprintf(d.out, "ブレークポイント %d を %s:%d に設定しました\n", bp.ID, d.name, line)
+}
+
+// deleteBreakpoint は番号を指定してブレークポイントを削除する
+func (d *debugger) deleteBreakpoint(arg string) {
+	id, err := strconv.Atoi(arg)
+	if err == nil {
+		for i, bp := range d.breakpoints {
+			if bp.ID == id {
+				d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+				fmt.Fprintf(d.out, "ブレークポイント %d を削除しました\n", id)
+				return
+			}
+		}
+	}
+	fmt.Fprintf(d.out, "ブレークポイントが見つかりません: %s\n", arg)
+}
+
+// print は式の値を表示する。式を省略した場合は🍕と💩を表示する
+func (d *debugger) print(arg string, f *debug.Frame) {
+	switch arg {
+	case "":
+		fmt.Fprintf(d.out, "🍕 = %s\n", debug.Inspect(f.Pizza()))
+		fmt.Fprintf(d.out, "💩 = %s\n", debug.Inspect(f.Poo()))
+		if d.session.LastReturn != nil {
+			fmt.Fprintf(d.out, "直前の関数の戻り値 = %s\n", d.session.LastReturn.Inspect())
+		}
+		return
+	case "🍕":
+		fmt.Fprintf(d.out, "🍕 = %s\n", debug.Inspect(f.Pizza()))
+		return
+	case "💩":
+		fmt.Fprintf(d.out, "💩 = %s\n", debug.Inspect(f.Poo()))
+		return
+	}
+
+	expr, err := debug.ParseExpression(arg)
+	if err != nil {
+		fmt.Fprintf(d.out, "式を解析できませんでした: %s\n", err)
+		return
+	}
+	fmt.Fprintln(d.out, debug.Inspect(d.session.Evaluate(expr, f.Env)))
+}
+
+// printVariables は現在の環境の変数を名前順に表示する（組み込み関数は除く）
+func (d *debugger) printVariables(env *object.Environment) {
+	names, values := debug.Variables(env)
+	if len(names) == 0 {
+		fmt.Fprintln(d.out, "変数はありません")
+	}
+	for _, name := range names {
+		fmt.Fprintf(d.out, "%s = %s\n", name, debug.Inspect(values[name]))
+	}
+}
+
+// printBacktrace は内側の関数から順に呼び出し履歴を表示する
+func (d *debugger) printBacktrace() {
+	frames := d.session.Frames
+	for i := len(frames) - 1; i >= 0; i-- {
+		fmt.Fprintf(d.out, "#%d %s (%s:%d)\n", len(frames)-1-i, frames[i].Function, d.name, frames[i].Line)
+	}
+}
+
+// printSource は指定した行の前後のソースを表示する
+func (d *debugger) printSource(current int) {
+	const context = 3
+	for line := current - context; line <= current+context; line++ {
+		if line < 1 || line > len(d.lines) {
+			continue
+		}
+		marker := " "
+		if line == current {
+			marker = ">"
+		}
+		fmt.Fprintf(d.out, "%s%4d | %s\n", marker, line, strings.TrimRight(d.lines[line-1], " \t\r"))
+	}
+}
@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/uncode/config"
+	"github.com/uncode/dump"
+	"github.com/uncode/runtime"
+)
+
+// indentUnit は fmt が使うインデント1段分の文字列
+const indentUnit = "  "
+
+// bracketScanner は文字列とコメントの外にある括弧の深さを追跡する
+// 複数行にまたがる文字列や /* */ コメントの途中かどうかも行をまたいで保持する
+type bracketScanner struct {
+	inString  bool // 文字列リテラルの途中
+	inComment bool // /* */ コメントの途中
+}
+
+// scanLine は1行を読み、括弧の深さの増減を返す
+func (s *bracketScanner) scanLine(line string) int {
+	delta := 0
+	escaped := false
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		switch {
+		case s.inComment:
+			if ch == '*' && i+1 < len(runes) && runes[i+1] == '/' {
+				s.inComment = false
+				i++
+			}
+		case s.inString:
+			if escaped {
+				escaped = false
+			} else if ch == '\\' {
+				escaped = true
+			} else if ch == '"' {
+				s.inString = false
+			}
+		case ch == '"':
+			s.inString = true
+		case ch == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			return delta
+		case ch == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			s.inComment = true
+			i++
+		case ch == '{' || ch == '[' || ch == '(':
+			delta++
+		case ch == '}' || ch == ']' || ch == ')':
+			delta--
+		}
+	}
+	return delta
+}
+
+// incomplete は文字列やコメントが閉じられていないかどうかを返す
+func (s *bracketScanner) incomplete() bool {
+	return s.inString || s.inComment
+}
+
+// leadingClosers は行頭に続く閉じ括弧の数を返す
+func leadingClosers(trimmed string) int {
+	count := 0
+	for _, ch := range trimmed {
+		switch ch {
+		case '}', ']', ')':
+			count++
+		case ' ', '\t':
+		default:
+			return count
+		}
+	}
+	return count
+}
+
+// formatSource はソースコードのインデントと空白を整える
+// 括弧の深さに応じて2スペースでインデントし、行末の空白を取り除き、連続する空行を1行にまとめる
+// 複数行にまたがる文字列やコメントの中身は変更しない
+func formatSource(source string) string {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	var out []string
+	var scanner bracketScanner
+	depth := 0
+	pendingBlank := false
+
+	for i, line := range lines {
+		// 前の行から続く文字列やコメントの中はそのまま残す
+		if scanner.incomplete() {
+			depth += scanner.scanLine(line)
+			out = append(out, line)
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			pendingBlank = len(out) > 0
+			continue
+		}
+		if pendingBlank {
+			out = append(out, "")
+			pendingBlank = false
+		}
+
+		// 先頭の #! の行はそのまま残す
+		if i == 0 && strings.HasPrefix(line, "#!") {
+			out = append(out, strings.TrimRight(line, " \t"))
+			continue
+		}
+
+		lineDepth := depth - leadingClosers(trimmed)
+		if lineDepth < 0 {
+			lineDepth = 0
+		}
+		out = append(out, strings.Repeat(indentUnit, lineDepth)+trimmed)
+
+		depth += scanner.scanLine(trimmed)
+		if depth < 0 {
+			depth = 0
+		}
+	}
+
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// readSource はソースファイルを読み込む。"-" の場合は標準入力から読み込む
+func readSource(path string) (string, string, error) {
+	if path == config.StdinSourceFile {
+		content, err := io.ReadAll(os.Stdin)
+		return config.StdinSourceName, string(content), err
+	}
+	content, err := os.ReadFile(path)
+	return path, string(content), err
+}
+
+// readError はソースファイルを読み込めなかったことを表す診断を作成する
+func readError(path string, err error) runtime.Diagnostic {
+	return runtime.NewDiagnostic(runtime.CodeUsageError, path, fmt.Sprintf("ファイルを読み込めませんでした: %s", err))
+}
+
+// writeDiagnostics は診断を --diagnostics の形式で標準エラー出力に書き出し、終了コードを返す
+func writeDiagnostics(diagnostics []runtime.Diagnostic) int {
+	if config.GlobalConfig.Diagnostics == config.DiagnosticsJSON {
+		runtime.WriteDiagnosticsJSON(os.Stderr, diagnostics)
+	} else {
+		for _, d := range diagnostics {
+			fmt.Fprintln(os.Stderr, d)
+		}
+	}
+	if len(diagnostics) == 0 {
+		return runtime.ExitOK
+	}
+	return diagnostics[0].ExitCode()
+}
+
+// runFormat は fmt サブコマンドを実行する
+// write が false の場合は整形結果を標準出力に表示し、true の場合は変更のあったファイルに書き戻してその名前を表示する
+func runFormat(paths []string, write bool) int {
+	var diagnostics []runtime.Diagnostic
+	for _, path := range paths {
+		name, source, err := readSource(path)
+		if err != nil {
+			diagnostics = append(diagnostics, readError(path, err))
+			continue
+		}
+
+		// 構文エラーのあるファイルは整形しない
+		if found := runtime.CheckSource(name, source); len(found) > 0 {
+			diagnostics = append(diagnostics, found...)
+			continue
+		}
+
+		formatted := formatSource(source)
+		if !write || path == config.StdinSourceFile {
+			fmt.Print(formatted)
+			continue
+		}
+		if formatted == source {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(formatted), 0644); err != nil {
+			diagnostics = append(diagnostics, runtime.NewDiagnostic(runtime.CodeUsageError, path, fmt.Sprintf("ファイルに書き込めませんでした: %s", err)))
+			continue
+		}
+		fmt.Println(path)
+	}
+	return writeDiagnostics(diagnostics)
+}
+
+// runCheck は check サブコマンドを実行する
+// すべてのファイルの構文エラーを表示し、最初のエラーの種類に対応する終了コードを返す
+func runCheck(paths []string) int {
+	var diagnostics []runtime.Diagnostic
+	for _, path := range paths {
+		name, source, err := readSource(path)
+		if err != nil {
+			diagnostics = append(diagnostics, readError(path, err))
+			continue
+		}
+		diagnostics = append(diagnostics, runtime.CheckSource(name, source)...)
+	}
+	return writeDiagnostics(diagnostics)
+}
+
+// runDump は dump サブコマンドを実行する
+// トークン列や AST を標準出力に書き出す。構文エラーがある場合は診断を表示して何も書き出さない
+func runDump(path string, kind string, format string) int {
+	name, source, err := readSource(path)
+	if err != nil {
+		return writeDiagnostics([]runtime.Diagnostic{readError(path, err)})
+	}
+	if kind == "tokens" {
+		tokens, diagnostics := runtime.TokenizeSource(name, source)
+		if len(diagnostics) > 0 {
+			return writeDiagnostics(diagnostics)
+		}
+		err = dump.WriteTokens(os.Stdout, tokens, format)
+	} else {
+		program, diagnostics := runtime.ParseSource(name, source)
+		if len(diagnostics) > 0 {
+			return writeDiagnostics(diagnostics)
+		}
+		err = dump.WriteAST(os.Stdout, program, format)
+	}
+	if err != nil {
+		return writeDiagnostics([]runtime.Diagnostic{runtime.NewDiagnostic(runtime.CodeUsageError, path, fmt.Sprintf("書き出せませんでした: %s", err))})
+	}
+	return runtime.ExitOK
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/uncode/config"
+	"github.com/uncode/evaluator"
+	"github.com/uncode/object"
+	"github.com/uncode/runtime"
+)
+
+// REPL のプロンプト
+const (
+	replPrompt             = "poo> "
+	replContinuationPrompt = "...> "
+	replSourceName         = "<repl>"
+)
+
+// runREPL は repl サブコマンドを実行する
+// 入力を1行ずつ評価し、null 以外の結果を表示する。前の入力で定義した変数や関数は引き継がれる
+// 括弧や文字列が閉じていない間は続きの行を読み込んでからまとめて評価する
+func runREPL(in io.Reader, out io.Writer) int {
+	reader := bufio.NewReader(in)
+	// input や stdin_lines も REPL と同じ入力から読み込む
+	evaluator.SetInput(reader)
+
+	env := object.NewEnvironment()
+	runtime.SetupBuiltins(env)
+
+	fmt.Fprintf(out, "PooCode %s (:quit または Ctrl-D で終了)\n", config.Version)
+
+	var pending strings.Builder
+	for {
+		if pending.Len() == 0 {
+			fmt.Fprint(out, replPrompt)
+		} else {
+			fmt.Fprint(out, replContinuationPrompt)
+		}
+
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(out)
+			return 0
+		}
+		if pending.Len() == 0 && strings.TrimSpace(line) == ":quit" {
+			return 0
+		}
+		pending.WriteString(line)
+
+		source := pending.String()
+		if err == nil && needsMoreInput(source) {
+			continue
+		}
+		pending.Reset()
+		if strings.TrimSpace(source) == "" {
+			continue
+		}
+
+		// テキスト形式のエラーは runtime がログに出力する。JSON の場合は入力ごとに1行で表示する
+		result, execErr := runtime.ExecuteSourceInEnvironment(replSourceName, source, env)
+		if errObj, ok := result.Result.(*object.Error); ok && errObj.Exit {
+			return result.ExitCode
+		}
+		if execErr != nil && config.GlobalConfig.Diagnostics == config.DiagnosticsJSON {
+			runtime.WriteDiagnosticsJSON(out, result.Diagnostics)
+		}
+		if execErr != nil || result.Result == nil {
+			continue
+		}
+
+		value := result.Result
+		if returnValue, ok := value.(*object.ReturnValue); ok {
+			value = returnValue.Value
+		}
+		if value.Type() != object.NULL_OBJ {
+			fmt.Fprintln(out, value.Inspect())
+		}
+	}
+}
+
+// needsMoreInput は括弧・文字列・コメントが閉じていないかどうかを判定する
+func needsMoreInput(source string) bool {
+	var scanner bracketScanner
+	depth := 0
+	for _, line := range strings.Split(source, "\n") {
+		depth += scanner.scanLine(line)
+	}
+	return depth > 0 || scanner.incomplete()
+}
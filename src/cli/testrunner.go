@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uncode/config"
+	"github.com/uncode/coverage"
+	"github.com/uncode/evaluator"
+	"github.com/uncode/runtime"
+)
+
+// testFileSuffixes はテストとして実行するファイル名の末尾
+var testFileSuffixes = []string{"_test.poo", "_test.💩"}
+
+// isTestFile はファイル名がテストファイルかどうかを判定する
+func isTestFile(name string) bool {
+	for _, suffix := range testFileSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// findTestFiles は指定されたパスからテストファイルを探す
+// ディレクトリは再帰的に探し、ファイルが直接指定された場合は名前に関わらずテストとして扱う
+func findTestFiles(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && isTestFile(d.Name()) {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// runTests は test サブコマンドを実行する
+// 各テストファイルを実行し、エラーなく終了コード0で終わったものを成功とする
+// 失敗したテストはプログラムの出力も表示する
+func runTests(paths []string) int {
+	files, err := findTestFiles(paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "テストファイルを探せませんでした: %s\n", err)
+		return runtime.ExitUsageError
+	}
+	if len(files) == 0 {
+		fmt.Println("テストファイルが見つかりませんでした")
+		return 0
+	}
+
+	output := evaluator.Output()
+	defer evaluator.SetOutput(output)
+
+	// すべてのテストファイルのカバレッジを1つにまとめて書き出す
+	var cov *coverage.Coverage
+	if config.GlobalConfig.CoverageFile != "" {
+		cov = coverage.New()
+		evaluator.SetHook(cov)
+		defer evaluator.SetHook(nil)
+	}
+
+	failed := 0
+	for _, file := range files {
+		var captured bytes.Buffer
+		evaluator.SetOutput(&captured)
+
+		start := time.Now()
+		result, err := runtime.ExecuteSourceFile(file)
+		elapsed := time.Since(start).Seconds()
+		if cov != nil {
+			cov.Add(file, result.Source, result.Program)
+		}
+
+		if err == nil && result.ExitCode == 0 {
+			fmt.Printf("ok    %s (%.2fs)\n", file, elapsed)
+			continue
+		}
+
+		failed++
+		fmt.Printf("FAIL  %s (%.2fs)\n", file, elapsed)
+		if err != nil {
+			fmt.Printf("      %s\n", err)
+		} else {
+			fmt.Printf("      終了コード %d\n", result.ExitCode)
+		}
+		for _, line := range strings.Split(strings.TrimRight(captured.String(), "\n"), "\n") {
+			if line != "" {
+				fmt.Printf("      | %s\n", line)
+			}
+		}
+	}
+
+	fmt.Printf("\n%d 件中 %d 件成功、%d 件失敗\n", len(files), len(files)-failed, failed)
+	if cov != nil {
+		if err := writeCoverage(cov, config.GlobalConfig.CoverageFile); err != nil {
+			fmt.Fprintf(os.Stderr, "カバレッジを書き込めませんでした: %s\n", err)
+			return runtime.ExitUsageError
+		}
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
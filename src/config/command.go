@@ -0,0 +1,375 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Version はインタプリタのバージョン
+const Version = "0.1.0"
+
+// ErrHelp は -h / help でヘルプを表示したことを表す（エラーとしては扱わない）
+var ErrHelp = flag.ErrHelp
+
+// Command はコマンドラインで指定されたサブコマンドとその引数
+type Command struct {
+	Name   string   // サブコマンド名 (run, repl, debug, dap, check, fmt, dump, test, version, config)
+	Args   []string // フラグ以外の引数
+	Write  bool     // fmt -w: 整形結果をファイルに書き戻す
+	Dump   string   // dump: 出力する内容 (tokens / ast)
+	Format string   // dump --format: 出力形式 (json / dot / sexpr)
+}
+
+// commandSpec はサブコマンドの定義
+type commandSpec struct {
+	name    string
+	usage   string       // 使用方法の引数部分
+	summary string       // コマンド一覧に表示する説明
+	groups  settingGroup // 受け付ける設定項目の分類
+	// extraFlags はサブコマンド固有のフラグ（設定ファイルや環境変数では指定しない）を登録する
+	extraFlags func(fs *flag.FlagSet, cmd *Command)
+}
+
+// commands はサブコマンドの一覧
+var commands = []*commandSpec{
+	{
+		name:    "run",
+		usage:   "[オプション] <ファイル名> [-- 引数...]\n       uncode run [オプション] -e <ソースコード> [-- 引数...]\n       uncode run [オプション] - [-- 引数...]  (標準入力から読み込む)",
+		summary: "スクリプトを実行する（コマンドを省略した場合も run として扱う）",
+		groups:  groupLog | groupRuntime,
+		extraFlags: func(fs *flag.FlagSet, cmd *Command) {
+			fs.StringVar(&GlobalConfig.InlineCode, "e", "", "ファイルの代わりに実行するソースコード")
+		},
+	},
+	{
+		name:    "repl",
+		usage:   "[オプション]",
+		summary: "対話的にコードを評価する",
+		groups:  groupLog | groupRuntime,
+	},
+	{
+		name:    "debug",
+		usage:   "[オプション] <ファイル名> [-- 引数...]",
+		summary: "ブレークポイントやステップ実行でスクリプトをデバッグする",
+		groups:  groupLog | groupRuntime,
+	},
+	{
+		name:    "dap",
+		usage:   "[オプション]",
+		summary: "エディタから使う Debug Adapter Protocol のサーバーを標準入出力で起動する",
+		groups:  groupLog | groupRuntime,
+	},
+	{
+		name:    "check",
+		usage:   "[オプション] <ファイル名>...",
+		summary: "スクリプトを実行せずに構文を検査する",
+		groups:  groupLog,
+	},
+	{
+		name:    "fmt",
+		usage:   "[オプション] <ファイル名>...",
+		summary: "スクリプトのインデントと空白を整える",
+		groups:  groupLog,
+		extraFlags: func(fs *flag.FlagSet, cmd *Command) {
+			fs.BoolVar(&cmd.Write, "w", false, "整形結果を標準出力ではなくファイルに書き戻す")
+		},
+	},
+	{
+		name:    "dump",
+		usage:   "tokens|ast [オプション] <ファイル名>",
+		summary: "トークン列や構文木を位置付きで JSON・Graphviz・S式として出力する",
+		groups:  groupLog,
+		extraFlags: func(fs *flag.FlagSet, cmd *Command) {
+			fs.StringVar(&cmd.Format, "format", DumpFormatJSON, "出力形式 (json, dot, sexpr)")
+		},
+	},
+	{
+		name:    "test",
+		usage:   "[オプション] [ディレクトリまたはファイル名...]",
+		summary: "*_test.poo を実行し、終了コードが0かどうかで結果を表示する",
+		groups:  groupLog | groupRuntime,
+	},
+	{
+		name:    "version",
+		usage:   "",
+		summary: "バージョンを表示する",
+	},
+	{
+		name:    "config",
+		usage:   "show [オプション] [ファイル名]",
+		summary: "有効な設定とその出どころを表示する",
+		groups:  groupLog | groupRuntime,
+	},
+}
+
+// lookupCommand は名前からサブコマンドを探す
+func lookupCommand(name string) *commandSpec {
+	for _, spec := range commands {
+		if spec.name == name {
+			return spec
+		}
+	}
+	return nil
+}
+
+// recordedFlag は指定されたフラグの値を記録する flag.Value
+// 設定ファイルや環境変数の後に適用するため、パース中は GlobalConfig に書き込まない
+type recordedFlag struct {
+	setting *setting
+	values  *[]settingValue
+}
+
+// String は flag.Value インターフェースの実装
+func (f *recordedFlag) String() string {
+	// 真偽値の false はヘルプに既定値として表示しない（flag パッケージの BoolVar と同じ表示にする）
+	if f == nil || f.setting == nil || f.setting.defaultVal == "false" {
+		return ""
+	}
+	return f.setting.defaultVal
+}
+
+// Set は flag.Value インターフェースの実装
+func (f *recordedFlag) Set(value string) error {
+	// 値が正しいかをその場で確認する
+	if err := f.setting.set(newScratchConfig(), value); err != nil {
+		return err
+	}
+
+	// --allow-read のようなフラグは繰り返し指定すると許可が追加される
+	if f.setting.accumulate {
+		for i, v := range *f.values {
+			if v.name == f.setting.name {
+				(*f.values)[i].value = joinPermissions(v.value, value)
+				return nil
+			}
+		}
+	}
+	*f.values = append(*f.values, settingValue{name: f.setting.name, value: value})
+	return nil
+}
+
+// IsBoolFlag は値なしのフラグを受け付けるための flag パッケージ向けの実装
+func (f *recordedFlag) IsBoolFlag() bool {
+	return f.setting.isBool
+}
+
+// joinPermissions は許可の一覧を結合する。どちらかがすべて許可なら、すべて許可になる
+func joinPermissions(a, b string) string {
+	if a == "true" || b == "true" {
+		return "true"
+	}
+	return a + "," + b
+}
+
+// newScratchConfig は値の検証に使う一時的な設定を作成する
+func newScratchConfig() *Config {
+	c := &Config{}
+	resetConfig(c)
+	return c
+}
+
+// newFlagSet はサブコマンドのフラグを登録した FlagSet を作成する
+func newFlagSet(spec *commandSpec, cmd *Command, values *[]settingValue) *flag.FlagSet {
+	fs := flag.NewFlagSet(spec.name, flag.ContinueOnError)
+	for _, s := range settings {
+		if s.group&spec.groups != 0 {
+			fs.Var(&recordedFlag{setting: s, values: values}, s.name, s.usage)
+		}
+	}
+	if spec.extraFlags != nil {
+		spec.extraFlags(fs, cmd)
+	}
+	return fs
+}
+
+// ParseCommandLine はコマンドライン引数（プログラム名を除く）をパースし、設定を行う
+// 設定はデフォルト値、設定ファイル (poo.toml / .poorc)、POO_* 環境変数、フラグの順に重ねる
+func ParseCommandLine(args []string) (*Command, error) {
+	// サブコマンドを省略した場合は run として扱う (uncode script.poo)
+	spec := commands[0]
+	if len(args) > 0 {
+		if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+			if len(args) > 1 && lookupCommand(args[1]) != nil {
+				PrintUsage(os.Stdout, args[1])
+			} else {
+				PrintUsage(os.Stdout, "")
+			}
+			return nil, ErrHelp
+		}
+		if found := lookupCommand(args[0]); found != nil {
+			spec = found
+			args = args[1:]
+		}
+	}
+
+	cmd := &Command{Name: spec.name}
+	if spec.name == "config" {
+		if len(args) == 0 || args[0] != "show" {
+			return nil, &InvalidArgsError{Message: "config には show を指定してください (uncode config show)"}
+		}
+		args = args[1:]
+	}
+	if spec.name == "dump" {
+		if len(args) == 0 || (args[0] != "tokens" && args[0] != "ast") {
+			return nil, &InvalidArgsError{Message: "dump には tokens か ast を指定してください (uncode dump ast main.poo)"}
+		}
+		cmd.Dump = args[0]
+		args = args[1:]
+	}
+
+	GlobalConfig.InlineCode = ""
+	var flagValues []settingValue
+	fs := newFlagSet(spec, cmd, &flagValues)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			PrintUsage(os.Stdout, spec.name)
+			return nil, ErrHelp
+		}
+		return nil, &InvalidArgsError{Message: err.Error()}
+	}
+	cmd.Args = fs.Args()
+	inlineCode := GlobalConfig.InlineCode
+
+	// 引数の確認と、設定ファイルを探し始める場所の決定
+	searchFrom := "."
+	switch spec.name {
+	case "run":
+		if inlineCode == "" {
+			if len(cmd.Args) == 0 {
+				return nil, &InvalidArgsError{Message: "ソースファイルが指定されていません"}
+			}
+			if cmd.Args[0] != StdinSourceFile {
+				if err := checkExtension(cmd.Args[0]); err != nil {
+					return nil, err
+				}
+				searchFrom = filepath.Dir(cmd.Args[0])
+			}
+		}
+	case "debug":
+		// 標準入力はデバッガのコマンドの入力に使う
+		if len(cmd.Args) == 0 || cmd.Args[0] == StdinSourceFile {
+			return nil, &InvalidArgsError{Message: "デバッグするソースファイルを指定してください"}
+		}
+		if err := checkExtension(cmd.Args[0]); err != nil {
+			return nil, err
+		}
+		searchFrom = filepath.Dir(cmd.Args[0])
+	case "check", "fmt":
+		if len(cmd.Args) == 0 {
+			return nil, &InvalidArgsError{Message: "ソースファイルが指定されていません"}
+		}
+		searchFrom = searchDir(cmd.Args[0])
+	case "dump":
+		if len(cmd.Args) != 1 {
+			return nil, &InvalidArgsError{Message: "dump にはソースファイルを1つ指定してください"}
+		}
+		switch cmd.Format {
+		case DumpFormatJSON, DumpFormatDot, DumpFormatSexpr:
+		default:
+			return nil, &InvalidArgsError{Message: fmt.Sprintf("format には %s, %s, %s のいずれかを指定してください: %q", DumpFormatJSON, DumpFormatDot, DumpFormatSexpr, cmd.Format)}
+		}
+		searchFrom = searchDir(cmd.Args[0])
+	case "test", "config":
+		if len(cmd.Args) > 0 {
+			searchFrom = searchDir(cmd.Args[0])
+		}
+	case "repl", "dap", "version":
+		if len(cmd.Args) > 0 {
+			return nil, &InvalidArgsError{Message: fmt.Sprintf("%s は引数を取りません: %s", spec.name, strings.Join(cmd.Args, " "))}
+		}
+	}
+
+	if err := applyLayers(&GlobalConfig, FindConfigFile(searchFrom), flagValues); err != nil {
+		return nil, err
+	}
+
+	// 実行するソースコードとスクリプトへの引数
+	if spec.name == "run" || spec.name == "debug" {
+		if inlineCode != "" {
+			GlobalConfig.InlineCode = inlineCode
+			GlobalConfig.SourceFile = InlineSourceName
+			GlobalConfig.ScriptArgs = scriptArgs(cmd.Args)
+		} else {
+			GlobalConfig.SourceFile = cmd.Args[0]
+			GlobalConfig.ScriptArgs = scriptArgs(cmd.Args[1:])
+		}
+	}
+
+	return cmd, nil
+}
+
+// checkExtension はソースファイルの拡張子を確認する
+// #! で実行するスクリプトのために拡張子のないファイルも受け付ける
+func checkExtension(path string) error {
+	ext := filepath.Ext(path)
+	if ext != "" && ext != ".poo" && ext != ".💩" {
+		return &UnsupportedExtensionError{
+			Extension: ext,
+		}
+	}
+	return nil
+}
+
+// searchDir は設定ファイルを探し始めるディレクトリを返す
+func searchDir(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return path
+	}
+	return filepath.Dir(path)
+}
+
+// PrintUsage はコマンドの使用方法を表示する
+// name が空の場合はサブコマンドの一覧を表示する
+func PrintUsage(w io.Writer, name string) {
+	spec := lookupCommand(name)
+	if spec == nil {
+		fmt.Fprintln(w, "使用方法: uncode <コマンド> [オプション] [引数...]")
+		fmt.Fprintln(w, "          uncode [オプション] <ファイル名> [-- 引数...]  (uncode run と同じ)")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "コマンド:")
+		for _, c := range commands {
+			fmt.Fprintf(w, "  %-8s %s\n", c.name, c.summary)
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "各コマンドのオプションは 'uncode help <コマンド>' で確認できます。")
+		fmt.Fprintln(w, "設定はデフォルト値、poo.toml / .poorc、POO_* 環境変数、フラグの順に上書きされます。")
+		return
+	}
+
+	fmt.Fprintf(w, "使用方法: uncode %s %s\n", spec.name, spec.usage)
+	fmt.Fprintf(w, "%s\n", spec.summary)
+
+	var values []settingValue
+	fs := newFlagSet(spec, &Command{}, &values)
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "オプション:")
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+	}
+}
+
+// PrintSettings は有効な設定とその出どころを設定ファイルの形式で表示する
+func PrintSettings(w io.Writer, c *Config) {
+	if c.ConfigFile != "" {
+		fmt.Fprintf(w, "# 設定ファイル: %s\n", c.ConfigFile)
+	} else {
+		fmt.Fprintln(w, "# 設定ファイル: なし")
+	}
+	for _, s := range settings {
+		value := s.get(c)
+		formatted := fmt.Sprintf("%q", value)
+		if s.isBool && (value == "true" || value == "false") {
+			formatted = value
+		}
+		fmt.Fprintf(w, "%s = %s  # %s\n", s.name, formatted, c.Sources[s.name])
+	}
+}
@@ -1,10 +1,10 @@
 package config
 
 import (
-	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/uncode/logger"
 )
@@ -12,12 +12,17 @@ import (
 // Config はアプリケーション全体の設定を保持する構造体
 type Config struct {
 	SourceFile           string
+	InlineCode           string // -e で指定されたソースコード
 	DebugMode            bool
 	LogLevel             logger.LogLevel
 	ComponentLogLevels   map[logger.ComponentType]logger.LogLevel
 	SpecialLogLevels     map[logger.LogLevel]bool  // 特殊なログレベルの有効/無効
 	LogFile              string
+	LogFormat            string // ログの出力形式 (text / json)
+	ComponentLogFiles    map[logger.ComponentType]string // コンポーネントごとのログの出力先（指定がなければ標準出力）
+	FlightRecorder       int    // 異常終了の際に書き出す直近のログの件数（0 の場合は記録しない）
 	OutputFile           string
+	Quiet                bool // プログラムの出力を標準出力に表示しない
 	ColorOutput          bool
 	ShowTimestamp        bool
 	ShowTypeInfo         bool
@@ -29,141 +34,232 @@ type Config struct {
 	ShowPipelineDebug    bool // パイプライン処理のデバッグ表示
 	ShowMapFilterDebug   bool // map/filter演算子のデバッグ表示
 	PreregisterFunctions bool // 関数を事前に登録する
-}
-
-// GlobalConfig はアプリケーション全体で使用される設定
-var GlobalConfig Config
+	StrictMode           bool // 暗黙の型変換を無効にする厳密モード
+	MaxCallDepth         int  // 関数呼び出しの深さの上限（0 の場合は上限なし）
+	Diagnostics          string // エラーの出力形式 (text / json)
+	ProfileFile          string // プロファイルの出力先（空の場合はプロファイルしない）
+	ProfileFormat        string // プロファイルの形式 (pprof / folded)
+	TraceMermaidFile     string // 実行の流れを表す Mermaid のシーケンス図の出力先（空の場合は記録しない）
+	TraceFile            string // Chrome Trace Event 形式のトレースの出力先（空の場合は記録しない）
+	CoverageFile         string // lcov 形式のカバレッジの出力先（空の場合は記録しない）
+	AllowRead            PathPermission // ファイルの読み込みを許可するパス (--allow-read)
+	AllowWrite           PathPermission // ファイルの書き込みを許可するパス (--allow-write)
+	AllowEnv             NamePermission // 読み込みを許可する環境変数 (--allow-env)
+	ScriptArgs           []string       // ソースファイルより後ろに指定されたスクリプトへの引数
+	ConfigFile           string            // 読み込んだ設定ファイル (poo.toml / .poorc)
+	Sources              map[string]Source // 各設定項目の値の出どころ
 
-// カスタムエラー型
-type InvalidArgsError struct {
-	Message string
+	logLevelSpecified bool // log-level が明示的に指定されたかどうか
 }
 
-func (e *InvalidArgsError) Error() string {
-	return fmt.Sprintf("引数エラー: %s", e.Message)
+// PathPermission はファイルシステムへのアクセスを許可するパスの一覧を表す
+// --allow-read のように値なしで指定した場合はすべてのパスを許可する
+type PathPermission struct {
+	All   bool     // すべてのパスを許可する
+	Paths []string // 許可するパス（絶対パス）。ディレクトリの場合は配下もすべて許可する
 }
 
-type UnsupportedExtensionError struct {
-	Extension string
+// String は flag.Value インターフェースの実装
+func (p *PathPermission) String() string {
+	if p == nil {
+		return ""
+	}
+	if p.All {
+		return "*"
+	}
+	return strings.Join(p.Paths, ",")
 }
 
-func (e *UnsupportedExtensionError) Error() string {
-	return fmt.Sprintf("エラー: サポートされていないファイル拡張子です: %s", e.Extension)
+// Set は flag.Value インターフェースの実装
+// カンマ区切りで複数のパスを指定でき、フラグを繰り返し指定することもできる
+// 値なし（true）はすべてのパスを許可し、空の値（--allow-read=）は何も許可しない
+func (p *PathPermission) Set(value string) error {
+	if value == "true" {
+		p.All = true
+		return nil
+	}
+	for _, path := range strings.Split(value, ",") {
+		if path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("パス %s を解決できませんでした: %w", path, err)
+		}
+		p.Paths = append(p.Paths, abs)
+	}
+	return nil
 }
 
-// ParseFlags はコマンドライン引数をパースし、設定を行う
-func ParseFlags() error {
-	// マップの初期化
-	GlobalConfig.ComponentLogLevels = make(map[logger.ComponentType]logger.LogLevel)
-	GlobalConfig.SpecialLogLevels = make(map[logger.LogLevel]bool)
-	
-	// コマンドラインフラグのパース
-	flag.BoolVar(&GlobalConfig.DebugMode, "debug", false, "デバッグモードを有効にする")
-	flag.StringVar(&GlobalConfig.LogFile, "log", "", "ログファイルのパス (指定がなければ標準出力のみ)")
-	flag.StringVar(&GlobalConfig.OutputFile, "output", "", "出力ファイルのパス (tee で出力を記録)")
-	flag.BoolVar(&GlobalConfig.ColorOutput, "color", true, "カラー出力を有効にする")
-	flag.BoolVar(&GlobalConfig.ShowTimestamp, "timestamp", true, "タイムスタンプを表示する")
-	flag.BoolVar(&GlobalConfig.ShowTypeInfo, "show-types", false, "型情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowLexerDebug, "show-lexer", false, "レキサーのデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowParserDebug, "show-parser", false, "パーサーのデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowEvalDebug, "show-eval", false, "評価時のデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowBuiltinDebug, "show-builtin", false, "組み込み関数のデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowConditionDebug, "show-condition", false, "条件式評価のデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowPipelineDebug, "show-pipeline", false, "パイプライン処理のデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowMapFilterDebug, "show-map-filter", false, "map/filter演算子のデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.PreregisterFunctions, "preregister", true, "関数を事前に登録する (ASTを2回走査)")
-
-	// ログレベルをフラグで指定できるようにする
-	logLevelStr := flag.String("log-level", "", "グローバルログレベル (OFF, ERROR, WARN, INFO, DEBUG, TRACE)")
-	
-	// コンポーネント別ログレベルの設定
-	lexerLogLevelStr := flag.String("lexer-log-level", "", "レキサーのログレベル (OFF, ERROR, WARN, INFO, DEBUG, TRACE)")
-	parserLogLevelStr := flag.String("parser-log-level", "", "パーサーのログレベル")
-	evalLogLevelStr := flag.String("eval-log-level", "", "評価器のログレベル")
-	runtimeLogLevelStr := flag.String("runtime-log-level", "", "ランタイムのログレベル")
-	builtinLogLevelStr := flag.String("builtin-log-level", "", "組み込み関数のログレベル")
+// IsBoolFlag は値なしの --allow-read を受け付けるための flag パッケージ向けの実装
+func (p *PathPermission) IsBoolFlag() bool {
+	return true
+}
 
-	flag.Parse()
-	
-	// 環境変数からもパイプライン/map/filterデバッグを設定可能に
-	if os.Getenv("POO_PIPE_DEBUG") == "1" {
-		GlobalConfig.ShowPipelineDebug = true
+// Allows は指定したパスへのアクセスが許可されているかを判定する
+// 許可ディレクトリ内のシンボリックリンクで外に出られないように、許可したパスと対象のパスの両方の
+// シンボリックリンクを解決してから比較する
+func (p *PathPermission) Allows(path string) bool {
+	if p.All {
+		return true
 	}
-	
-	if os.Getenv("POO_MAP_FILTER_DEBUG") == "1" {
-		GlobalConfig.ShowMapFilterDebug = true
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range p.Paths {
+		allowedResolved, err := resolvePath(allowed)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(allowedResolved, resolved)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return true
+		}
 	}
+	return false
+}
 
-	// グローバルログレベルの設定
-	if *logLevelStr != "" {
-		GlobalConfig.LogLevel = logger.ParseLogLevel(*logLevelStr)
-	} else if GlobalConfig.DebugMode {
-		GlobalConfig.LogLevel = logger.LevelDebug
-	} else {
-		GlobalConfig.LogLevel = logger.LevelInfo
+// resolvePath はパスを絶対パスにし、シンボリックリンクを解決する
+// まだ存在しないパス（新しく書き込むファイルなど）は、存在する親ディレクトリまでを解決して残りを付け足す
+// 解決できないシンボリックリンク（リンク先が存在しないもの）はリンク先を確認できないためエラーにする
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
 	}
-	
-	// コンポーネント別ログレベルの設定
-	if *lexerLogLevelStr != "" {
-		GlobalConfig.ComponentLogLevels[logger.ComponentLexer] = logger.ParseLogLevel(*lexerLogLevelStr)
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err == nil {
+		return resolved, nil
 	}
-	
-	if *parserLogLevelStr != "" {
-		GlobalConfig.ComponentLogLevels[logger.ComponentParser] = logger.ParseLogLevel(*parserLogLevelStr)
+	if !os.IsNotExist(err) {
+		return "", err
 	}
-	
-	if *evalLogLevelStr != "" {
-		GlobalConfig.ComponentLogLevels[logger.ComponentEval] = logger.ParseLogLevel(*evalLogLevelStr)
+	if _, lerr := os.Lstat(abs); lerr == nil {
+		return "", err
 	}
-	
-	if *runtimeLogLevelStr != "" {
-		GlobalConfig.ComponentLogLevels[logger.ComponentRuntime] = logger.ParseLogLevel(*runtimeLogLevelStr)
+	parent := filepath.Dir(abs)
+	if parent == abs {
+		return abs, nil
 	}
-	
-	if *builtinLogLevelStr != "" {
-		GlobalConfig.ComponentLogLevels[logger.ComponentBuiltin] = logger.ParseLogLevel(*builtinLogLevelStr)
+	resolvedParent, err := resolvePath(parent)
+	if err != nil {
+		return "", err
 	}
+	return filepath.Join(resolvedParent, filepath.Base(abs)), nil
+}
 
-	// デバッグフラグを設定した場合は自動的に対応するデバッグを有効にする
-	if GlobalConfig.DebugMode {
-		GlobalConfig.ShowLexerDebug = true
-		GlobalConfig.ShowParserDebug = true 
-		GlobalConfig.ShowEvalDebug = true
-		GlobalConfig.ShowBuiltinDebug = true
-		GlobalConfig.ShowConditionDebug = true
-		GlobalConfig.ShowPipelineDebug = true
-		GlobalConfig.ShowMapFilterDebug = true
-		
-		// 特殊デバッグログレベルも有効化
-		GlobalConfig.SpecialLogLevels[logger.LevelTypeInfo] = GlobalConfig.ShowTypeInfo
-		GlobalConfig.SpecialLogLevels[logger.LevelEvalDebug] = GlobalConfig.ShowEvalDebug
-		GlobalConfig.SpecialLogLevels[logger.LevelParserDebug] = GlobalConfig.ShowParserDebug
-	} else {
-		// デバッグモードでない場合の設定
-		GlobalConfig.SpecialLogLevels[logger.LevelTypeInfo] = GlobalConfig.ShowTypeInfo
-		GlobalConfig.SpecialLogLevels[logger.LevelEvalDebug] = GlobalConfig.ShowEvalDebug
-		GlobalConfig.SpecialLogLevels[logger.LevelParserDebug] = GlobalConfig.ShowParserDebug
+// NamePermission は読み込みを許可する環境変数名の一覧を表す
+// --allow-env のように値なしで指定した場合はすべての環境変数を許可する
+type NamePermission struct {
+	All   bool     // すべての名前を許可する
+	Names []string // 許可する名前
+}
+
+// String は flag.Value インターフェースの実装
+func (p *NamePermission) String() string {
+	if p == nil {
+		return ""
+	}
+	if p.All {
+		return "*"
 	}
+	return strings.Join(p.Names, ",")
+}
 
-	// ソースファイルのパス取得
-	args := flag.Args()
-	if len(args) != 1 {
-		return &InvalidArgsError{
-			Message: "ソースファイルが指定されていません",
+// Set は flag.Value インターフェースの実装
+// カンマ区切りで複数の名前を指定でき、フラグを繰り返し指定することもできる
+// 値なし（true）はすべての名前を許可し、空の値（--allow-env=）は何も許可しない
+func (p *NamePermission) Set(value string) error {
+	if value == "true" {
+		p.All = true
+		return nil
+	}
+	for _, name := range strings.Split(value, ",") {
+		if name != "" {
+			p.Names = append(p.Names, name)
 		}
 	}
+	return nil
+}
 
-	GlobalConfig.SourceFile = args[0]
+// IsBoolFlag は値なしの --allow-env を受け付けるための flag パッケージ向けの実装
+func (p *NamePermission) IsBoolFlag() bool {
+	return true
+}
 
-	// ファイル拡張子のチェック
-	ext := filepath.Ext(GlobalConfig.SourceFile)
-	if ext != ".poo" && ext != ".💩" {
-		return &UnsupportedExtensionError{
-			Extension: ext,
+// Allows は指定した名前が許可されているかを判定する
+func (p *NamePermission) Allows(name string) bool {
+	if p.All {
+		return true
+	}
+	for _, allowed := range p.Names {
+		if allowed == name {
+			return true
 		}
 	}
+	return false
+}
 
-	return nil
+// GlobalConfig はアプリケーション全体で使用される設定
+var GlobalConfig Config
+
+// カスタムエラー型
+type InvalidArgsError struct {
+	Message string
+}
+
+func (e *InvalidArgsError) Error() string {
+	return fmt.Sprintf("引数エラー: %s", e.Message)
+}
+
+type UnsupportedExtensionError struct {
+	Extension string
+}
+
+func (e *UnsupportedExtensionError) Error() string {
+	return fmt.Sprintf("エラー: サポートされていないファイル拡張子です: %s", e.Extension)
+}
+
+// DefaultMaxCallDepth は関数呼び出しの深さの上限のデフォルト値
+// Go のスタックを使い切ってプロセスが異常終了する前に、上限超過のエラーとして止める
+const DefaultMaxCallDepth = 100000
+
+// エラーの出力形式 (--diagnostics)
+const (
+	DiagnosticsText = "text" // ログとして人が読める形式で出力する
+	DiagnosticsJSON = "json" // 標準エラー出力に JSON の配列として出力する
+)
+
+// プロファイルの形式 (--profile-format)
+const (
+	ProfileFormatPprof  = "pprof"  // go tool pprof で読める gzip 圧縮した protobuf
+	ProfileFormatFolded = "folded" // フレームグラフ用の折りたたみ形式 (flamegraph.pl など)
+)
+
+// dump サブコマンドの出力形式 (--format)
+const (
+	DumpFormatJSON  = "json"  // 外部のツールから読み込む JSON
+	DumpFormatDot   = "dot"   // Graphviz の有向グラフ
+	DumpFormatSexpr = "sexpr" // 人が読むためのS式
+)
+
+// ソースコードをファイル以外から読み込む場合の名前
+const (
+	StdinSourceFile  = "-"       // 標準入力から読み込む場合に指定するファイル名
+	StdinSourceName  = "<stdin>" // 標準入力から読み込んだソースコードの診断メッセージ上の名前
+	InlineSourceName = "<-e>"    // -e で指定したソースコードの診断メッセージ上の名前
+)
+
+// scriptArgs はスクリプトに渡す引数から、先頭の区切りの "--" を取り除く
+func scriptArgs(args []string) []string {
+	if len(args) > 0 && args[0] == "--" {
+		return args[1:]
+	}
+	return args
 }
 
 // SetupLogger はロガーの設定を行う
@@ -232,45 +328,21 @@ func SetupLogger() error {
 		}
 		logger.SetFileOutput(f)
 	}
-	
-	return nil
-}
 
-// PrintUsage はコマンドの使用方法を表示する
-func PrintUsage() {
-	fmt.Println("使用方法: uncode [オプション] <ファイル名>")
-	fmt.Println("オプション:")
-	
-	// config.goのGlobalConfigを初期化して全てのフラグ定義を呼び出す
-	GlobalConfig.ComponentLogLevels = make(map[logger.ComponentType]logger.LogLevel)
-	GlobalConfig.SpecialLogLevels = make(map[logger.LogLevel]bool)
-	
-	// コマンドラインフラグの定義（しかしParseはしない）
-	flag.BoolVar(&GlobalConfig.DebugMode, "debug", false, "デバッグモードを有効にする")
-	flag.StringVar(&GlobalConfig.LogFile, "log", "", "ログファイルのパス (指定がなければ標準出力のみ)")
-	flag.StringVar(&GlobalConfig.OutputFile, "output", "", "出力ファイルのパス (tee で出力を記録)")
-	flag.BoolVar(&GlobalConfig.ColorOutput, "color", true, "カラー出力を有効にする")
-	flag.BoolVar(&GlobalConfig.ShowTimestamp, "timestamp", true, "タイムスタンプを表示する")
-	flag.BoolVar(&GlobalConfig.ShowTypeInfo, "show-types", false, "型情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowLexerDebug, "show-lexer", false, "レキサーのデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowParserDebug, "show-parser", false, "パーサーのデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowEvalDebug, "show-eval", false, "評価時のデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowBuiltinDebug, "show-builtin", false, "組み込み関数のデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowConditionDebug, "show-condition", false, "条件式評価のデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowPipelineDebug, "show-pipeline", false, "パイプライン処理のデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.ShowMapFilterDebug, "show-map-filter", false, "map/filter演算子のデバッグ情報を表示する")
-	flag.BoolVar(&GlobalConfig.PreregisterFunctions, "preregister", true, "関数を事前に登録する (ASTを2回走査)")
-	
-	flag.String("log-level", "", "グローバルログレベル (OFF, ERROR, WARN, INFO, DEBUG, TRACE)")
-	flag.String("lexer-log-level", "", "レキサーのログレベル (OFF, ERROR, WARN, INFO, DEBUG, TRACE)")
-	flag.String("parser-log-level", "", "パーサーのログレベル")
-	flag.String("eval-log-level", "", "評価器のログレベル")
-	flag.String("runtime-log-level", "", "ランタイムのログレベル")
-	flag.String("builtin-log-level", "", "組み込み関数のログレベル")
+	// ログの出力形式の設定
+	logger.SetFormat(logger.Format(GlobalConfig.LogFormat))
+
+	// コンポーネントごとのログの出力先の設定
+	for component, path := range GlobalConfig.ComponentLogFiles {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("%s のログファイルを開けませんでした: %w", component, err)
+		}
+		logger.SetComponentOutput(component, f)
+	}
+
+	// フライトレコーダーの設定
+	logger.SetFlightRecorder(GlobalConfig.FlightRecorder)
 	
-	flag.PrintDefaults()
-	fmt.Println("\n環境変数:")
-	fmt.Println("  POO_PIPE_DEBUG=1       パイプライン処理のデバッグログを有効にする")
-	fmt.Println("  POO_MAP_FILTER_DEBUG=1 map/filter演算子のデバッグログを有効にする")
-	fmt.Println("\nサポートされている拡張子: .poo, .💩")
+	return nil
 }
@@ -0,0 +1,296 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uncode/logger"
+)
+
+// withGlobalConfig はテスト後に GlobalConfig を元に戻す
+func withGlobalConfig(t *testing.T) {
+	t.Helper()
+	prev := GlobalConfig
+	t.Cleanup(func() { GlobalConfig = prev })
+}
+
+// writeFile はテスト用のファイルを作成する
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSettingLayers は設定がデフォルト値・設定ファイル・環境変数・フラグの順に上書きされることをテストする
+func TestSettingLayers(t *testing.T) {
+	withGlobalConfig(t)
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "poo.toml"), strings.Join([]string{
+		"# プロジェクトの設定",
+		`strict = true`,
+		`quiet = true`,
+		`log-level = "warn"  # 大文字小文字は区別しない`,
+		`log = "logs/uncode.log"`,
+	}, "\n"))
+	script := filepath.Join(dir, "src", "nested", "main.poo")
+	writeFile(t, script, `1 |> print;`)
+
+	t.Setenv("POO_QUIET", "false")
+	t.Setenv("POO_PIPE_DEBUG", "1")
+
+	cmd, err := ParseCommandLine([]string{"-strict=false", script, "--", "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cmd.Name != "run" {
+		t.Errorf("command should default to run. got=%q", cmd.Name)
+	}
+
+	c := &GlobalConfig
+	configFile := filepath.Join(dir, "poo.toml")
+	if c.ConfigFile != configFile {
+		t.Errorf("config file should be found from parent directories. got=%q", c.ConfigFile)
+	}
+
+	tests := []struct {
+		name   string
+		value  string
+		source Source
+	}{
+		{"strict", "false", Source{Kind: SourceFlag, Name: "strict"}},
+		{"quiet", "false", Source{Kind: SourceEnv, Name: "POO_QUIET"}},
+		{"show-pipeline", "true", Source{Kind: SourceEnv, Name: "POO_PIPE_DEBUG"}},
+		{"log-level", "WARN", Source{Kind: SourceFile, Name: configFile}},
+		{"log", filepath.Join(dir, "logs", "uncode.log"), Source{Kind: SourceFile, Name: configFile}},
+		{"preregister", "true", Source{Kind: SourceDefault}},
+	}
+	for _, tt := range tests {
+		s := lookupSetting(tt.name)
+		if got := s.get(c); got != tt.value {
+			t.Errorf("%s: wrong value. expected=%q, got=%q", tt.name, tt.value, got)
+		}
+		if got := c.Sources[tt.name]; got != tt.source {
+			t.Errorf("%s: wrong source. expected=%v, got=%v", tt.name, tt.source, got)
+		}
+	}
+
+	if c.LogLevel != logger.LevelWarn {
+		t.Errorf("wrong log level. got=%v", c.LogLevel)
+	}
+	if c.SourceFile != script || len(c.ScriptArgs) != 1 || c.ScriptArgs[0] != "a" {
+		t.Errorf("wrong source file or script args. file=%q, args=%v", c.SourceFile, c.ScriptArgs)
+	}
+}
+
+// TestParseCommandLine はサブコマンドごとの引数とフラグの扱いをテストする
+func TestParseCommandLine(t *testing.T) {
+	withGlobalConfig(t)
+
+	cmd, err := ParseCommandLine([]string{"fmt", "-w", "a.poo", "b.poo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cmd.Name != "fmt" || !cmd.Write || len(cmd.Args) != 2 {
+		t.Errorf("wrong fmt command. got=%+v", cmd)
+	}
+
+	cmd, err = ParseCommandLine([]string{"run", "-e", "1 |> print", "x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if GlobalConfig.InlineCode != "1 |> print" || GlobalConfig.SourceFile != InlineSourceName || len(GlobalConfig.ScriptArgs) != 1 {
+		t.Errorf("wrong inline code settings. got=%+v", GlobalConfig)
+	}
+
+	cmd, err = ParseCommandLine([]string{"dump", "ast", "-format=sexpr", "a.poo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cmd.Dump != "ast" || cmd.Format != DumpFormatSexpr || len(cmd.Args) != 1 || cmd.Args[0] != "a.poo" {
+		t.Errorf("wrong dump command. got=%+v", cmd)
+	}
+
+	// 実行時の設定は実行しないサブコマンドでは受け付けない
+	errorTests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"check", "-strict", "a.poo"}, "引数エラー: flag provided but not defined: -strict"},
+		{[]string{"check"}, "引数エラー: ソースファイルが指定されていません"},
+		{[]string{"repl", "a.poo"}, "引数エラー: repl は引数を取りません: a.poo"},
+		{[]string{"config"}, "引数エラー: config には show を指定してください (uncode config show)"},
+		{[]string{"dump", "a.poo"}, "引数エラー: dump には tokens か ast を指定してください (uncode dump ast main.poo)"},
+		{[]string{"dump", "ast", "-format=yaml", "a.poo"}, `引数エラー: format には json, dot, sexpr のいずれかを指定してください: "yaml"`},
+		{[]string{"-log-level=LOUD", "a.poo"}, `引数エラー: invalid value "LOUD" for flag -log-level: log-level に不明なログレベルが指定されました: "LOUD"`},
+		{[]string{"-diagnostics=xml", "a.poo"}, `引数エラー: invalid value "xml" for flag -diagnostics: diagnostics には text, json のいずれかを指定してください: "xml"`},
+		{[]string{"a.txt"}, "エラー: サポートされていないファイル拡張子です: .txt"},
+	}
+	for _, tt := range errorTests {
+		_, err := ParseCommandLine(tt.args)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("args %v: expected error %q, got=%v", tt.args, tt.expected, err)
+		}
+	}
+}
+
+// TestReadConfigFile は設定ファイルの書式とエラーをテストする
+func TestReadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".poorc")
+	writeFile(t, path, "a = \"x # y\"\nb = ['c:\\dir', \"e\"]  # コメント\n\nc = 3\nd = false\n")
+
+	values, err := readConfigFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []settingValue{
+		{name: "a", value: "x # y", line: 1},
+		{name: "b", value: `c:\dir,e`, line: 2},
+		{name: "c", value: "3", line: 4},
+		{name: "d", value: "false", line: 5},
+	}
+	if len(values) != len(expected) {
+		t.Fatalf("wrong number of values. got=%+v", values)
+	}
+	for i := range expected {
+		if values[i] != expected[i] {
+			t.Errorf("values[%d] wrong. expected=%+v, got=%+v", i, expected[i], values[i])
+		}
+	}
+
+	errorTests := []struct {
+		content  string
+		expected string
+	}{
+		{"[run]\n", path + ":1: テーブルはサポートされていません: [run]"},
+		{"strict\n", path + ":1: 「キー = 値」の形式で指定してください: strict"},
+		{"\nlog = debug.log\n", path + ":2: log の値が不正です: 文字列は引用符で囲んでください: debug.log"},
+	}
+	for _, tt := range errorTests {
+		writeFile(t, path, tt.content)
+		if _, err := readConfigFile(path); err == nil || err.Error() != tt.expected {
+			t.Errorf("content %q: expected error %q, got=%v", tt.content, tt.expected, err)
+		}
+	}
+
+	// 不明なキーは行番号つきのエラーになる
+	withGlobalConfig(t)
+	writeFile(t, path, "strict = true\nstrikt = true\n")
+	err = applyLayers(&GlobalConfig, path, nil)
+	if err == nil || err.Error() != path+":2: 不明な設定項目です: strikt" {
+		t.Errorf("unknown key should be an error. got=%v", err)
+	}
+}
+
+// TestPermissionsOnlyFromFlags は権限を与える設定をフラグでのみ受け付けることをテストする
+func TestPermissionsOnlyFromFlags(t *testing.T) {
+	withGlobalConfig(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "poo.toml")
+
+	for _, name := range []string{"allow-read", "allow-write", "allow-env"} {
+		writeFile(t, path, "strict = true\n"+name+" = true\n")
+		err := applyLayers(&GlobalConfig, path, nil)
+		expected := fmt.Sprintf("%s:2: %s は権限を与える設定のため、設定ファイルでは指定できません (コマンドラインで --%s を指定してください)", path, name, name)
+		if err == nil || err.Error() != expected {
+			t.Errorf("%s in config file: expected error %q, got=%v", name, expected, err)
+		}
+	}
+
+	t.Setenv("POO_ALLOW_READ", "/")
+	err := applyLayers(&GlobalConfig, "", nil)
+	expected := "環境変数 POO_ALLOW_READ: allow-read は権限を与える設定のため、環境変数では指定できません (コマンドラインで --allow-read を指定してください)"
+	if err == nil || err.Error() != expected {
+		t.Errorf("POO_ALLOW_READ: expected error %q, got=%v", expected, err)
+	}
+	os.Unsetenv("POO_ALLOW_READ")
+
+	// フラグでは指定できる
+	err = applyLayers(&GlobalConfig, "", []settingValue{{name: "allow-read", value: dir}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !GlobalConfig.AllowRead.Allows(filepath.Join(dir, "a.txt")) {
+		t.Errorf("allow-read flag should be applied. got=%+v", GlobalConfig.AllowRead)
+	}
+}
+
+// TestEmptyPermissionGrantsNothing は空の値（--allow-read=）が何も許可しないことをテストする
+// すべてを許可するのは値なしのフラグ（--allow-read）か true を指定した場合だけ
+func TestEmptyPermissionGrantsNothing(t *testing.T) {
+	withGlobalConfig(t)
+	path := filepath.Join(t.TempDir(), "a.txt")
+
+	var read PathPermission
+	if err := read.Set(""); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if read.All || len(read.Paths) != 0 || read.Allows(path) {
+		t.Errorf("empty path permission should grant nothing. got=%+v", read)
+	}
+	if err := read.Set("true"); err != nil || !read.All {
+		t.Errorf("true should grant all paths. got=%+v (err=%v)", read, err)
+	}
+
+	var env NamePermission
+	if err := env.Set(""); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if env.All || len(env.Names) != 0 || env.Allows("HOME") {
+		t.Errorf("empty name permission should grant nothing. got=%+v", env)
+	}
+
+	tests := []struct {
+		args     []string
+		expected bool // すべて許可されるか
+	}{
+		{[]string{"-allow-read=", "-allow-write=", "-allow-env=", "a.poo"}, false},
+		{[]string{"-allow-read", "-allow-write", "-allow-env", "a.poo"}, true},
+		{[]string{"-allow-read=true", "-allow-write=true", "-allow-env=true", "a.poo"}, true},
+	}
+	for _, tt := range tests {
+		if _, err := ParseCommandLine(tt.args); err != nil {
+			t.Fatalf("args %v: unexpected error: %s", tt.args, err)
+		}
+		for name, granted := range map[string]bool{
+			"allow-read":  GlobalConfig.AllowRead.Allows(path),
+			"allow-write": GlobalConfig.AllowWrite.Allows(path),
+			"allow-env":   GlobalConfig.AllowEnv.Allows("HOME"),
+		} {
+			if granted != tt.expected {
+				t.Errorf("args %v: %s granted=%t, want=%t", tt.args, name, granted, tt.expected)
+			}
+		}
+	}
+}
+
+// TestLegacyDebugEnvUnknownValue は互換性のための POO_PIPE_DEBUG などに不明な値を指定しても
+// 起動を止めず false として扱うことをテストする（新しい POO_SHOW_PIPELINE はエラーにする）
+func TestLegacyDebugEnvUnknownValue(t *testing.T) {
+	withGlobalConfig(t)
+
+	t.Setenv("POO_PIPE_DEBUG", "yes")
+	t.Setenv("POO_MAP_FILTER_DEBUG", "on")
+	if err := applyLayers(&GlobalConfig, "", nil); err != nil {
+		t.Fatalf("unknown value of a legacy variable should not be an error. got=%s", err)
+	}
+	if GlobalConfig.ShowPipelineDebug || GlobalConfig.ShowMapFilterDebug {
+		t.Errorf("unknown value should be treated as false. got=%+v", GlobalConfig)
+	}
+	os.Unsetenv("POO_PIPE_DEBUG")
+	os.Unsetenv("POO_MAP_FILTER_DEBUG")
+
+	t.Setenv("POO_SHOW_PIPELINE", "yes")
+	err := applyLayers(&GlobalConfig, "", nil)
+	expected := `環境変数 POO_SHOW_PIPELINE: show-pipeline には true か false を指定してください: "yes"`
+	if err == nil || err.Error() != expected {
+		t.Errorf("POO_SHOW_PIPELINE: expected error %q, got=%v", expected, err)
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configFileNames はプロジェクトの設定ファイル名（同じディレクトリにあれば先のものを優先する）
+var configFileNames = []string{"poo.toml", ".poorc"}
+
+// FindConfigFile は start から親ディレクトリへ向かって設定ファイルを探す
+// 見つからなければ空文字列を返す
+func FindConfigFile(start string) string {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return ""
+	}
+	for {
+		for _, name := range configFileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// readConfigFile は設定ファイルを読み込む
+// poo.toml と .poorc はどちらも TOML のサブセットで、1行に1つの「キー = 値」を書く
+//
+//	# コメント
+//	strict = true
+//	log-level = "WARN"
+//	log = "logs/uncode.log"
+//
+// 値は文字列・真偽値・整数・文字列の配列のいずれかで、配列はカンマ区切りの文字列として扱う
+func readConfigFile(path string) ([]settingValue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("設定ファイルを開けませんでした: %w", err)
+	}
+	defer f.Close()
+
+	var values []settingValue
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(stripComment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "[") {
+			return nil, fmt.Errorf("%s:%d: テーブルはサポートされていません: %s", path, line, text)
+		}
+
+		eq := strings.Index(text, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("%s:%d: 「キー = 値」の形式で指定してください: %s", path, line, text)
+		}
+		key := strings.TrimSpace(text[:eq])
+		value, err := parseConfigValue(strings.TrimSpace(text[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s の値が不正です: %w", path, line, key, err)
+		}
+		values = append(values, settingValue{name: key, value: value, line: line})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("設定ファイルを読み込めませんでした: %w", err)
+	}
+	return values, nil
+}
+
+// stripComment は文字列の外にある # 以降を取り除く
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, ch := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && ch == '\\':
+			escaped = true
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseConfigValue は設定ファイルの値を文字列に変換する
+func parseConfigValue(raw string) (string, error) {
+	switch {
+	case raw == "":
+		return "", fmt.Errorf("値がありません")
+	case strings.HasPrefix(raw, "["):
+		if !strings.HasSuffix(raw, "]") {
+			return "", fmt.Errorf("配列が閉じられていません")
+		}
+		inner := strings.TrimSpace(raw[1 : len(raw)-1])
+		if inner == "" {
+			return "", nil
+		}
+		var items []string
+		for _, item := range splitArrayItems(inner) {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			value, err := parseConfigValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, value)
+		}
+		return strings.Join(items, ","), nil
+	case strings.HasPrefix(raw, `"`):
+		value, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("文字列が正しくありません: %s", raw)
+		}
+		return value, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return "", fmt.Errorf("文字列が閉じられていません: %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	case raw == "true" || raw == "false":
+		return raw, nil
+	default:
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return "", fmt.Errorf("文字列は引用符で囲んでください: %s", raw)
+		}
+		return raw, nil
+	}
+}
+
+// splitArrayItems は配列の要素を文字列の外にあるカンマで区切る
+func splitArrayItems(inner string) []string {
+	var items []string
+	var quote rune
+	escaped := false
+	start := 0
+	for i, ch := range inner {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && ch == '\\':
+			escaped = true
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == ',':
+			items = append(items, inner[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, inner[start:])
+}
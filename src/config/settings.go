@@ -0,0 +1,481 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/uncode/logger"
+)
+
+// SourceKind は設定値の出どころの種類
+// 値が大きいものほど優先され、デフォルト < 設定ファイル < 環境変数 < フラグ の順に上書きされる
+type SourceKind int
+
+const (
+	SourceDefault SourceKind = iota // デフォルト値
+	SourceFile                      // poo.toml / .poorc
+	SourceEnv                       // POO_* 環境変数
+	SourceFlag                      // コマンドラインフラグ
+)
+
+// Source は設定値がどこから来たかを表す
+type Source struct {
+	Kind SourceKind
+	Name string // 設定ファイルのパス、環境変数名、フラグ名のいずれか
+}
+
+// String は config show で表示する出どころの説明を返す
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceFile:
+		return "設定ファイル " + s.Name
+	case SourceEnv:
+		return "環境変数 " + s.Name
+	case SourceFlag:
+		return "フラグ -" + s.Name
+	default:
+		return "デフォルト"
+	}
+}
+
+// settingGroup は設定を受け付けるサブコマンドの分類
+type settingGroup int
+
+const (
+	groupLog     settingGroup = 1 << iota // ログの設定（すべてのサブコマンド）
+	groupRuntime                          // 実行時の設定（run / repl / test）
+)
+
+// setting は1つの設定項目の定義
+// 同じ定義から、フラグ・環境変数・設定ファイルのキーがすべて作られる
+type setting struct {
+	name       string       // フラグ名と設定ファイルのキー
+	defaultVal string       // デフォルト値
+	usage      string       // フラグの説明
+	isBool     bool         // 値なしのフラグ (-strict) を受け付ける
+	isPath     bool         // 設定ファイルに書かれた相対パスを設定ファイルの場所から解決する
+	accumulate bool         // フラグを繰り返し指定すると値を追加する (--allow-read=a --allow-read=b)
+	flagOnly   bool         // 権限を与える設定のため、設定ファイルや環境変数では受け付けずフラグでのみ指定できる
+	envAliases []string     // 互換性のために残している環境変数名
+	group      settingGroup // 設定を受け付けるサブコマンドの分類
+	set        func(c *Config, value string) error
+	get        func(c *Config) string
+}
+
+// envName は設定項目に対応する環境変数名を返す (log-level なら POO_LOG_LEVEL)
+func (s *setting) envName() string {
+	return "POO_" + strings.ToUpper(strings.ReplaceAll(s.name, "-", "_"))
+}
+
+// boolSetting は真偽値の設定項目を作成する
+func boolSetting(name string, defaultVal bool, group settingGroup, usage string, field func(c *Config) *bool) *setting {
+	return &setting{
+		name:       name,
+		defaultVal: strconv.FormatBool(defaultVal),
+		usage:      usage,
+		isBool:     true,
+		group:      group,
+		set: func(c *Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s には true か false を指定してください: %q", name, value)
+			}
+			*field(c) = b
+			return nil
+		},
+		get: func(c *Config) string {
+			return strconv.FormatBool(*field(c))
+		},
+	}
+}
+
+// stringSetting は文字列の設定項目を作成する
+func stringSetting(name string, group settingGroup, usage string, field func(c *Config) *string) *setting {
+	return &setting{
+		name:  name,
+		usage: usage,
+		group: group,
+		set: func(c *Config, value string) error {
+			*field(c) = value
+			return nil
+		},
+		get: func(c *Config) string {
+			return *field(c)
+		},
+	}
+}
+
+// intSetting は0以上の整数の設定項目を作成する
+func intSetting(name string, defaultVal int, group settingGroup, usage string, field func(c *Config) *int) *setting {
+	return &setting{
+		name:       name,
+		defaultVal: strconv.Itoa(defaultVal),
+		usage:      usage,
+		group:      group,
+		set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("%s には0以上の整数を指定してください: %q", name, value)
+			}
+			*field(c) = n
+			return nil
+		},
+		get: func(c *Config) string {
+			return strconv.Itoa(*field(c))
+		},
+	}
+}
+
+// choiceSetting は決められた値のいずれかを指定する設定項目を作成する
+// 最初の値がデフォルト値になる
+func choiceSetting(name string, choices []string, group settingGroup, usage string, field func(c *Config) *string) *setting {
+	return &setting{
+		name:       name,
+		defaultVal: choices[0],
+		usage:      fmt.Sprintf("%s (%s)", usage, strings.Join(choices, ", ")),
+		group:      group,
+		set: func(c *Config, value string) error {
+			for _, choice := range choices {
+				if strings.EqualFold(value, choice) {
+					*field(c) = choice
+					return nil
+				}
+			}
+			return fmt.Errorf("%s には %s のいずれかを指定してください: %q", name, strings.Join(choices, ", "), value)
+		},
+		get: func(c *Config) string {
+			return *field(c)
+		},
+	}
+}
+
+// logLevelSetting はログレベルの設定項目を作成する
+// component が ComponentGlobal の場合はグローバルログレベルになり、
+// 空の値は「指定なし」を表して -debug の有無からレベルを決める
+func logLevelSetting(name string, usage string, component logger.ComponentType) *setting {
+	global := component == logger.ComponentGlobal
+	return &setting{
+		name:  name,
+		usage: usage,
+		group: groupLog,
+		set: func(c *Config, value string) error {
+			if value == "" {
+				if global {
+					c.logLevelSpecified = false
+				} else {
+					delete(c.ComponentLogLevels, component)
+				}
+				return nil
+			}
+			level := strings.ToUpper(value)
+			if !isLevelName(level) {
+				return fmt.Errorf("%s に不明なログレベルが指定されました: %q", name, value)
+			}
+			if global {
+				c.LogLevel = logger.ParseLogLevel(level)
+				c.logLevelSpecified = true
+			} else {
+				c.ComponentLogLevels[component] = logger.ParseLogLevel(level)
+			}
+			return nil
+		},
+		get: func(c *Config) string {
+			if global {
+				if !c.logLevelSpecified {
+					return ""
+				}
+				return logger.LevelNames[c.LogLevel]
+			}
+			if level, ok := c.ComponentLogLevels[component]; ok {
+				return logger.LevelNames[level]
+			}
+			return ""
+		},
+	}
+}
+
+// componentLogSetting はコンポーネントごとのログの出力先の設定項目を作成する
+// 空の値は「指定なし」を表し、そのコンポーネントのログは標準出力に書き出す
+func componentLogSetting(name string, usage string, component logger.ComponentType) *setting {
+	return &setting{
+		name:   name,
+		usage:  usage,
+		isPath: true,
+		group:  groupLog,
+		set: func(c *Config, value string) error {
+			if value == "" {
+				delete(c.ComponentLogFiles, component)
+				return nil
+			}
+			c.ComponentLogFiles[component] = value
+			return nil
+		},
+		get: func(c *Config) string {
+			return c.ComponentLogFiles[component]
+		},
+	}
+}
+
+// isLevelName はログレベルの名前が有効かどうかを確認する
+func isLevelName(name string) bool {
+	for _, levelName := range logger.LevelNames {
+		if levelName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// pathPermissionSetting は --allow-read / --allow-write の設定項目を作成する
+// 実行するスクリプトと同じディレクトリの設定ファイルなどで権限を与えられないように、フラグでのみ指定できる
+func pathPermissionSetting(name string, usage string, field func(c *Config) *PathPermission) *setting {
+	return &setting{
+		name:   name,
+		usage:  usage,
+		isBool:     true,
+		accumulate: true,
+		flagOnly:   true,
+		group:      groupRuntime,
+		set: func(c *Config, value string) error {
+			// 下の層の値に追加せず、上書きする
+			*field(c) = PathPermission{}
+			if value == "" || value == "false" {
+				return nil
+			}
+			return field(c).Set(value)
+		},
+		get: func(c *Config) string {
+			if field(c).All {
+				return "true"
+			}
+			return field(c).String()
+		},
+	}
+}
+
+// settings はすべての設定項目の一覧
+// config show やヘルプはこの順序で表示する
+var settings = []*setting{
+	boolSetting("debug", false, groupLog, "デバッグモードを有効にする", func(c *Config) *bool { return &c.DebugMode }),
+	logLevelSetting("log-level", "グローバルログレベル (OFF, ERROR, WARN, INFO, DEBUG, TRACE)", logger.ComponentGlobal),
+	logLevelSetting("lexer-log-level", "レキサーのログレベル", logger.ComponentLexer),
+	logLevelSetting("parser-log-level", "パーサーのログレベル", logger.ComponentParser),
+	logLevelSetting("eval-log-level", "評価器のログレベル", logger.ComponentEval),
+	logLevelSetting("runtime-log-level", "ランタイムのログレベル", logger.ComponentRuntime),
+	logLevelSetting("builtin-log-level", "組み込み関数のログレベル", logger.ComponentBuiltin),
+	withPath(stringSetting("log", groupLog, "ログファイルのパス (指定がなければ標準出力のみ)", func(c *Config) *string { return &c.LogFile })),
+	choiceSetting("log-format", []string{string(logger.FormatText), string(logger.FormatJSON)}, groupLog, "ログの出力形式", func(c *Config) *string { return &c.LogFormat }),
+	componentLogSetting("lexer-log", "レキサーのログの出力先 (指定がなければ標準出力)", logger.ComponentLexer),
+	componentLogSetting("parser-log", "パーサーのログの出力先 (指定がなければ標準出力)", logger.ComponentParser),
+	componentLogSetting("eval-log", "評価器のログの出力先 (指定がなければ標準出力)", logger.ComponentEval),
+	componentLogSetting("runtime-log", "ランタイムのログの出力先 (指定がなければ標準出力)", logger.ComponentRuntime),
+	componentLogSetting("builtin-log", "組み込み関数のログの出力先 (指定がなければ標準出力)", logger.ComponentBuiltin),
+	intSetting("flight-recorder", 0, groupLog, "実行時エラーやパニックで終了する際に書き出す直近のログの件数 (0 で記録しない)", func(c *Config) *int { return &c.FlightRecorder }),
+	choiceSetting("diagnostics", []string{DiagnosticsText, DiagnosticsJSON}, groupLog, "エラーの出力形式", func(c *Config) *string { return &c.Diagnostics }),
+	boolSetting("color", true, groupLog, "カラー出力を有効にする", func(c *Config) *bool { return &c.ColorOutput }),
+	boolSetting("timestamp", true, groupLog, "タイムスタンプを表示する", func(c *Config) *bool { return &c.ShowTimestamp }),
+	boolSetting("show-types", false, groupLog, "型情報を表示する", func(c *Config) *bool { return &c.ShowTypeInfo }),
+	boolSetting("show-lexer", false, groupLog, "レキサーのデバッグ情報を表示する", func(c *Config) *bool { return &c.ShowLexerDebug }),
+	boolSetting("show-parser", false, groupLog, "パーサーのデバッグ情報を表示する", func(c *Config) *bool { return &c.ShowParserDebug }),
+	boolSetting("show-eval", false, groupRuntime, "評価時のデバッグ情報を表示する", func(c *Config) *bool { return &c.ShowEvalDebug }),
+	boolSetting("show-builtin", false, groupRuntime, "組み込み関数のデバッグ情報を表示する", func(c *Config) *bool { return &c.ShowBuiltinDebug }),
+	boolSetting("show-condition", false, groupRuntime, "条件式評価のデバッグ情報を表示する", func(c *Config) *bool { return &c.ShowConditionDebug }),
+	withEnvAliases(boolSetting("show-pipeline", false, groupRuntime, "パイプライン処理のデバッグ情報を表示する", func(c *Config) *bool { return &c.ShowPipelineDebug }), "POO_PIPE_DEBUG"),
+	withEnvAliases(boolSetting("show-map-filter", false, groupRuntime, "map/filter演算子のデバッグ情報を表示する", func(c *Config) *bool { return &c.ShowMapFilterDebug }), "POO_MAP_FILTER_DEBUG"),
+	withPath(stringSetting("output", groupRuntime, "出力ファイルのパス (tee で出力を記録)", func(c *Config) *string { return &c.OutputFile })),
+	boolSetting("quiet", false, groupRuntime, "プログラムの出力を標準出力に表示しない (-output のファイルには記録する)", func(c *Config) *bool { return &c.Quiet }),
+	boolSetting("preregister", true, groupRuntime, "関数を事前に登録する (ASTを2回走査)", func(c *Config) *bool { return &c.PreregisterFunctions }),
+	boolSetting("strict", false, groupRuntime, "厳密モード: 暗黙の型変換を無効にし、型の合わない演算をエラーにする", func(c *Config) *bool { return &c.StrictMode }),
+	intSetting("max-depth", DefaultMaxCallDepth, groupRuntime, "関数呼び出しの深さの上限 (0 で上限なし)", func(c *Config) *int { return &c.MaxCallDepth }),
+	withPath(stringSetting("profile", groupRuntime, "関数・組み込み関数・パイプラインの段ごとの実行時間と呼び出し回数を記録するファイル", func(c *Config) *string { return &c.ProfileFile })),
+	choiceSetting("profile-format", []string{ProfileFormatPprof, ProfileFormatFolded}, groupRuntime, "プロファイルの形式", func(c *Config) *string { return &c.ProfileFormat }),
+	withPath(stringSetting("trace", groupRuntime, "関数・組み込み関数・パイプラインの段の開始と終了を Chrome Trace Event 形式で書き出すファイル (Perfetto で表示できる)", func(c *Config) *string { return &c.TraceFile })),
+	withPath(stringSetting("trace-mermaid", groupRuntime, "パイプラインの段と関数呼び出しの流れを Mermaid のシーケンス図として書き出すファイル", func(c *Config) *string { return &c.TraceMermaidFile })),
+	withPath(stringSetting("coverage", groupRuntime, "実行した文・case 文・条件付き関数を lcov 形式で書き出すファイル (同じ名前に .html を付けた HTML のレポートも書き出す)", func(c *Config) *string { return &c.CoverageFile })),
+	pathPermissionSetting("allow-read", "ファイルの読み込みを許可するパス (カンマ区切り、値なしですべて許可)", func(c *Config) *PathPermission { return &c.AllowRead }),
+	pathPermissionSetting("allow-write", "ファイルの書き込みを許可するパス (カンマ区切り、値なしですべて許可)", func(c *Config) *PathPermission { return &c.AllowWrite }),
+	{
+		name:       "allow-env",
+		usage:      "読み込みを許可する環境変数 (カンマ区切り、値なしですべて許可)",
+		isBool:     true,
+		accumulate: true,
+		flagOnly:   true,
+		group:      groupRuntime,
+		set: func(c *Config, value string) error {
+			c.AllowEnv = NamePermission{}
+			if value == "" || value == "false" {
+				return nil
+			}
+			return c.AllowEnv.Set(value)
+		},
+		get: func(c *Config) string {
+			if c.AllowEnv.All {
+				return "true"
+			}
+			return c.AllowEnv.String()
+		},
+	},
+}
+
+// withPath は設定ファイルの相対パスを設定ファイルの場所から解決するようにする
+func withPath(s *setting) *setting {
+	s.isPath = true
+	return s
+}
+
+// withEnvAliases は互換性のための環境変数名を追加する
+func withEnvAliases(s *setting, names ...string) *setting {
+	s.envAliases = names
+	return s
+}
+
+// lookupSetting は名前から設定項目を探す
+func lookupSetting(name string) *setting {
+	for _, s := range settings {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// settingValue は1つの層で指定された設定値
+type settingValue struct {
+	name  string
+	value string
+	line  int // 設定ファイルの行番号（設定ファイル以外は0）
+}
+
+// applySetting は設定値を適用し、その出どころを記録する
+func applySetting(c *Config, s *setting, value string, source Source) error {
+	if err := s.set(c, value); err != nil {
+		return err
+	}
+	c.Sources[s.name] = source
+	return nil
+}
+
+// resetConfig は設定をデフォルト値に戻す
+func resetConfig(c *Config) {
+	*c = Config{
+		ComponentLogLevels: make(map[logger.ComponentType]logger.LogLevel),
+		SpecialLogLevels:   make(map[logger.LogLevel]bool),
+		ComponentLogFiles:  make(map[logger.ComponentType]string),
+		Sources:            make(map[string]Source),
+	}
+	for _, s := range settings {
+		// デフォルト値は常に有効な値なのでエラーにはならない
+		applySetting(c, s, s.defaultVal, Source{Kind: SourceDefault})
+	}
+}
+
+// applyLayers はデフォルト値・設定ファイル・環境変数・フラグの順に設定を重ねる
+// 後の層ほど優先される。flags は指定されたフラグだけを含む
+func applyLayers(c *Config, configFile string, flags []settingValue) error {
+	resetConfig(c)
+	c.ConfigFile = configFile
+
+	// 設定ファイル
+	if configFile != "" {
+		values, err := readConfigFile(configFile)
+		if err != nil {
+			return err
+		}
+		dir := filepath.Dir(configFile)
+		for _, v := range values {
+			s := lookupSetting(v.name)
+			if s == nil {
+				return fmt.Errorf("%s:%d: 不明な設定項目です: %s", configFile, v.line, v.name)
+			}
+			if s.flagOnly {
+				return fmt.Errorf("%s:%d: %s は権限を与える設定のため、設定ファイルでは指定できません (コマンドラインで --%s を指定してください)", configFile, v.line, s.name, s.name)
+			}
+			value := v.value
+			if s.isPath {
+				value = resolvePaths(dir, value)
+			}
+			if err := applySetting(c, s, value, Source{Kind: SourceFile, Name: configFile}); err != nil {
+				return fmt.Errorf("%s:%d: %w", configFile, v.line, err)
+			}
+		}
+	}
+
+	// 環境変数
+	for _, s := range settings {
+		for _, name := range append([]string{s.envName()}, s.envAliases...) {
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			if s.flagOnly {
+				return fmt.Errorf("環境変数 %s: %s は権限を与える設定のため、環境変数では指定できません (コマンドラインで --%s を指定してください)", name, s.name, s.name)
+			}
+			if err := applySetting(c, s, value, Source{Kind: SourceEnv, Name: name}); err != nil {
+				// 互換性のために残している環境変数（POO_PIPE_DEBUG=1 など）は、以前と同じく
+				// 不明な値を false として扱い、起動を止めずに警告だけを表示する
+				if s.isBool && name != s.envName() {
+					logger.Warn("環境変数 %s: %s。false として扱います", name, err)
+					applySetting(c, s, "false", Source{Kind: SourceEnv, Name: name})
+					continue
+				}
+				return fmt.Errorf("環境変数 %s: %w", name, err)
+			}
+		}
+	}
+
+	// フラグ
+	for _, v := range flags {
+		s := lookupSetting(v.name)
+		if err := applySetting(c, s, v.value, Source{Kind: SourceFlag, Name: v.name}); err != nil {
+			return err
+		}
+	}
+
+	finalizeConfig(c)
+	return nil
+}
+
+// resolvePaths はカンマ区切りの相対パスを dir からのパスに変換する
+func resolvePaths(dir string, value string) string {
+	if value == "" || value == "true" || value == "false" {
+		return value
+	}
+	paths := strings.Split(value, ",")
+	for i, path := range paths {
+		if path != "" && !filepath.IsAbs(path) {
+			paths[i] = filepath.Join(dir, path)
+		}
+	}
+	return strings.Join(paths, ",")
+}
+
+// finalizeConfig は他の設定から決まる設定を反映する
+func finalizeConfig(c *Config) {
+	// ログレベルの指定がなければ -debug の有無で決める
+	if !c.logLevelSpecified {
+		if c.DebugMode {
+			c.LogLevel = logger.LevelDebug
+		} else {
+			c.LogLevel = logger.LevelInfo
+		}
+	}
+
+	// デバッグフラグを設定した場合は自動的に対応するデバッグを有効にする
+	if c.DebugMode {
+		c.ShowLexerDebug = true
+		c.ShowParserDebug = true
+		c.ShowEvalDebug = true
+		c.ShowBuiltinDebug = true
+		c.ShowConditionDebug = true
+		c.ShowPipelineDebug = true
+		c.ShowMapFilterDebug = true
+	}
+
+	// 特殊デバッグログレベルの有効/無効
+	c.SpecialLogLevels[logger.LevelTypeInfo] = c.ShowTypeInfo
+	c.SpecialLogLevels[logger.LevelEvalDebug] = c.ShowEvalDebug
+	c.SpecialLogLevels[logger.LevelParserDebug] = c.ShowParserDebug
+}
@@ -0,0 +1,298 @@
+// Package coverage は実行した文・case 文の分岐・条件付き関数の定義を記録し、
+// lcov 形式と、ソースを色分けした HTML のレポートとして出力する
+package coverage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/uncode/ast"
+	"github.com/uncode/object"
+)
+
+// anonymousFunction は名前のない関数の名前（定義した行を付けて区別する）
+const anonymousFunction = "<無名関数>"
+
+// Coverage は evaluator.Hook を実装し、実行中に評価したノードと呼び出した関数を数える
+// 実行が終わったら Add でファイルごとの結果にまとめ、WriteLCOV や WriteHTML で書き出す
+type Coverage struct {
+	evals map[ast.Node]int            // ノードを評価した回数（文と、case 文・条件付き関数の条件式）
+	calls map[*ast.BlockStatement]int // 関数の本体ごとの呼び出し回数
+	arms  map[ast.Statement]int       // case 文と default 文が選ばれた回数
+	files []*report
+}
+
+// New は記録を開始する
+func New() *Coverage {
+	return &Coverage{
+		evals: make(map[ast.Node]int),
+		calls: make(map[*ast.BlockStatement]int),
+		arms:  make(map[ast.Statement]int),
+	}
+}
+
+// BeforeEval は評価したノードを数える
+func (c *Coverage) BeforeEval(node ast.Node, env *object.Environment) {
+	c.evals[node]++
+}
+
+func (c *Coverage) BeforeStage(node *ast.InfixExpression, input object.Object, env *object.Environment) {
+}
+
+func (c *Coverage) AfterStage(node *ast.InfixExpression, result object.Object) {}
+
+// EnterFunction は呼び出した関数を本体で区別して数える（同名の条件付き関数もそれぞれ数える）
+func (c *Coverage) EnterFunction(fn *object.Function, env *object.Environment) {
+	if body, ok := fn.ASTBody.(*ast.BlockStatement); ok {
+		c.calls[body]++
+	}
+}
+
+func (c *Coverage) ExitFunction(fn *object.Function, result object.Object) {}
+
+// SelectCase は選ばれた case 文と default 文を数える
+func (c *Coverage) SelectCase(arm ast.Statement, env *object.Environment) {
+	c.arms[arm]++
+}
+
+// report は1つのソースファイルのカバレッジ
+type report struct {
+	name      string
+	lines     []string          // ソースの各行
+	counts    map[int]*lineStat // 文のある行ごとの実行回数
+	functions []function
+	branches  []branch
+	blocks    int // 分岐のまとまりの数
+}
+
+// lineStat は1行にある文の数と実行回数
+type lineStat struct {
+	statements int // 文の数
+	executed   int // 1回以上実行した文の数
+	count      int // 文の実行回数の最大値
+}
+
+// function は関数の定義と呼び出し回数
+type function struct {
+	name  string
+	line  int
+	count int
+}
+
+// branch は case 文・default 文、または同名の条件付き関数の1つ
+// 同じ block の分岐のうち、どれか1つが選ばれる
+type branch struct {
+	line    int
+	block   int // 分岐のまとまりの番号
+	arm     int // まとまりの中での順番
+	label   string
+	count   int  // 選ばれた回数
+	reached bool // 分岐のまとまりまで実行が到達したか（lcov では到達していない分岐を "-" にする）
+}
+
+// Add は実行したソースファイルの結果を加える。program は実行したソースの AST
+// 構文エラーで実行できなかったファイル (program が nil) は加えない
+func (c *Coverage) Add(name string, source string, program *ast.Program) {
+	if program == nil {
+		return
+	}
+	r := &report{name: name, lines: strings.Split(source, "\n"), counts: make(map[int]*lineStat)}
+
+	// 同名の関数は条件付き関数の候補として、定義の順にまとめる
+	overloads := make(map[string][]*ast.FunctionLiteral)
+	var names []string
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Program:
+			c.addStatements(r, n.Statements)
+		case *ast.BlockStatement:
+			c.addStatements(r, n.Statements)
+		case *ast.FunctionLiteral:
+			name := fmt.Sprintf("%s:%d", anonymousFunction, n.Token.Line)
+			if n.Name != nil && n.Name.Value != "" {
+				name = n.Name.Value
+				if len(overloads[name]) == 0 {
+					names = append(names, name)
+				} else {
+					// lcov の関数名は一意にする必要があるため、2つ目以降は条件付き関数の登録名と同じく #N を付ける
+					name = fmt.Sprintf("%s#%d", name, len(overloads[name]))
+				}
+				overloads[n.Name.Value] = append(overloads[n.Name.Value], n)
+			}
+			r.functions = append(r.functions, function{name: name, line: n.Token.Line, count: c.calls[n.Body]})
+		}
+		return true
+	})
+
+	for _, name := range names {
+		c.addOverloads(r, overloads[name])
+	}
+	c.files = append(c.files, r)
+}
+
+// addStatements はブロックの文を行ごとに数え、case 文と default 文を分岐のまとまりとして加える
+func (c *Coverage) addStatements(r *report, statements []ast.Statement) {
+	var arms []branch
+	reached := false
+	for _, stmt := range statements {
+		line, count := 0, 0
+		switch s := stmt.(type) {
+		case *ast.CaseStatement:
+			if s == nil {
+				continue
+			}
+			// ブロックの中の case 文は Eval を通らないため、条件式を評価した回数をその行の実行回数とする
+			line, count = s.Token.Line, c.evals[s.Condition]
+			arms = append(arms, branch{line: line, label: "case " + s.Condition.String(), count: c.arms[s]})
+			reached = reached || count > 0
+		case *ast.DefaultCaseStatement:
+			if s == nil {
+				continue
+			}
+			line, count = s.Token.Line, c.arms[s]
+			arms = append(arms, branch{line: line, label: "default", count: count})
+			reached = reached || count > 0
+		case *ast.ExpressionStatement:
+			if s == nil {
+				continue
+			}
+			line, count = s.Token.Line, c.evals[s]
+		case *ast.AssignStatement:
+			if s == nil {
+				continue
+			}
+			line, count = s.Token.Line, c.evals[s]
+		case *ast.PipeStatement:
+			if s == nil {
+				continue
+			}
+			line, count = s.Token.Line, c.evals[s]
+		case *ast.GlobalStatement:
+			if s == nil {
+				continue
+			}
+			line, count = s.Token.Line, c.evals[s]
+		case *ast.BlockStatement:
+			if s == nil {
+				continue
+			}
+			line, count = s.Token.Line, c.evals[s]
+		default:
+			continue
+		}
+		r.addLine(line, count)
+	}
+	r.addBranches(arms, reached)
+}
+
+// addOverloads は同名の関数のうち条件付き関数があるものを、呼び出しごとにどれか1つが選ばれる分岐のまとまりとして加える
+func (c *Coverage) addOverloads(r *report, functions []*ast.FunctionLiteral) {
+	conditional := false
+	for _, fn := range functions {
+		conditional = conditional || fn.Condition != nil
+	}
+	if !conditional {
+		return
+	}
+	arms := make([]branch, len(functions))
+	reached := false
+	for i, fn := range functions {
+		label := "条件なし"
+		if fn.Condition != nil {
+			label = "条件 " + fn.Condition.String()
+			reached = reached || c.evals[fn.Condition] > 0
+		}
+		arms[i] = branch{line: fn.Token.Line, label: fn.Name.Value + " " + label, count: c.calls[fn.Body]}
+		reached = reached || arms[i].count > 0
+	}
+	r.addBranches(arms, reached)
+}
+
+// addLine は行にある文の実行回数を加える
+func (r *report) addLine(line int, count int) {
+	if line <= 0 {
+		return
+	}
+	stat, ok := r.counts[line]
+	if !ok {
+		stat = &lineStat{}
+		r.counts[line] = stat
+	}
+	stat.statements++
+	if count > 0 {
+		stat.executed++
+	}
+	if count > stat.count {
+		stat.count = count
+	}
+}
+
+// addBranches は分岐のまとまりを加える
+func (r *report) addBranches(arms []branch, reached bool) {
+	if len(arms) == 0 {
+		return
+	}
+	for i, b := range arms {
+		b.block, b.arm, b.reached = r.blocks, i, reached
+		r.branches = append(r.branches, b)
+	}
+	r.blocks++
+}
+
+// sortedLines は文のある行を昇順に返す
+func (r *report) sortedLines() []int {
+	lines := make([]int, 0, len(r.counts))
+	for line := range r.counts {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// summary は見つかった数と実行した数
+type summary struct {
+	Found int
+	Hit   int
+}
+
+// Percent は割合を "87.5%" の形式で返す。対象がなければ "-"
+func (s summary) Percent() string {
+	if s.Found == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(s.Hit)*100/float64(s.Found))
+}
+
+// lineSummary は文のある行と、1回以上実行した行の数を返す
+func (r *report) lineSummary() summary {
+	s := summary{Found: len(r.counts)}
+	for _, stat := range r.counts {
+		if stat.count > 0 {
+			s.Hit++
+		}
+	}
+	return s
+}
+
+// branchSummary は分岐と、1回以上選ばれた分岐の数を返す
+func (r *report) branchSummary() summary {
+	s := summary{Found: len(r.branches)}
+	for _, b := range r.branches {
+		if b.count > 0 {
+			s.Hit++
+		}
+	}
+	return s
+}
+
+// functionSummary は関数と、1回以上呼び出した関数の数を返す
+func (r *report) functionSummary() summary {
+	s := summary{Found: len(r.functions)}
+	for _, f := range r.functions {
+		if f.count > 0 {
+			s.Hit++
+		}
+	}
+	return s
+}
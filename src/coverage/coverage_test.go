@@ -0,0 +1,144 @@
+package coverage
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/uncode/evaluator"
+	"github.com/uncode/logger"
+	"github.com/uncode/runtime"
+)
+
+// source は case 文・default 文、条件付き関数、呼び出さない関数を含むプログラム
+var source = strings.Join([]string{
+	"def kind(): int -> str {",
+	"  case 🍕 % 2 == 0: {",
+	`    "even" >> 💩;`,
+	"  }",
+	"  default: {",
+	`    "odd" >> 💩;`,
+	"  }",
+	"}",
+	"def sign() if 🍕 > 0 {",
+	`  "plus" >> 💩;`,
+	"}",
+	"def sign() if 🍕 < 0 {",
+	`  "minus" >> 💩;`,
+	"}",
+	"def unused() {",
+	"  🍕 >> 💩;",
+	"}",
+	"3 |> kind;",
+	"5 |> kind;",
+	"5 |> sign;",
+}, "\n")
+
+// runCoverage はプログラムを実行してカバレッジを記録する
+func runCoverage(t *testing.T, source string) *Coverage {
+	t.Helper()
+	logger.SetLevel(logger.LevelOff)
+	evaluator.SetOutput(io.Discard)
+	t.Cleanup(func() {
+		evaluator.SetHook(nil)
+		evaluator.SetOutput(os.Stdout)
+	})
+
+	c := New()
+	evaluator.SetHook(c)
+	result, _ := runtime.ExecuteSource("main.poo", source)
+	evaluator.SetHook(nil)
+	c.Add("main.poo", result.Source, result.Program)
+	return c
+}
+
+// TestLCOV は行・case 文の分岐・条件付き関数・関数の実行回数を lcov 形式で書き出すことをテストする
+func TestLCOV(t *testing.T) {
+	var out strings.Builder
+	if err := runCoverage(t, source).WriteLCOV(&out); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"TN:",
+		"SF:main.poo",
+		"FN:1,kind",
+		"FN:9,sign",
+		"FN:12,sign#1",
+		"FN:15,unused",
+		"FNDA:2,kind",
+		"FNDA:1,sign",
+		"FNDA:0,sign#1",
+		"FNDA:0,unused",
+		"FNF:4",
+		"FNH:2",
+		"BRDA:2,0,0,0",
+		"BRDA:5,0,1,2",
+		"BRDA:9,1,0,1",
+		"BRDA:12,1,1,0",
+		"BRF:4",
+		"BRH:2",
+		"DA:1,1",
+		"DA:2,2",
+		"DA:3,0",
+		"DA:5,2",
+		"DA:6,2",
+		"DA:9,1",
+		"DA:10,1",
+		"DA:12,1",
+		"DA:13,0",
+		"DA:15,1",
+		"DA:16,0",
+		"DA:18,1",
+		"DA:19,1",
+		"DA:20,1",
+		"LF:14",
+		"LH:11",
+		"end_of_record",
+		"",
+	}, "\n")
+	if out.String() != expected {
+		t.Errorf("wrong lcov.\nexpected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+// TestUnreachedBranches は実行が到達しなかった分岐のまとまりを "-" にすることをテストする
+func TestUnreachedBranches(t *testing.T) {
+	var out strings.Builder
+	if err := runCoverage(t, strings.Split(source, "def unused")[0]).WriteLCOV(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"BRDA:2,0,0,-", "BRDA:5,0,1,-", "BRDA:9,1,0,-", "BRDA:12,1,1,-", "BRH:0"} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("lcov should contain %q.\ngot:\n%s", line, out.String())
+		}
+	}
+}
+
+// TestHTML は行を実行の有無で色分けし、分岐の回数を表示することをテストする
+func TestHTML(t *testing.T) {
+	var out strings.Builder
+	if err := runCoverage(t, source).WriteHTML(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, fragment := range []string{
+		`<td>78.6% (11/14)</td><td>50.0% (2/4)</td><td>50.0% (2/4)</td>`,
+		`<tr class="miss"><td class="num">3</td><td class="count">0</td><td class="code">    &#34;even&#34; &gt;&gt; 💩;</td></tr>`,
+		`<tr class="hit"><td class="num">9</td><td class="count">1</td><td class="code">def sign() if 🍕 &gt; 0 {<span class="branch taken">sign 条件 (🍕 &gt; 0): 1</span></td></tr>`,
+		`<span class="branch missed">sign 条件 (🍕 &lt; 0): 0</span>`,
+		`<tr class=""><td class="num">4</td><td class="count"></td>`,
+	} {
+		if !strings.Contains(out.String(), fragment) {
+			t.Errorf("html should contain %q.\ngot:\n%s", fragment, out.String())
+		}
+	}
+
+	// 実行した文としていない文が同じ行にある場合は一部だけ実行した行にする
+	out.Reset()
+	if err := runCoverage(t, "def f() { 🍕 >> 💩; }").WriteHTML(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `<tr class="partial"><td class="num">1</td>`) {
+		t.Errorf("the line should be partially covered.\ngot:\n%s", out.String())
+	}
+}
@@ -0,0 +1,125 @@
+package coverage
+
+import (
+	"html/template"
+	"io"
+	"strconv"
+)
+
+// htmlFile は HTML のレポートに表示する1つのファイル
+type htmlFile struct {
+	ID        string
+	Name      string
+	Lines     summary
+	Branches  summary
+	Functions summary
+	Source    []htmlLine
+}
+
+// htmlLine はソースの1行
+// Class は文のすべてを実行した行が hit、一部だけが partial、どれも実行していない行が miss で、文のない行は空
+type htmlLine struct {
+	Number   int
+	Count    string
+	Class    string
+	Text     string
+	Branches []htmlBranch
+}
+
+// htmlBranch は行にある分岐。Class は選ばれた分岐が taken、到達したが選ばれなかった分岐が missed、到達していない分岐が unreached
+type htmlBranch struct {
+	Label string
+	Count int
+	Class string
+}
+
+var htmlTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<title>uncode カバレッジ</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+.summary td, .summary th { padding: 0.2em 1em; border-bottom: 1px solid #ddd; text-align: left; }
+.source { width: 100%; font-family: monospace; font-size: 14px; margin-bottom: 3em; }
+.source td { padding: 0 0.5em; vertical-align: top; }
+.source .num, .source .count { text-align: right; color: #888; user-select: none; }
+.source .code { white-space: pre; width: 100%; }
+tr.hit .code, tr.hit .count { background: #dfd; }
+tr.partial .code, tr.partial .count { background: #ffc; }
+tr.miss .code, tr.miss .count { background: #fdd; }
+.branch { display: inline-block; margin-left: 0.5em; padding: 0 0.3em; border-radius: 3px; white-space: nowrap; }
+.branch.taken { background: #bfb; }
+.branch.missed { background: #fbb; }
+.branch.unreached { background: #eee; color: #888; }
+</style>
+</head>
+<body>
+<h1>カバレッジ</h1>
+<table class="summary">
+<tr><th>ファイル</th><th>行</th><th>分岐</th><th>関数</th></tr>
+{{- range .}}
+<tr><td><a href="#{{.ID}}">{{.Name}}</a></td><td>{{.Lines.Percent}} ({{.Lines.Hit}}/{{.Lines.Found}})</td><td>{{.Branches.Percent}} ({{.Branches.Hit}}/{{.Branches.Found}})</td><td>{{.Functions.Percent}} ({{.Functions.Hit}}/{{.Functions.Found}})</td></tr>
+{{- end}}
+</table>
+{{- range .}}
+<h2 id="{{.ID}}">{{.Name}}</h2>
+<table class="source">
+{{- range .Source}}
+<tr class="{{.Class}}"><td class="num">{{.Number}}</td><td class="count">{{.Count}}</td><td class="code">{{.Text}}{{range .Branches}}<span class="branch {{.Class}}">{{.Label}}: {{.Count}}</span>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML はファイルごとに、実行した行・一部だけ実行した行・実行していない行を色分けしたソースを HTML で書き出す
+// 行の左には実行回数を、case 文・default 文と条件付き関数の行には各分岐が選ばれた回数を表示する
+func (c *Coverage) WriteHTML(w io.Writer) error {
+	files := make([]htmlFile, len(c.files))
+	for i, r := range c.files {
+		f := htmlFile{
+			ID:        "file" + strconv.Itoa(i),
+			Name:      r.name,
+			Lines:     r.lineSummary(),
+			Branches:  r.branchSummary(),
+			Functions: r.functionSummary(),
+			Source:    make([]htmlLine, len(r.lines)),
+		}
+		for j, text := range r.lines {
+			f.Source[j] = htmlLine{Number: j + 1, Text: text}
+		}
+		for line, stat := range r.counts {
+			if line > len(f.Source) {
+				continue
+			}
+			l := &f.Source[line-1]
+			l.Count = strconv.Itoa(stat.count)
+			switch {
+			case stat.executed == stat.statements:
+				l.Class = "hit"
+			case stat.executed > 0:
+				l.Class = "partial"
+			default:
+				l.Class = "miss"
+			}
+		}
+		for _, b := range r.branches {
+			if b.line <= 0 || b.line > len(f.Source) {
+				continue
+			}
+			class := "unreached"
+			if b.count > 0 {
+				class = "taken"
+			} else if b.reached {
+				class = "missed"
+			}
+			l := &f.Source[b.line-1]
+			l.Branches = append(l.Branches, htmlBranch{Label: b.label, Count: b.count, Class: class})
+		}
+		files[i] = f
+	}
+	return htmlTemplate.Execute(w, files)
+}
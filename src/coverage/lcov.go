@@ -0,0 +1,44 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// WriteLCOV は lcov のトレースファイル形式で書き出す
+// https://github.com/linux-test-project/lcov/blob/master/man/geninfo.1
+// 文のある行を DA、case 文・default 文と条件付き関数を BRDA、関数を FN / FNDA として記録する
+func (c *Coverage) WriteLCOV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range c.files {
+		fmt.Fprintf(bw, "TN:\nSF:%s\n", r.name)
+
+		for _, f := range r.functions {
+			fmt.Fprintf(bw, "FN:%d,%s\n", f.line, f.name)
+		}
+		for _, f := range r.functions {
+			fmt.Fprintf(bw, "FNDA:%d,%s\n", f.count, f.name)
+		}
+		functions := r.functionSummary()
+		fmt.Fprintf(bw, "FNF:%d\nFNH:%d\n", functions.Found, functions.Hit)
+
+		for _, b := range r.branches {
+			taken := "-"
+			if b.reached {
+				taken = fmt.Sprint(b.count)
+			}
+			fmt.Fprintf(bw, "BRDA:%d,%d,%d,%s\n", b.line, b.block, b.arm, taken)
+		}
+		branches := r.branchSummary()
+		fmt.Fprintf(bw, "BRF:%d\nBRH:%d\n", branches.Found, branches.Hit)
+
+		for _, line := range r.sortedLines() {
+			fmt.Fprintf(bw, "DA:%d,%d\n", line, r.counts[line].count)
+		}
+		lines := r.lineSummary()
+		fmt.Fprintf(bw, "LF:%d\nLH:%d\n", lines.Found, lines.Hit)
+		bw.WriteString("end_of_record\n")
+	}
+	return bw.Flush()
+}
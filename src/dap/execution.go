@@ -0,0 +1,320 @@
+package dap
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/uncode/debug"
+	"github.com/uncode/evaluator"
+	"github.com/uncode/object"
+	"github.com/uncode/runtime"
+)
+
+// stackEntry はクライアントに見せるスタックフレーム（関数またはパイプラインの段）
+type stackEntry struct {
+	name   string
+	line   int
+	column int
+	env    *object.Environment
+	pizza  object.Object // 段に渡される値（関数のフレームでは nil で、環境の🍕を使う）
+}
+
+// terminated は実行を打ち切るための panic の値
+type terminated struct{}
+
+// execution はデバッグ中のプログラムの実行
+// debug.Session のフロントエンドとして、評価器のゴルーチンで動く
+// 停止中はリクエストを処理するゴルーチンから渡された関数を評価器のゴルーチンで実行するため、
+// フレームや変数の参照には評価器のゴルーチンからしかアクセスしない
+type execution struct {
+	server  *Server
+	name    string // 診断に表示するファイル名
+	path    string // ブレークポイントを照合するための絶対パス
+	source  string
+	session *debug.Session
+
+	// 停止中に作成したスタックフレームと変数の参照（再開すると無効になる）
+	stack []stackEntry
+	refs  []func() []variable
+
+	calls chan func() bool // 停止中に評価器のゴルーチンで実行する関数（true を返すと再開する）
+	done  chan struct{}
+
+	mu             sync.Mutex
+	paused         bool
+	terminating    bool
+	pauseRequested bool
+}
+
+// newExecution は実行を作成する。stopOnEntry の場合は最初の文で停止する
+func newExecution(server *Server, name string, source string, stopOnEntry bool) *execution {
+	e := &execution{
+		server: server,
+		name:   name,
+		path:   sourceKey(name),
+		source: source,
+		calls:  make(chan func() bool),
+		done:   make(chan struct{}),
+	}
+	e.session = debug.NewSession(e, stopOnEntry)
+	return e
+}
+
+// run はプログラムを実行し、終了したらクライアントに通知する
+func (e *execution) run() {
+	conn := e.server.conn
+	defer close(e.done)
+	defer conn.event("terminated", nil)
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(terminated); !ok {
+				panic(r)
+			}
+		}
+	}()
+
+	// 標準入出力はプロトコルに使うため、プログラムの出力は output イベントで送る
+	evaluator.SetOutput(&outputWriter{conn: conn, category: "stdout"})
+	evaluator.SetInput(strings.NewReader(""))
+	evaluator.SetHook(e.session)
+	defer evaluator.SetHook(nil)
+
+	result, _ := runtime.ExecuteSource(e.name, e.source)
+	for _, d := range result.Diagnostics {
+		conn.event("output", outputBody{Category: "stderr", Output: d.String() + "\n"})
+	}
+	conn.event("exited", exitedBody{ExitCode: result.ExitCode})
+}
+
+// outputWriter はプログラムの出力を output イベントとして送信する
+type outputWriter struct {
+	conn     *conn
+	category string
+}
+
+// Write は io.Writer インターフェースの実装
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.conn.event("output", outputBody{Category: w.category, Output: string(p)})
+	return len(p), nil
+}
+
+// PauseRequested は一時停止の要求を取り出す。終了が要求されていれば実行を打ち切る
+func (e *execution) PauseRequested() bool {
+	e.mu.Lock()
+	terminating, pauseRequested := e.terminating, e.pauseRequested
+	e.pauseRequested = false
+	e.mu.Unlock()
+	if terminating {
+		panic(terminated{})
+	}
+	return pauseRequested
+}
+
+// BreakpointsAt は実行中のファイルの指定した行のブレークポイントを返す
+func (e *execution) BreakpointsAt(line int) []*debug.Breakpoint {
+	return e.server.breakpointsAt(e.path, line)
+}
+
+// Message はデバッガからのメッセージをクライアントのコンソールに表示する
+func (e *execution) Message(format string, args ...interface{}) {
+	e.server.conn.event("output", outputBody{Category: "console", Output: fmt.Sprintf(format, args...)})
+}
+
+// Stop は停止したことを通知し、再開するまで停止中のリクエストを処理する
+func (e *execution) Stop(reason string, hits []*debug.Breakpoint) {
+	var ids []int
+	for _, bp := range hits {
+		ids = append(ids, bp.ID)
+	}
+	e.pause(reason, ids)
+}
+
+// pause は停止したことを通知し、再開するまで停止中のリクエストを処理する
+func (e *execution) pause(reason string, hits []int) {
+	e.mu.Lock()
+	if e.terminating {
+		e.mu.Unlock()
+		panic(terminated{})
+	}
+	e.paused = true
+	e.mu.Unlock()
+
+	e.stack, e.refs = nil, nil
+	e.server.conn.event("stopped", stoppedBody{Reason: reason, ThreadID: threadID, AllThreadsStopped: true, HitBreakpointIDs: hits})
+	for {
+		call := <-e.calls
+		if call() {
+			break
+		}
+	}
+
+	e.mu.Lock()
+	terminating := e.terminating
+	e.mu.Unlock()
+	if terminating {
+		panic(terminated{})
+	}
+}
+
+// resume は指定したモードで実行を再開する関数を返す（評価器のゴルーチンで実行する）
+func (e *execution) resume(mode debug.StepMode) func() bool {
+	return func() bool {
+		e.session.Resume(mode)
+		e.stack, e.refs = nil, nil
+		e.mu.Lock()
+		e.paused = false
+		e.mu.Unlock()
+		return true
+	}
+}
+
+// call は停止中の評価器のゴルーチンで f を実行し、終わるまで待つ
+// 停止していない場合は実行せずに false を返す
+func (e *execution) call(f func() bool) bool {
+	e.mu.Lock()
+	paused := e.paused
+	e.mu.Unlock()
+	if !paused {
+		return false
+	}
+	done := make(chan struct{})
+	e.calls <- func() bool {
+		defer close(done)
+		return f()
+	}
+	<-done
+	return true
+}
+
+// terminate は実行を打ち切る。停止中であればすぐに、実行中であれば次の停止位置で終了する
+func (e *execution) terminate() {
+	e.mu.Lock()
+	e.terminating = true
+	e.mu.Unlock()
+	e.call(e.resume(debug.ModeContinue))
+}
+
+// stepModes は再開するリクエストとステップ実行のモードの対応
+var stepModes = map[string]debug.StepMode{
+	"continue": debug.ModeContinue,
+	"next":     debug.ModeNext,
+	"stepIn":   debug.ModeStep,
+	"stepOut":  debug.ModeFinish,
+}
+
+// handle は実行中のプログラムに対するリクエストを処理する
+func (e *execution) handle(req *request) {
+	conn := e.server.conn
+	if mode, ok := stepModes[req.Command]; ok {
+		// 再開後すぐに停止しても stopped イベントが応答より後になるように、再開する前に応答する
+		resumed := e.call(func() bool {
+			conn.respond(req, map[string]interface{}{"allThreadsContinued": true}, nil)
+			return e.resume(mode)()
+		})
+		if !resumed {
+			conn.respond(req, nil, errRunning)
+		}
+		return
+	}
+
+	var body interface{}
+	var err error
+	switch req.Command {
+	case "pause":
+		e.mu.Lock()
+		e.pauseRequested = true
+		e.mu.Unlock()
+	case "stackTrace":
+		if !e.call(func() bool { body = e.stackTrace(); return false }) {
+			err = errRunning
+		}
+	case "scopes":
+		var args frameArguments
+		if err = unmarshalArguments(req, &args); err == nil && !e.call(func() bool { body, err = e.scopes(args.FrameID); return false }) {
+			err = errRunning
+		}
+	case "variables":
+		var args variablesArguments
+		if err = unmarshalArguments(req, &args); err == nil && !e.call(func() bool { body, err = e.variables(args.VariablesReference); return false }) {
+			err = errRunning
+		}
+	case "evaluate":
+		var args evaluateArguments
+		if err = unmarshalArguments(req, &args); err == nil && !e.call(func() bool { body, err = e.evaluateRequest(args); return false }) {
+			err = fmt.Errorf("実行中は式を評価できません")
+		}
+	default:
+		err = fmt.Errorf("サポートしていないリクエストです: %s", req.Command)
+	}
+	conn.respond(req, body, err)
+}
+
+// stackTrace は内側から順にスタックフレームを作成する
+// 関数の中でパイプラインの段を実行している場合は、その段も1つのフレームとして関数の上に積む
+func (e *execution) stackTrace() interface{} {
+	e.buildStack()
+	frames := make([]stackFrame, len(e.stack))
+	for i, entry := range e.stack {
+		frames[i] = stackFrame{
+			ID:     i + 1,
+			Name:   entry.name,
+			Source: source{Name: filepath.Base(e.name), Path: e.path},
+			Line:   entry.line,
+			Column: entry.column,
+		}
+	}
+	return map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)}
+}
+
+// buildStack は現在のフレームからスタックフレームの一覧を作成する
+func (e *execution) buildStack() {
+	e.stack = nil
+	frames := e.session.Frames
+	for i := len(frames) - 1; i >= 0; i-- {
+		f := frames[i]
+		if f.Stage != nil {
+			e.stack = append(e.stack, stackEntry{
+				name:   fmt.Sprintf("段 %s %s", f.Stage.Operator, f.Stage.Right.String()),
+				line:   f.Stage.Token.Line,
+				column: f.Stage.Token.Column,
+				env:    f.Env,
+				pizza:  f.StageInput,
+			})
+		}
+		e.stack = append(e.stack, stackEntry{name: f.Function, line: f.Line, column: f.Column, env: f.Env})
+	}
+}
+
+// entryAt はスタックフレームの ID からフレームを探す。0 の場合は最も内側のフレームになる
+func (e *execution) entryAt(id int) (stackEntry, error) {
+	if e.stack == nil {
+		e.buildStack()
+	}
+	if id == 0 && len(e.stack) > 0 {
+		id = 1
+	}
+	if id < 1 || id > len(e.stack) {
+		return stackEntry{}, fmt.Errorf("スタックフレームが見つかりません: %d", id)
+	}
+	return e.stack[id-1], nil
+}
+
+// evaluateRequest はフレームの環境で式を評価する
+func (e *execution) evaluateRequest(args evaluateArguments) (interface{}, error) {
+	entry, err := e.entryAt(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := debug.ParseExpression(args.Expression)
+	if err != nil {
+		return nil, err
+	}
+	result := e.session.Evaluate(expr, entry.env)
+	if errObj, ok := result.(*object.Error); ok {
+		return nil, fmt.Errorf("%s", errObj.Message)
+	}
+	v := e.variable("", result)
+	return evaluateBody{Result: v.Value, Type: v.Type, VariablesReference: v.VariablesReference}, nil
+}
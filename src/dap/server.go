@@ -0,0 +1,194 @@
+// Package dap は Debug Adapter Protocol のサーバーを提供する
+// VS Code などのエディタから、ブレークポイントやステップ実行で PooCode のスクリプトをデバッグできるようにする
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/uncode/config"
+	"github.com/uncode/debug"
+)
+
+// threadID は唯一のスレッドの ID（PooCode のプログラムは1つのスレッドで実行される）
+const threadID = 1
+
+// errRunning は停止していないときに停止中だけ有効なリクエストを受け取ったことを表す
+var errRunning = errors.New("プログラムは停止していません")
+
+// Server は標準入出力などでクライアントと通信する DAP サーバー
+type Server struct {
+	in   *bufio.Reader
+	conn *conn
+
+	mu          sync.Mutex
+	breakpoints map[string][]*debug.Breakpoint // 絶対パスごとのブレークポイント
+	nextID      int
+
+	launch     *launchArguments // launch リクエストの引数
+	configured bool             // configurationDone を受け取ったか
+	exec       *execution       // 実行中のプログラム（開始前は nil）
+}
+
+// NewServer はサーバーを作成する
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:          bufio.NewReader(in),
+		conn:        &conn{w: out},
+		breakpoints: make(map[string][]*debug.Breakpoint),
+		nextID:      1,
+	}
+}
+
+// Serve は disconnect を受け取るか入力が終わるまでリクエストを処理する
+func (s *Server) Serve() error {
+	defer s.stop()
+	for {
+		body, err := readMessage(s.in)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("メッセージを解析できませんでした: %w", err)
+		}
+		if req.Type != "request" {
+			continue
+		}
+		if !s.handle(&req) {
+			return nil
+		}
+	}
+}
+
+// handle はリクエストを1つ処理する。disconnect の場合は false を返す
+func (s *Server) handle(req *request) bool {
+	switch req.Command {
+	case "initialize":
+		s.conn.respond(req, capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsConditionalBreakpoints:   true,
+			SupportsEvaluateForHovers:        true,
+			SupportTerminateDebuggee:         true,
+		}, nil)
+		s.conn.event("initialized", nil)
+	case "launch":
+		var args launchArguments
+		err := json.Unmarshal(req.Arguments, &args)
+		if err == nil && args.Program == "" {
+			err = errors.New("launch の引数に program を指定してください")
+		}
+		if err == nil {
+			s.launch = &args
+		}
+		s.conn.respond(req, nil, err)
+		s.start()
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			s.conn.respond(req, nil, err)
+			break
+		}
+		s.conn.respond(req, map[string]interface{}{"breakpoints": s.setBreakpoints(args)}, nil)
+	case "setExceptionBreakpoints":
+		s.conn.respond(req, map[string]interface{}{"breakpoints": []breakpointInfo{}}, nil)
+	case "configurationDone":
+		s.configured = true
+		s.conn.respond(req, nil, nil)
+		s.start()
+	case "threads":
+		s.conn.respond(req, map[string]interface{}{"threads": []thread{{ID: threadID, Name: "main"}}}, nil)
+	case "disconnect", "terminate":
+		s.stop()
+		s.conn.respond(req, nil, nil)
+		return req.Command == "terminate"
+	default:
+		if s.exec == nil {
+			s.conn.respond(req, nil, fmt.Errorf("プログラムが開始されていません: %s", req.Command))
+			break
+		}
+		s.exec.handle(req)
+	}
+	return true
+}
+
+// setBreakpoints はソースファイルのブレークポイントをまとめて置き換える
+func (s *Server) setBreakpoints(args setBreakpointsArguments) []breakpointInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]breakpointInfo, 0, len(args.Breakpoints))
+	var breakpoints []*debug.Breakpoint
+	for _, sb := range args.Breakpoints {
+		info := breakpointInfo{ID: s.nextID, Verified: true, Line: sb.Line}
+		s.nextID++
+		bp, err := debug.NewBreakpoint(info.ID, sb.Line, sb.Condition)
+		if err != nil {
+			info.Verified = false
+			info.Message = fmt.Sprintf("条件式を解析できませんでした: %s", err)
+			infos = append(infos, info)
+			continue
+		}
+		breakpoints = append(breakpoints, bp)
+		infos = append(infos, info)
+	}
+	s.breakpoints[sourceKey(args.Source.Path)] = breakpoints
+	return infos
+}
+
+// breakpointsAt はファイルの指定した行のブレークポイントを返す
+func (s *Server) breakpointsAt(path string, line int) []*debug.Breakpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found []*debug.Breakpoint
+	for _, bp := range s.breakpoints[path] {
+		if bp.Line == line {
+			found = append(found, bp)
+		}
+	}
+	return found
+}
+
+// start は launch と configurationDone の両方を受け取ったらプログラムの実行を開始する
+func (s *Server) start() {
+	if s.launch == nil || !s.configured || s.exec != nil {
+		return
+	}
+
+	content, err := os.ReadFile(s.launch.Program)
+	if err != nil {
+		s.conn.event("output", outputBody{Category: "stderr", Output: fmt.Sprintf("ファイルを読み込めませんでした: %s\n", err)})
+		s.conn.event("terminated", nil)
+		return
+	}
+	config.GlobalConfig.ScriptArgs = s.launch.Args
+
+	s.exec = newExecution(s, s.launch.Program, string(content), s.launch.StopOnEntry)
+	go s.exec.run()
+}
+
+// stop は実行中のプログラムを打ち切り、終了を待つ
+func (s *Server) stop() {
+	if s.exec != nil {
+		s.exec.terminate()
+		<-s.exec.done
+	}
+}
+
+// sourceKey はブレークポイントを照合するためのファイルのパスを返す
+func sourceKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
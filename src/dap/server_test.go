@@ -0,0 +1,321 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uncode/evaluator"
+	"github.com/uncode/logger"
+)
+
+// testMessage はテストで受け取る応答とイベント
+type testMessage struct {
+	Type    string          `json:"type"`
+	Command string          `json:"command"`
+	Event   string          `json:"event"`
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// testClient はスクリプトどおりにリクエストを送る DAP クライアント
+type testClient struct {
+	t        *testing.T
+	w        io.Writer
+	messages chan testMessage
+	seq      int
+	output   strings.Builder // 受け取ったプログラムの出力
+}
+
+func newTestClient(t *testing.T) *testClient {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	server := NewServer(inR, outW)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve()
+		outW.Close()
+	}()
+
+	c := &testClient{t: t, w: inW, messages: make(chan testMessage, 100)}
+	go func() {
+		defer close(c.messages)
+		r := bufio.NewReader(outR)
+		for {
+			body, err := readMessage(r)
+			if err != nil {
+				return
+			}
+			var m testMessage
+			if err := json.Unmarshal(body, &m); err != nil {
+				t.Errorf("invalid message: %s", body)
+				return
+			}
+			c.messages <- m
+		}
+	}()
+	t.Cleanup(func() {
+		inW.Close()
+		if err := <-serveErr; err != nil {
+			t.Errorf("Serve returned an error: %s", err)
+		}
+	})
+	return c
+}
+
+// send はリクエストを送信する
+func (c *testClient) send(command string, args interface{}) {
+	c.seq++
+	body, _ := json.Marshal(map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": args})
+	if err := writeMessage(c.w, body); err != nil {
+		c.t.Fatalf("failed to send %s: %s", command, err)
+	}
+}
+
+// next は指定した応答かイベントが届くまで読み進める。途中のプログラムの出力は記録する
+func (c *testClient) next(kind string, name string) testMessage {
+	c.t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case m, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("connection closed while waiting for %s %s", kind, name)
+			}
+			if m.Type == "event" && m.Event == "output" {
+				var body outputBody
+				json.Unmarshal(m.Body, &body)
+				if body.Category == "stdout" {
+					c.output.WriteString(body.Output)
+				}
+			}
+			if m.Type == kind && (m.Command == name || m.Event == name) {
+				return m
+			}
+		case <-timeout:
+			c.t.Fatalf("timed out waiting for %s %s", kind, name)
+		}
+	}
+}
+
+// request はリクエストを送信して成功した応答の本体を v に読み込む
+func (c *testClient) request(command string, args interface{}, v interface{}) {
+	c.t.Helper()
+	c.send(command, args)
+	m := c.next("response", command)
+	if !m.Success {
+		c.t.Fatalf("%s failed: %s", command, m.Message)
+	}
+	if v != nil {
+		if err := json.Unmarshal(m.Body, v); err != nil {
+			c.t.Fatalf("invalid %s body: %s", command, m.Body)
+		}
+	}
+}
+
+// stackTrace は現在のスタックフレームを返す
+func (c *testClient) stackTrace() []stackFrame {
+	c.t.Helper()
+	var body struct {
+		StackFrames []stackFrame `json:"stackFrames"`
+	}
+	c.request("stackTrace", map[string]int{"threadId": threadID}, &body)
+	return body.StackFrames
+}
+
+// variables は変数の参照を展開して名前から変数を引けるようにする
+func (c *testClient) variables(ref int) map[string]variable {
+	c.t.Helper()
+	var body struct {
+		Variables []variable `json:"variables"`
+	}
+	c.request("variables", map[string]int{"variablesReference": ref}, &body)
+	vars := make(map[string]variable)
+	for _, v := range body.Variables {
+		vars[v.Name] = v
+	}
+	return vars
+}
+
+// scope はフレームのスコープを名前で探して変数を返す
+func (c *testClient) scope(frameID int, name string) map[string]variable {
+	c.t.Helper()
+	var body struct {
+		Scopes []scope `json:"scopes"`
+	}
+	c.request("scopes", map[string]int{"frameId": frameID}, &body)
+	for _, s := range body.Scopes {
+		if s.Name == name {
+			return c.variables(s.VariablesReference)
+		}
+	}
+	c.t.Fatalf("scope %q not found in %v", name, body.Scopes)
+	return nil
+}
+
+// TestDebugSession はブレークポイント、パイプラインの段のフレーム、スコープと変数（ストリームを含む）の展開、ステップ実行をテストする
+func TestDebugSession(t *testing.T) {
+	logger.SetLevel(logger.LevelOff)
+	t.Cleanup(func() {
+		evaluator.SetOutput(os.Stdout)
+		evaluator.SetInput(os.Stdin)
+	})
+
+	program := filepath.Join(t.TempDir(), "main.poo")
+	source := strings.Join([]string{
+		"[1, 2, 3] >> a; [1..] >> r;",
+		`"{\"k\": [1, 2]}" |> json_parse >> h;`,
+		"def sq(): int -> int {",
+		"  🍕 * 🍕 >> 💩;",
+		"}",
+		"2 |> sq >> b;",
+		"3 |> sq |> print;",
+	}, "\n")
+	if err := os.WriteFile(program, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestClient(t)
+	var caps capabilities
+	c.request("initialize", map[string]string{"adapterID": "poo"}, &caps)
+	if !caps.SupportsConditionalBreakpoints {
+		t.Errorf("conditional breakpoints should be supported")
+	}
+	c.next("event", "initialized")
+
+	c.request("launch", map[string]interface{}{"program": program}, nil)
+	var bps struct {
+		Breakpoints []breakpointInfo `json:"breakpoints"`
+	}
+	c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]string{"path": program},
+		"breakpoints": []map[string]interface{}{{"line": 4, "condition": "🍕 == 3"}},
+	}, &bps)
+	if len(bps.Breakpoints) != 1 || !bps.Breakpoints[0].Verified {
+		t.Fatalf("breakpoint should be verified. got=%+v", bps.Breakpoints)
+	}
+	c.request("configurationDone", nil, nil)
+
+	// 1回目の sq (🍕 = 2) では条件を満たさないので停止しない
+	var stopped stoppedBody
+	json.Unmarshal(c.next("event", "stopped").Body, &stopped)
+	if stopped.Reason != "breakpoint" {
+		t.Errorf("wrong stop reason. got=%q", stopped.Reason)
+	}
+
+	// 関数と、その関数を呼び出したパイプラインの段がそれぞれフレームになる
+	frames := c.stackTrace()
+	expected := []struct {
+		name string
+		line int
+	}{
+		{"sq", 4},
+		{"段 |> sq", 7},
+		{"<メイン>", 7},
+	}
+	if len(frames) != len(expected) {
+		t.Fatalf("wrong number of frames. got=%+v", frames)
+	}
+	for i, e := range expected {
+		if frames[i].Name != e.name || frames[i].Line != e.line {
+			t.Errorf("frame %d wrong. expected=%s:%d, got=%s:%d", i, e.name, e.line, frames[i].Name, frames[i].Line)
+		}
+	}
+
+	if pizza := c.scope(frames[0].ID, "🍕 と 💩")["🍕"]; pizza.Value != "3" || pizza.Type != "INTEGER" {
+		t.Errorf("wrong 🍕 in sq. got=%+v", pizza)
+	}
+
+	// 配列とハッシュマップは子要素を展開できる
+	locals := c.scope(frames[2].ID, "ローカル変数")
+	if locals["b"].Value != "4" {
+		t.Errorf("wrong local b. got=%+v", locals["b"])
+	}
+	if a := locals["a"]; a.VariablesReference == 0 {
+		t.Errorf("array should be expandable. got=%+v", a)
+	} else if elements := c.variables(a.VariablesReference); elements["[2]"].Value != "3" {
+		t.Errorf("wrong array element. got=%+v", elements)
+	}
+	if h := locals["h"]; h.VariablesReference == 0 {
+		t.Errorf("hash should be expandable. got=%+v", h)
+	} else if k := c.variables(h.VariablesReference)["k"]; k.VariablesReference == 0 {
+		t.Errorf("nested array should be expandable. got=%+v", k)
+	} else if elements := c.variables(k.VariablesReference); elements["[1]"].Value != "2" {
+		t.Errorf("wrong nested element. got=%+v", elements)
+	}
+
+	// 終わりのないストリームは先頭の要素だけを展開する
+	if r := locals["r"]; r.VariablesReference == 0 {
+		t.Errorf("stream should be expandable. got=%+v", r)
+	} else if elements := c.variables(r.VariablesReference); len(elements) != streamPreviewLimit+1 || elements["[99]"].Value != "100" || elements["..."].Value == "" {
+		t.Errorf("stream should be expanded up to the limit. got %d elements: [99]=%+v", len(elements), elements["[99]"])
+	}
+
+	var result evaluateBody
+	c.request("evaluate", map[string]interface{}{"expression": "🍕 * 10", "frameId": frames[0].ID}, &result)
+	if result.Result != "30" {
+		t.Errorf("wrong evaluate result. got=%q", result.Result)
+	}
+
+	// 関数から戻ると、次の段で停止する
+	c.request("stepOut", map[string]int{"threadId": threadID}, nil)
+	json.Unmarshal(c.next("event", "stopped").Body, &stopped)
+	frames = c.stackTrace()
+	if stopped.Reason != "step" || len(frames) != 2 || frames[0].Name != "段 |> print" {
+		t.Fatalf("should stop at the next stage. got=%q %+v", stopped.Reason, frames)
+	}
+	if pizza := c.scope(frames[0].ID, "🍕 と 💩")["🍕"]; pizza.Value != "9" {
+		t.Errorf("🍕 should be the stage input. got=%+v", pizza)
+	}
+
+	c.request("continue", map[string]int{"threadId": threadID}, nil)
+	var exited exitedBody
+	json.Unmarshal(c.next("event", "exited").Body, &exited)
+	if exited.ExitCode != 0 {
+		t.Errorf("wrong exit code. got=%d", exited.ExitCode)
+	}
+	c.next("event", "terminated")
+	if c.output.String() != "9\n" {
+		t.Errorf("program output should be sent as output events. got=%q", c.output.String())
+	}
+	c.request("disconnect", nil, nil)
+}
+
+// TestDisconnectWhilePaused は停止中に切断するとプログラムを打ち切ることをテストする
+func TestDisconnectWhilePaused(t *testing.T) {
+	logger.SetLevel(logger.LevelOff)
+	t.Cleanup(func() {
+		evaluator.SetOutput(os.Stdout)
+		evaluator.SetInput(os.Stdin)
+	})
+
+	program := filepath.Join(t.TempDir(), "main.poo")
+	if err := os.WriteFile(program, []byte("1 |> print;\n2 |> print;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestClient(t)
+	c.request("initialize", nil, nil)
+	c.request("launch", map[string]interface{}{"program": program, "stopOnEntry": true}, nil)
+	c.request("configurationDone", nil, nil)
+
+	var stopped stoppedBody
+	json.Unmarshal(c.next("event", "stopped").Body, &stopped)
+	if stopped.Reason != "entry" {
+		t.Errorf("wrong stop reason. got=%q", stopped.Reason)
+	}
+	if frames := c.stackTrace(); len(frames) != 1 || frames[0].Line != 1 {
+		t.Errorf("should stop at the first statement. got=%+v", frames)
+	}
+
+	c.request("disconnect", nil, nil)
+	if c.output.String() != "" {
+		t.Errorf("program should not run after disconnect. got=%q", c.output.String())
+	}
+}
@@ -0,0 +1,120 @@
+package dap
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/uncode/debug"
+	"github.com/uncode/object"
+)
+
+// streamPreviewLimit はストリームを展開するときに計算する要素の最大数
+const streamPreviewLimit = 100
+
+// scopes はフレームの🍕と💩、ローカル変数のスコープを作成する
+func (e *execution) scopes(frameID int) (interface{}, error) {
+	entry, err := e.entryAt(frameID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"scopes": []scope{
+		{Name: "🍕 と 💩", VariablesReference: e.addRef(func() []variable { return e.pizzaAndPoo(entry) })},
+		{Name: "ローカル変数", VariablesReference: e.addRef(func() []variable { return e.locals(entry.env) })},
+	}}, nil
+}
+
+// variables は変数の参照が指す子要素を返す
+func (e *execution) variables(ref int) (interface{}, error) {
+	if ref < 1 || ref > len(e.refs) {
+		return nil, fmt.Errorf("変数の参照が見つかりません: %d", ref)
+	}
+	return map[string]interface{}{"variables": e.refs[ref-1]()}, nil
+}
+
+// addRef は子要素を展開する関数を登録し、その参照を返す
+func (e *execution) addRef(children func() []variable) int {
+	e.refs = append(e.refs, children)
+	return len(e.refs)
+}
+
+// pizzaAndPoo はフレームの🍕と💩を返す
+// パイプラインの段では🍕は段に渡される値、fold の中では💩は累積値になる
+func (e *execution) pizzaAndPoo(entry stackEntry) []variable {
+	pizza := entry.pizza
+	if pizza == nil {
+		pizza, _ = entry.env.Get("🍕")
+	}
+	poo, _ := entry.env.Get("💩")
+	return []variable{e.variable("🍕", pizza), e.variable("💩", poo)}
+}
+
+// locals は環境の変数を名前順に返す
+func (e *execution) locals(env *object.Environment) []variable {
+	names, all := debug.Variables(env)
+	vars := make([]variable, len(names))
+	for i, name := range names {
+		vars[i] = e.variable(name, all[name])
+	}
+	return vars
+}
+
+// variable は値を変数として表示する。配列とハッシュマップ、ストリームは子要素を展開できるようにする
+// ストリームは走査し直せるもの（範囲式や変数に代入したもの）だけを展開し、展開したときに先頭の要素だけを計算する
+func (e *execution) variable(name string, value object.Object) variable {
+	if rv, ok := value.(*object.ReturnValue); ok {
+		value = rv.Value
+	}
+	v := variable{Name: name, Value: debug.Inspect(value)}
+	if value == nil {
+		return v
+	}
+	v.Type = string(value.Type())
+
+	switch value := value.(type) {
+	case *object.Array:
+		if len(value.Elements) > 0 {
+			v.VariablesReference = e.addRef(func() []variable {
+				children := make([]variable, len(value.Elements))
+				for i, element := range value.Elements {
+					children[i] = e.variable(fmt.Sprintf("[%d]", i), element)
+				}
+				return children
+			})
+		}
+	case *object.Hash:
+		if value.Len() > 0 {
+			v.VariablesReference = e.addRef(func() []variable {
+				pairs := value.Pairs()
+				children := make([]variable, len(pairs))
+				for i, pair := range pairs {
+					children[i] = e.variable(pair.Key.Inspect(), pair.Value)
+				}
+				return children
+			})
+		}
+	case *object.Stream:
+		if !value.Replayable {
+			break
+		}
+		v.VariablesReference = e.addRef(func() []variable {
+			elements, more := e.session.Preview(value, streamPreviewLimit)
+			children := make([]variable, len(elements), len(elements)+1)
+			for i, element := range elements {
+				children[i] = e.variable(fmt.Sprintf("[%d]", i), element)
+			}
+			if more {
+				children = append(children, variable{Name: "...", Value: fmt.Sprintf("(先頭の %d 要素だけを表示しています)", streamPreviewLimit)})
+			}
+			return children
+		})
+	}
+	return v
+}
+
+// unmarshalArguments はリクエストの引数を読み込む
+func unmarshalArguments(req *request, v interface{}) error {
+	if len(req.Arguments) == 0 {
+		return fmt.Errorf("%s の引数がありません", req.Command)
+	}
+	return json.Unmarshal(req.Arguments, v)
+}
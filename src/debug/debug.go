@@ -0,0 +1,358 @@
+// Package debug は debug サブコマンドと DAP サーバーが共有するデバッガの中核
+// 評価器のフックとして登録され、文とパイプラインの段の前でステップ実行やブレークポイントによる停止を判断する
+// 停止したときの表示やコマンドの受け付けは Frontend が受け持つ
+package debug
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/uncode/ast"
+	"github.com/uncode/evaluator"
+	"github.com/uncode/lexer"
+	"github.com/uncode/object"
+	"github.com/uncode/parser"
+)
+
+const (
+	// MainFrameName はトップレベルのフレームの名前
+	MainFrameName = "<メイン>"
+	// UnsetValue は値が設定されていない🍕や💩の表示
+	UnsetValue = "(未設定)"
+)
+
+// StepMode は次にどこで停止するかを表す
+type StepMode int
+
+const (
+	ModeContinue StepMode = iota // ブレークポイントまで停止しない
+	ModeStep                     // 次の停止位置で停止する (step / stepIn)
+	ModeNext                     // 同じ深さか浅い関数の停止位置で停止する (next)
+	ModeFinish                   // 現在の関数から戻った後の停止位置で停止する (finish / stepOut)
+)
+
+// 停止した理由
+const (
+	ReasonStep       = "step"       // ステップ実行
+	ReasonEntry      = "entry"      // 最初の文
+	ReasonPause      = "pause"      // 一時停止の要求
+	ReasonBreakpoint = "breakpoint" // ブレークポイント
+)
+
+// Breakpoint は行ブレークポイント
+type Breakpoint struct {
+	ID        int
+	Line      int
+	Condition string         // 条件式のソース（条件なしの場合は空）
+	Expr      ast.Expression // 条件式（条件なしの場合は nil）
+}
+
+// NewBreakpoint はブレークポイントを作成する。condition が空でなければ条件式として解析する
+func NewBreakpoint(id int, line int, condition string) (*Breakpoint, error) {
+	bp := &Breakpoint{ID: id, Line: line}
+	if condition = strings.TrimSpace(condition); condition != "" {
+		expr, err := ParseExpression(condition)
+		if err != nil {
+			return nil, err
+		}
+		bp.Condition, bp.Expr = condition, expr
+	}
+	return bp, nil
+}
+
+// Frame は実行中のユーザー定義関数の呼び出し
+type Frame struct {
+	Function string
+	Env      *object.Environment
+	Line     int // この関数の中で最後に通過した位置（呼び出し元としての位置にもなる）
+	Column   int
+	// Stage はこの関数の中で実行中のパイプラインの段（次の文に進むと nil になる）
+	Stage      *ast.InfixExpression
+	StageInput object.Object // 段に渡される値（🍕）
+}
+
+// Pizza はフレームの🍕を返す。パイプラインの段ではその段に渡される値になる
+func (f *Frame) Pizza() object.Object {
+	if f.Stage != nil {
+		return f.StageInput
+	}
+	value, _ := f.Env.Get("🍕")
+	return value
+}
+
+// Poo はフレームの💩を返す。fold の中では累積値になる
+func (f *Frame) Poo() object.Object {
+	value, _ := f.Env.Get("💩")
+	return value
+}
+
+// Frontend は停止したときの表示やコマンドの受け付けを受け持つ
+type Frontend interface {
+	// PauseRequested は停止位置に到達するたびに呼ばれ、一時停止が要求されていれば true を返す
+	// 実行を打ち切る場合は panic で評価を抜けてよい
+	PauseRequested() bool
+	// BreakpointsAt は指定した行のブレークポイントを返す
+	BreakpointsAt(line int) []*Breakpoint
+	// Message はデバッガからのメッセージ（条件式のエラーや関数の戻り値）を表示する
+	Message(format string, args ...interface{})
+	// Stop は停止したときに呼ばれ、実行を再開するまで戻らない
+	// hits は停止したブレークポイント（ブレークポイント以外で停止した場合は nil）
+	Stop(reason string, hits []*Breakpoint)
+}
+
+// Session は evaluator.Hook を実装し、呼び出し履歴を追いながら停止する位置を判断する
+type Session struct {
+	Frames     []*Frame
+	LastReturn object.Object // 直前に戻った関数の戻り値（💩）
+
+	frontend   Frontend
+	mode       StepMode
+	stepDepth  int  // next / finish を指定したときの呼び出しの深さ
+	entry      bool // 次の停止が最初の文での停止か
+	evaluating bool // 条件式や式の評価中はフックを無視する
+}
+
+// NewSession はセッションを作成する。stopOnEntry の場合は最初の文で停止する
+func NewSession(frontend Frontend, stopOnEntry bool) *Session {
+	s := &Session{
+		Frames:   []*Frame{{Function: MainFrameName}},
+		frontend: frontend,
+		entry:    stopOnEntry,
+	}
+	if stopOnEntry {
+		s.mode = ModeStep
+	}
+	return s
+}
+
+// Top は最も内側のフレームを返す
+func (s *Session) Top() *Frame {
+	return s.Frames[len(s.Frames)-1]
+}
+
+// Resume は指定したモードで実行を再開するように設定する
+// next と finish は現在の呼び出しの深さを基準にする
+func (s *Session) Resume(mode StepMode) {
+	s.mode, s.stepDepth = mode, len(s.Frames)
+}
+
+// BeforeEval は文を評価する前に停止するかどうかを判断する
+func (s *Session) BeforeEval(node ast.Node, env *object.Environment) {
+	if s.evaluating {
+		return
+	}
+	var tok ast.Node
+	switch stmt := node.(type) {
+	case *ast.ExpressionStatement:
+		// 関数の定義では停止しない
+		if stmt == nil {
+			return
+		}
+		if _, ok := stmt.Expression.(*ast.FunctionLiteral); ok {
+			return
+		}
+		tok = stmt
+	case *ast.AssignStatement:
+		if stmt == nil {
+			return
+		}
+		tok = stmt
+	default:
+		return
+	}
+	line, column := position(tok)
+	top := s.Top()
+	top.Stage, top.StageInput = nil, nil
+	s.reach(top, line, column, env)
+}
+
+// position は文の位置を返す
+func position(node ast.Node) (int, int) {
+	switch stmt := node.(type) {
+	case *ast.ExpressionStatement:
+		return stmt.Token.Line, stmt.Token.Column
+	case *ast.AssignStatement:
+		return stmt.Token.Line, stmt.Token.Column
+	}
+	return 0, 0
+}
+
+// BeforeStage はパイプラインの段に値を渡す前に停止するかどうかを判断する
+func (s *Session) BeforeStage(node *ast.InfixExpression, input object.Object, env *object.Environment) {
+	if s.evaluating {
+		return
+	}
+	top := s.Top()
+	top.Stage, top.StageInput = node, input
+	s.reach(top, node.Token.Line, node.Token.Column, env)
+}
+
+// AfterStage は何もしない（段のフレームは次の段に進むか関数から戻るまで残す）
+func (s *Session) AfterStage(node *ast.InfixExpression, result object.Object) {}
+
+// EnterFunction はフレームを追加する
+func (s *Session) EnterFunction(fn *object.Function, env *object.Environment) {
+	if s.evaluating {
+		return
+	}
+	s.Frames = append(s.Frames, &Frame{Function: evaluator.FunctionName(fn), Env: env})
+}
+
+// ExitFunction はフレームを取り除き、戻り値を記録する
+func (s *Session) ExitFunction(fn *object.Function, result object.Object) {
+	if s.evaluating {
+		return
+	}
+	frame := s.Top()
+	s.Frames = s.Frames[:len(s.Frames)-1]
+	if rv, ok := result.(*object.ReturnValue); ok {
+		result = rv.Value
+	}
+	s.LastReturn = result
+
+	// finish した関数から戻ったら次の停止位置で停止する
+	// +> のように同じ関数が続けて呼ばれても、呼び出しの深さが戻るので深さでは判断しない
+	if s.mode == ModeFinish && len(s.Frames) < s.stepDepth {
+		if result != nil {
+			s.frontend.Message("%s の戻り値 (💩): %s\n", frame.Function, result.Inspect())
+		}
+		s.mode = ModeStep
+	}
+}
+
+// SelectCase は何もしない（case 文のブロックの中の文で停止する）
+func (s *Session) SelectCase(arm ast.Statement, env *object.Environment) {}
+
+// reach は停止位置に到達したときに、停止するかどうかを判断する
+func (s *Session) reach(top *Frame, line int, column int, env *object.Environment) {
+	pauseRequested := s.frontend.PauseRequested()
+
+	newLine := top.Line != line
+	top.Line, top.Column, top.Env = line, column, env
+
+	reason := ""
+	switch {
+	case s.mode == ModeStep, s.mode == ModeNext && len(s.Frames) <= s.stepDepth:
+		reason = ReasonStep
+		if s.entry {
+			reason = ReasonEntry
+		}
+	case pauseRequested:
+		reason = ReasonPause
+	}
+
+	// ブレークポイントは同じ行の中の段では繰り返し停止しない
+	var hits []*Breakpoint
+	if reason == "" && newLine {
+		for _, bp := range s.frontend.BreakpointsAt(line) {
+			if s.conditionHolds(bp, env) {
+				hits = append(hits, bp)
+			}
+		}
+		if len(hits) > 0 {
+			reason = ReasonBreakpoint
+		}
+	}
+	if reason != "" {
+		s.entry = false
+		s.frontend.Stop(reason, hits)
+	}
+}
+
+// conditionHolds は条件付きブレークポイントの条件を評価する
+// 条件の評価でエラーが発生した場合や真偽値でない場合は、メッセージを表示して停止する
+func (s *Session) conditionHolds(bp *Breakpoint, env *object.Environment) bool {
+	if bp.Expr == nil {
+		return true
+	}
+	switch v := s.Evaluate(bp.Expr, env).(type) {
+	case *object.Boolean:
+		return v.Value
+	case *object.Error:
+		s.frontend.Message("ブレークポイント %d の条件式でエラーが発生しました: %s\n", bp.ID, v.Message)
+	default:
+		s.frontend.Message("ブレークポイント %d の条件式が真偽値ではありません: %s\n", bp.ID, Inspect(v))
+	}
+	return true
+}
+
+// Evaluate はフックを無効にして式を評価する
+func (s *Session) Evaluate(expr ast.Expression, env *object.Environment) object.Object {
+	s.evaluating = true
+	defer func() { s.evaluating = false }()
+	return evaluator.Eval(expr, env)
+}
+
+// Preview はフックを無効にして、ストリームの先頭から最大 limit 個の要素を計算する
+// 残りの要素がある場合は more が true になる。要素の計算でエラーが発生した場合はエラーを最後の要素にする
+func (s *Session) Preview(stream *object.Stream, limit int) (elements []object.Object, more bool) {
+	s.evaluating = true
+	defer func() { s.evaluating = false }()
+	next := stream.Iterate()
+	for {
+		elem, ok := next()
+		if !ok {
+			return elements, false
+		}
+		if len(elements) == limit {
+			return elements, true
+		}
+		elements = append(elements, elem)
+		if _, ok := elem.(*object.Error); ok {
+			return elements, false
+		}
+	}
+}
+
+// ParseExpression はデバッガのコマンドやクライアントから受け取った式を解析する
+func ParseExpression(source string) (ast.Expression, error) {
+	tokens, err := lexer.NewLexer(source).Tokenize()
+	if err != nil {
+		return nil, err
+	}
+	program, err := parser.NewParser(tokens).ParseProgram()
+	if err != nil {
+		return nil, err
+	}
+	if len(program.Statements) != 1 {
+		return nil, fmt.Errorf("式を1つだけ指定してください")
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok || stmt.Expression == nil {
+		return nil, fmt.Errorf("式を指定してください")
+	}
+	return stmt.Expression, nil
+}
+
+// Variables は環境の変数を名前順に返す
+// 組み込み関数と、条件付き関数の内部的な登録名は表示しない
+func Variables(env *object.Environment) ([]string, map[string]object.Object) {
+	all := env.GetVariables()
+	names := make([]string, 0, len(all))
+	for name, value := range all {
+		if _, ok := value.(*object.Builtin); ok || strings.Contains(name, "#") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, all
+}
+
+// Inspect は値を表示用の文字列にする
+func Inspect(value object.Object) string {
+	switch v := value.(type) {
+	case nil:
+		return UnsetValue
+	case *object.ReturnValue:
+		// 💩リテラルは値が入る前の空の戻り値として評価される
+		if v.Value == nil {
+			return UnsetValue
+		}
+		return v.Value.Inspect()
+	case *object.Error:
+		return "エラー: " + v.Message
+	}
+	return value.Inspect()
+}
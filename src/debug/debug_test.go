@@ -0,0 +1,37 @@
+package debug
+
+import (
+	"testing"
+
+	"github.com/uncode/object"
+)
+
+// TestParseExpression はデバッガで指定する式の解析をテストする
+func TestParseExpression(t *testing.T) {
+	if _, err := ParseExpression("🍕 * 10"); err != nil {
+		t.Errorf("ParseExpression failed: %s", err)
+	}
+	if _, err := ParseExpression("1; 2"); err == nil {
+		t.Errorf("ParseExpression should reject more than one expression")
+	}
+}
+
+// TestVariables は組み込み関数と条件付き関数の内部的な登録名を除いて変数を返すことをテストする
+func TestVariables(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Set("b", &object.Integer{Value: 2})
+	env.Set("a", &object.Integer{Value: 1})
+	env.Set("f#1", &object.Integer{Value: 3})
+	env.Set("print", &object.Builtin{})
+
+	names, values := Variables(env)
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Fatalf("Variables should return a and b in order. got=%v", names)
+	}
+	if Inspect(values["a"]) != "1" {
+		t.Errorf("values[a] = %s", Inspect(values["a"]))
+	}
+	if Inspect(nil) != UnsetValue {
+		t.Errorf("Inspect(nil) should be %q", UnsetValue)
+	}
+}
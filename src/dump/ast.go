@@ -0,0 +1,189 @@
+// Package dump はトークン列と AST を JSON・Graphviz (dot)・S式の形式で出力する
+// 外部のツールから使うことや、パーサーの変更を確認することを目的に、すべてのノードと位置を省略せずに出力する
+package dump
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/uncode/ast"
+	"github.com/uncode/token"
+)
+
+// node は出力する形式に依存しない木の節
+// AST のノードとトークンのどちらもこの形に変換してから出力する
+type node struct {
+	kind   string // ノードの型名 (InfixExpression など) やトークンの種類
+	line   int    // 位置（Program のように位置を持たない場合は 0）
+	column int
+	fields []field
+}
+
+// field は節の属性。値は string, int64, float64, bool, *node, []*node のいずれかで、
+// 値のない子ノードは (*node)(nil) になる
+type field struct {
+	name  string
+	value interface{}
+}
+
+// add は属性を追加する
+func (n *node) add(name string, value interface{}) *node {
+	n.fields = append(n.fields, field{name: name, value: value})
+	return n
+}
+
+// newNode はトークンの位置を持つ節を作成する
+func newNode(kind string, tok token.Token) *node {
+	return &node{kind: kind, line: tok.Line, column: tok.Column}
+}
+
+// isNil は nil のインターフェースと、インターフェースに入った nil のポインタのどちらも nil として扱う
+func isNil(n ast.Node) bool {
+	if n == nil {
+		return true
+	}
+	v := reflect.ValueOf(n)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// fromAST は AST のノードを節に変換する
+func fromAST(n ast.Node) *node {
+	if isNil(n) {
+		return nil
+	}
+	switch n := n.(type) {
+	case *ast.Program:
+		return (&node{kind: "Program"}).add("statements", statements(n.Statements))
+
+	// 文
+	case *ast.ExpressionStatement:
+		return newNode("ExpressionStatement", n.Token).add("expression", fromAST(n.Expression))
+	case *ast.AssignStatement:
+		return newNode("AssignStatement", n.Token).add("left", fromAST(n.Left)).add("value", fromAST(n.Value))
+	case *ast.PipeStatement:
+		return newNode("PipeStatement", n.Token).add("left", fromAST(n.Left)).add("right", fromAST(n.Right)).add("isParallel", n.IsParallel)
+	case *ast.BlockStatement:
+		return newNode("BlockStatement", n.Token).add("statements", statements(n.Statements))
+	case *ast.GlobalStatement:
+		return newNode("GlobalStatement", n.Token).add("name", fromAST(n.Name)).add("type", n.Type)
+	case *ast.CaseStatement:
+		return newNode("CaseStatement", n.Token).add("condition", fromAST(n.Condition)).add("consequence", fromAST(n.Consequence)).add("body", fromAST(n.Body))
+	case *ast.DefaultCaseStatement:
+		return newNode("DefaultCaseStatement", n.Token).add("body", fromAST(n.Body))
+
+	// 式
+	case *ast.Identifier:
+		return newNode("Identifier", n.Token).add("value", n.Value)
+	case *ast.PrefixExpression:
+		return newNode("PrefixExpression", n.Token).add("operator", n.Operator).add("right", fromAST(n.Right))
+	case *ast.InfixExpression:
+		return newNode("InfixExpression", n.Token).add("operator", n.Operator).add("left", fromAST(n.Left)).add("right", fromAST(n.Right))
+	case *ast.CallExpression:
+		return newNode("CallExpression", n.Token).add("function", fromAST(n.Function)).add("arguments", expressions(n.Arguments))
+	case *ast.PropertyAccessExpression:
+		return newNode("PropertyAccessExpression", n.Token).add("object", fromAST(n.Object)).add("property", fromAST(n.Property))
+	case *ast.IndexExpression:
+		return newNode("IndexExpression", n.Token).add("left", fromAST(n.Left)).add("index", fromAST(n.Index))
+	case *ast.RangeExpression:
+		return newNode("RangeExpression", n.Token).add("start", fromAST(n.Start)).add("end", fromAST(n.End))
+	case *ast.BlockExpression:
+		return newNode("BlockExpression", n.Token).add("block", fromAST(n.Block))
+	case *ast.FunctionLiteral:
+		cases := make([]*node, len(n.Cases))
+		for i, c := range n.Cases {
+			cases[i] = fromAST(c)
+		}
+		return newNode("FunctionLiteral", n.Token).
+			add("name", fromAST(n.Name)).
+			add("parameters", identifiers(n.Parameters)).
+			add("inputType", n.InputType).
+			add("returnType", n.ReturnType).
+			add("condition", fromAST(n.Condition)).
+			add("body", fromAST(n.Body)).
+			add("cases", cases)
+
+	// リテラル
+	case *ast.IntegerLiteral:
+		return newNode("IntegerLiteral", n.Token).add("value", n.Value)
+	case *ast.FloatLiteral:
+		return newNode("FloatLiteral", n.Token).add("value", n.Value)
+	case *ast.StringLiteral:
+		return newNode("StringLiteral", n.Token).add("value", n.Value)
+	case *ast.InterpolatedString:
+		return newNode("InterpolatedString", n.Token).add("parts", expressions(n.Parts))
+	case *ast.BooleanLiteral:
+		return newNode("BooleanLiteral", n.Token).add("value", n.Value)
+	case *ast.ArrayLiteral:
+		return newNode("ArrayLiteral", n.Token).add("elements", expressions(n.Elements))
+	case *ast.ClassLiteral:
+		properties := make([]*node, len(n.Properties))
+		for i, p := range n.Properties {
+			properties[i] = fromAST(p)
+		}
+		methods := make([]*node, len(n.Methods))
+		for i, m := range n.Methods {
+			methods[i] = fromAST(m)
+		}
+		return newNode("ClassLiteral", n.Token).
+			add("name", fromAST(n.Name)).
+			add("extends", fromAST(n.Extends)).
+			add("properties", properties).
+			add("methods", methods)
+	case *ast.PropertyDefinition:
+		return newNode("PropertyDefinition", n.Token).add("name", fromAST(n.Name)).add("type", n.Type).add("visibility", n.Visibility)
+	case *ast.EnumLiteral:
+		return newNode("EnumLiteral", n.Token).add("name", fromAST(n.Name)).add("values", identifiers(n.Values))
+	case *ast.PizzaLiteral:
+		return newNode("PizzaLiteral", n.Token)
+	case *ast.PooLiteral:
+		return newNode("PooLiteral", n.Token)
+	}
+	// 新しいノードの型を追加したときに出力から黙って消えないように、型名だけでも出力する
+	return &node{kind: fmt.Sprintf("%T", n)}
+}
+
+func statements(list []ast.Statement) []*node {
+	nodes := make([]*node, len(list))
+	for i, s := range list {
+		nodes[i] = fromAST(s)
+	}
+	return nodes
+}
+
+func expressions(list []ast.Expression) []*node {
+	nodes := make([]*node, len(list))
+	for i, e := range list {
+		nodes[i] = fromAST(e)
+	}
+	return nodes
+}
+
+func identifiers(list []*ast.Identifier) []*node {
+	nodes := make([]*node, len(list))
+	for i, id := range list {
+		nodes[i] = fromAST(id)
+	}
+	return nodes
+}
+
+// fromTokens はトークン列を節の並びに変換する
+// 補間文字列は文字列部分と ${...} 内の式のトークン列を parts に含める
+func fromTokens(tokens []token.Token) []*node {
+	nodes := make([]*node, len(tokens))
+	for i, tok := range tokens {
+		n := newNode(string(tok.Type), tok).add("literal", tok.Literal)
+		if tok.Type == token.INTERP_STRING {
+			parts := make([]*node, len(tok.Parts))
+			for j, part := range tok.Parts {
+				p := (&node{kind: "StringPart"}).add("isExpr", part.IsExpr).add("value", part.Value)
+				if part.IsExpr {
+					p.add("tokens", fromTokens(part.Tokens))
+				}
+				parts[j] = p
+			}
+			n.add("parts", parts)
+		}
+		nodes[i] = n
+	}
+	return nodes
+}
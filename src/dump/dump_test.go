@@ -0,0 +1,160 @@
+package dump
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/uncode/ast"
+	"github.com/uncode/config"
+	"github.com/uncode/lexer"
+	"github.com/uncode/logger"
+	"github.com/uncode/parser"
+	"github.com/uncode/token"
+)
+
+func tokenize(t *testing.T, source string) []token.Token {
+	t.Helper()
+	logger.SetLevel(logger.LevelOff)
+	tokens, err := lexer.NewLexer(source).Tokenize()
+	if err != nil {
+		t.Fatalf("tokenize error: %s", err)
+	}
+	return tokens
+}
+
+func parse(t *testing.T, source string) *ast.Program {
+	t.Helper()
+	program, err := parser.NewParser(tokenize(t, source)).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %s", err)
+	}
+	return program
+}
+
+// TestSexpr はS式の形式で属性・位置・子ノードを書き出すことをテストする
+func TestSexpr(t *testing.T) {
+	var out strings.Builder
+	if err := WriteAST(&out, parse(t, "[1..3] |> sq;"), config.DumpFormatSexpr); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"(Program",
+		"  :statements (",
+		"    (ExpressionStatement 1:1",
+		`      :expression (InfixExpression 1:9 :operator "|>"`,
+		"        :left (RangeExpression 1:4",
+		"          :start (IntegerLiteral 1:2 :value 1)",
+		"          :end (IntegerLiteral 1:5 :value 3))",
+		`        :right (Identifier 1:11 :value "sq")))))`,
+		"",
+	}, "\n")
+	if out.String() != expected {
+		t.Errorf("wrong sexpr.\nexpected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+// TestJSON は関数の条件・入出力の型・case 文を JSON に含めることをテストする
+func TestJSON(t *testing.T) {
+	source := strings.Join([]string{
+		"def kind(): int -> str {",
+		"  case 🍕 > 0: {",
+		`    "plus" >> 💩;`,
+		"  }",
+		"}",
+		"def sign() if 🍕 > 0 {",
+		`  "plus" >> 💩;`,
+		"}",
+	}, "\n")
+	var out strings.Builder
+	if err := WriteAST(&out, parse(t, source), config.DumpFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	type jsonNode map[string]interface{}
+	var program jsonNode
+	if err := json.Unmarshal([]byte(out.String()), &program); err != nil {
+		t.Fatalf("invalid JSON: %s\n%s", err, out.String())
+	}
+	statements := program["statements"].([]interface{})
+	if len(statements) != 2 {
+		t.Fatalf("wrong number of statements. got=%d", len(statements))
+	}
+	function := func(i int) jsonNode {
+		return jsonNode(statements[i].(map[string]interface{})["expression"].(map[string]interface{}))
+	}
+
+	kind := function(0)
+	if kind["type"] != "FunctionLiteral" || kind["inputType"] != "int" || kind["returnType"] != "str" || kind["condition"] != nil {
+		t.Errorf("wrong function literal. got=%v", kind)
+	}
+	if kind["line"] != 1.0 || kind["column"] != 1.0 {
+		t.Errorf("wrong position. got=%v:%v", kind["line"], kind["column"])
+	}
+	body := kind["body"].(map[string]interface{})["statements"].([]interface{})
+	if c := body[0].(map[string]interface{}); c["type"] != "CaseStatement" || c["line"] != 2.0 {
+		t.Errorf("case statement should be in the body. got=%v", c)
+	}
+
+	sign := function(1)
+	condition, ok := sign["condition"].(map[string]interface{})
+	if !ok || condition["type"] != "InfixExpression" || condition["operator"] != ">" {
+		t.Errorf("wrong condition. got=%v", sign["condition"])
+	}
+	if left := condition["left"].(map[string]interface{}); left["type"] != "PizzaLiteral" {
+		t.Errorf("wrong condition left. got=%v", left)
+	}
+	if cases, ok := sign["cases"].([]interface{}); !ok || len(cases) != 0 {
+		t.Errorf("cases should be an empty array. got=%v", sign["cases"])
+	}
+}
+
+// TestTokens はトークン列と補間文字列の構成要素を書き出すことをテストする
+func TestTokens(t *testing.T) {
+	var out strings.Builder
+	if err := WriteTokens(&out, tokenize(t, `"a${x}" |> print;`), config.DumpFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var tokens []map[string]interface{}
+	if err := json.Unmarshal([]byte(out.String()), &tokens); err != nil {
+		t.Fatalf("invalid JSON: %s\n%s", err, out.String())
+	}
+	if len(tokens) != 5 || tokens[1]["type"] != "|>" || tokens[1]["column"] != 10.0 || tokens[4]["type"] != "EOF" {
+		t.Fatalf("wrong tokens. got=%v", tokens)
+	}
+	parts := tokens[0]["parts"].([]interface{})
+	expr := parts[1].(map[string]interface{})
+	if expr["isExpr"] != true || expr["value"] != "x" {
+		t.Errorf("wrong interpolation part. got=%v", expr)
+	}
+	if inner := expr["tokens"].([]interface{})[0].(map[string]interface{}); inner["type"] != "IDENT" || inner["literal"] != "x" {
+		t.Errorf("tokens of the expression should be included. got=%v", inner)
+	}
+}
+
+// TestDot は Graphviz の節と、属性名を付けた辺を書き出すことをテストする
+func TestDot(t *testing.T) {
+	var out strings.Builder
+	if err := WriteAST(&out, parse(t, "1 + 2;"), config.DumpFormatDot); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"digraph uncode {",
+		`  n2 [label="InfixExpression\n1:3\noperator: \"+\""];`,
+		`  n2 -> n3 [label="left"];`,
+		`  n1 -> n2 [label="expression"];`,
+		`  n0 -> n1 [label="statements[0]"];`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("dot should contain %q.\ngot:\n%s", line, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := WriteTokens(&out, tokenize(t, "1 + 2;"), config.DumpFormatDot); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "  rankdir=LR;\n") || !strings.Contains(out.String(), "  n0 -> n1 [style=bold];\n") {
+		t.Errorf("tokens should be connected in order.\ngot:\n%s", out.String())
+	}
+}
@@ -0,0 +1,251 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/uncode/ast"
+	"github.com/uncode/config"
+	"github.com/uncode/token"
+)
+
+// WriteAST は AST を指定した形式 (json / dot / sexpr) で書き出す
+func WriteAST(w io.Writer, program *ast.Program, format string) error {
+	return write(w, []*node{fromAST(program)}, false, format)
+}
+
+// WriteTokens はトークン列を指定した形式 (json / dot / sexpr) で書き出す
+// json は配列、sexpr は1行に1つのトークン、dot は出現順につないだグラフになる
+func WriteTokens(w io.Writer, tokens []token.Token, format string) error {
+	return write(w, fromTokens(tokens), true, format)
+}
+
+// write は節の並びを書き出す。sequence が true なら節を順に並んだ列として扱う
+func write(w io.Writer, nodes []*node, sequence bool, format string) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case config.DumpFormatJSON:
+		if sequence {
+			writeJSONList(bw, nodes, "")
+		} else {
+			writeJSON(bw, nodes[0], "")
+		}
+		bw.WriteString("\n")
+	case config.DumpFormatDot:
+		writeDot(bw, nodes, sequence)
+	case config.DumpFormatSexpr:
+		for _, n := range nodes {
+			writeSexpr(bw, n, "")
+			bw.WriteString("\n")
+		}
+	default:
+		return fmt.Errorf("不明な出力形式です: %s", format)
+	}
+	return bw.Flush()
+}
+
+// position は節の位置を "行:列" で返す。位置を持たない場合は空文字列
+func (n *node) position() string {
+	if n.line == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", n.line, n.column)
+}
+
+// jsonString は文字列を JSON の文字列にする（段の演算子が読めるように、< や > を \u003c のようにエスケープしない）
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// scalar は子ノード以外の値を文字列にする。quote は文字列を引用符で囲む方法
+func scalar(value interface{}, quote func(string) string) string {
+	switch v := value.(type) {
+	case string:
+		return quote(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return quote(fmt.Sprint(value))
+}
+
+// writeJSON は節を JSON のオブジェクトとして書き出す
+// キーは type、位置 (line / column)、各属性の順に並べる
+func writeJSON(w *bufio.Writer, n *node, indent string) {
+	if n == nil {
+		w.WriteString("null")
+		return
+	}
+	inner := indent + "  "
+	w.WriteString("{\n" + inner + `"type": ` + jsonString(n.kind))
+	if n.line > 0 {
+		fmt.Fprintf(w, ",\n%s\"line\": %d,\n%s\"column\": %d", inner, n.line, inner, n.column)
+	}
+	for _, f := range n.fields {
+		w.WriteString(",\n" + inner + jsonString(f.name) + ": ")
+		switch v := f.value.(type) {
+		case *node:
+			writeJSON(w, v, inner)
+		case []*node:
+			writeJSONList(w, v, inner)
+		default:
+			w.WriteString(scalar(v, jsonString))
+		}
+	}
+	w.WriteString("\n" + indent + "}")
+}
+
+// writeJSONList は節の並びを JSON の配列として書き出す
+func writeJSONList(w *bufio.Writer, nodes []*node, indent string) {
+	if len(nodes) == 0 {
+		w.WriteString("[]")
+		return
+	}
+	inner := indent + "  "
+	w.WriteString("[")
+	for i, n := range nodes {
+		if i > 0 {
+			w.WriteString(",")
+		}
+		w.WriteString("\n" + inner)
+		writeJSON(w, n, inner)
+	}
+	w.WriteString("\n" + indent + "]")
+}
+
+// writeSexpr は節をS式として書き出す
+// 型名・位置・1行に収まる属性を1行目に並べ、子ノードはインデントして次の行から並べる
+// (InfixExpression 1:3 :operator "|>"
+//   :left (IntegerLiteral 1:1 :value 3)
+//   :right (Identifier 1:6 :value "sq"))
+func writeSexpr(w *bufio.Writer, n *node, indent string) {
+	if n == nil {
+		w.WriteString("nil")
+		return
+	}
+	w.WriteString("(" + n.kind)
+	if pos := n.position(); pos != "" {
+		w.WriteString(" " + pos)
+	}
+	// 値が1行に収まる属性を先に並べ、子ノードは後に並べる
+	var children []field
+	for _, f := range n.fields {
+		switch v := f.value.(type) {
+		case *node:
+			if v == nil {
+				w.WriteString(" :" + f.name + " nil")
+				continue
+			}
+			children = append(children, f)
+		case []*node:
+			if len(v) == 0 {
+				w.WriteString(" :" + f.name + " ()")
+				continue
+			}
+			children = append(children, f)
+		default:
+			w.WriteString(" :" + f.name + " " + scalar(v, strconv.Quote))
+		}
+	}
+	inner := indent + "  "
+	for _, f := range children {
+		w.WriteString("\n" + inner + ":" + f.name + " ")
+		if v, ok := f.value.(*node); ok {
+			writeSexpr(w, v, inner)
+			continue
+		}
+		w.WriteString("(")
+		for _, c := range f.value.([]*node) {
+			w.WriteString("\n" + inner + "  ")
+			writeSexpr(w, c, inner+"  ")
+		}
+		w.WriteString(")")
+	}
+	w.WriteString(")")
+}
+
+// dotString は Graphviz の文字列にする
+func dotString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
+
+// dotWriter は節に番号を振りながら Graphviz の節と辺を書き出す
+type dotWriter struct {
+	w    *bufio.Writer
+	next int
+}
+
+// node は節とその子孫を書き出し、節の ID を返す
+// ラベルは型名・位置・子ノード以外の属性を1行ずつ並べ、子ノードへの辺には属性名を付ける
+func (d *dotWriter) node(n *node) string {
+	id := fmt.Sprintf("n%d", d.next)
+	d.next++
+	label := []string{n.kind}
+	if pos := n.position(); pos != "" {
+		label = append(label, pos)
+	}
+	for _, f := range n.fields {
+		switch f.value.(type) {
+		case *node, []*node:
+		default:
+			label = append(label, f.name+": "+scalar(f.value, strconv.Quote))
+		}
+	}
+	fmt.Fprintf(d.w, "  %s [label=%s];\n", id, dotString(strings.Join(label, "\n")))
+
+	for _, f := range n.fields {
+		switch v := f.value.(type) {
+		case *node:
+			if v != nil {
+				d.edge(id, v, f.name)
+			}
+		case []*node:
+			for i, c := range v {
+				if c != nil {
+					d.edge(id, c, fmt.Sprintf("%s[%d]", f.name, i))
+				}
+			}
+		}
+	}
+	return id
+}
+
+// edge は子ノードを書き出し、親からの辺を書き出す
+func (d *dotWriter) edge(parent string, child *node, label string) {
+	id := d.node(child)
+	fmt.Fprintf(d.w, "  %s -> %s [label=%s];\n", parent, id, dotString(label))
+}
+
+// writeDot は節を Graphviz の有向グラフとして書き出す
+// sequence が true なら、節を出現順に左から右へつなぐ
+func writeDot(w *bufio.Writer, nodes []*node, sequence bool) {
+	w.WriteString("digraph uncode {\n")
+	if sequence {
+		w.WriteString("  rankdir=LR;\n")
+	}
+	w.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+	d := &dotWriter{w: w}
+	prev := ""
+	for _, n := range nodes {
+		id := d.node(n)
+		if sequence && prev != "" {
+			fmt.Fprintf(w, "  %s -> %s [style=bold];\n", prev, id)
+		}
+		prev = id
+	}
+	w.WriteString("}\n")
+}
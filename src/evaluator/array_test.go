@@ -42,7 +42,7 @@ func TestArrayIndexExpressions(t *testing.T) {
 			3,
 		},
 		{
-			"let i = 0; [1][i];",
+			"0 >> i; [1][i];",
 			1,
 		},
 		{
@@ -50,15 +50,15 @@ func TestArrayIndexExpressions(t *testing.T) {
 			3,
 		},
 		{
-			"let myArray = [1, 2, 3]; myArray[2];",
+			"[1, 2, 3] >> myArray; myArray[2];",
 			3,
 		},
 		{
-			"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];",
+			"[1, 2, 3] >> myArray; myArray[0] + myArray[1] + myArray[2];",
 			6,
 		},
 		{
-			"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]",
+			"[1, 2, 3] >> myArray; myArray[0] >> i; myArray[i];",
 			2,
 		},
 	}
@@ -19,6 +19,7 @@ func evalAssignment(node *ast.InfixExpression, env *object.Environment) object.O
 			return left
 		}
 
+		left = storedValue(left)
 		env.Set(ident.Value, left)
 		return left
 	}
@@ -30,7 +31,9 @@ func evalAssignment(node *ast.InfixExpression, env *object.Environment) object.O
 		if left.Type() == object.ERROR_OBJ {
 			return left
 		}
-		logger.Debug("💩に戻り値として %s を設定します\n", left.Inspect())
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("💩に戻り値として %s を設定します\n", left.Inspect())
+		}
 		return &object.ReturnValue{Value: left}
 	}
 
@@ -24,8 +24,7 @@ func GetBuiltinLogLevel() logger.LogLevel {
 
 // logIfEnabled はビルトイン関数のデバッグログを出力
 func logIfEnabled(level logger.LogLevel, format string, args ...interface{}) {
-	componentLevel := logger.GetComponentLevel(logger.ComponentBuiltin)
-	if level <= componentLevel {
+	if logger.ComponentEnabled(logger.ComponentBuiltin, level) {
 		// ComponentXXXの関数を使って出力
 		switch level {
 		case logger.LevelError:
@@ -55,6 +54,14 @@ func init() {
 	registerArrayBuiltins()
 	registerTypeBuiltins()
 	registerIOBuiltins()
+	registerFileBuiltins()
+	registerSystemBuiltins()
+	registerHashBuiltins()
+	registerJSONBuiltins()
+	registerStreamBuiltins()
+	
+	// すべての登録が終わってから、ストリームの引数を配列に変換する処理を組み込む
+	materializeStreamArguments()
 	
 	// 登録された組み込み関数を一覧表示（デバッグ用）
 	functions := make([]string, 0, len(Builtins))
@@ -84,6 +91,8 @@ func GetBuiltinParamTypes(name string) []object.ObjectType {
 // createError はエラーオブジェクトを作成するヘルパー関数
 func createError(format string, args ...interface{}) *object.Error {
 	errMsg := fmt.Sprintf(format, args...)
-	logIfEnabled(logger.LevelError, "エラー: %s", errMsg)
+	if errorLogsEnabled() {
+		logIfEnabled(logger.LevelError, "エラー: %s", errMsg)
+	}
 	return &object.Error{Message: errMsg}
 }
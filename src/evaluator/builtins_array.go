@@ -35,8 +35,10 @@ func registerArrayBuiltins() {
 			if len(args) > 2 {
 				funcFixedArgs = args[2:]
 				logger.Debug("map関数に追加の引数: %d個", len(funcFixedArgs))
-				for i, arg := range funcFixedArgs {
-					logger.Debug("  追加引数 %d: %s", i, arg.Inspect())
+				if logger.Enabled(logger.LevelDebug) {
+					for i, arg := range funcFixedArgs {
+						logger.Debug("  追加引数 %d: %s", i, arg.Inspect())
+					}
 				}
 			}
 			
@@ -85,7 +87,7 @@ func registerArrayBuiltins() {
 						return createError("関数本体がBlockStatementではありません: %T", fn.ASTBody)
 					}
 					
-					result := evalBlockStatement(astBody, extendedEnv)
+					result := evalFunctionBody(fn, astBody, extendedEnv)
 					
 					// エラー処理
 					if errObj, ok := result.(*object.Error); ok {
@@ -106,7 +108,7 @@ func registerArrayBuiltins() {
 					allArgs := []object.Object{elemArgs[0]}
 					allArgs = append(allArgs, funcFixedArgs...)
 					
-					return fn.Fn(allArgs...)
+					return callBuiltin(fn, allArgs...)
 				}
 			default:
 				// 文字列として関数名を取得し、環境から関数を検索
@@ -144,7 +146,7 @@ func registerArrayBuiltins() {
 								return createError("関数本体がBlockStatementではありません: %T", fn.ASTBody)
 							}
 							
-							result := evalBlockStatement(astBody, extendedEnv)
+							result := evalFunctionBody(fn, astBody, extendedEnv)
 							
 							// エラー処理
 							if errObj, ok := result.(*object.Error); ok {
@@ -165,7 +167,7 @@ func registerArrayBuiltins() {
 							allArgs := []object.Object{elemArgs[0]}
 							allArgs = append(allArgs, funcFixedArgs...)
 							
-							return fn.Fn(allArgs...)
+							return callBuiltin(fn, allArgs...)
 						}
 					default:
 						return createError("関数 '%s' は有効な関数ではありません: %T", funcName, funcObj)
@@ -186,8 +188,10 @@ func registerArrayBuiltins() {
 				result := mapFn(elemArgs)
 				
 				// デバッグ情報を出力
-				logger.Debug("map: 要素 %s に関数を適用した結果: %s", 
-					elem.Inspect(), result.Inspect())
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("map: 要素 %s に関数を適用した結果: %s", 
+						elem.Inspect(), result.Inspect())
+				}
 				
 				resultElements = append(resultElements, result)
 			}
@@ -231,7 +235,7 @@ func registerArrayBuiltins() {
 						return createError("関数本体がBlockStatementではありません: %T", fn.ASTBody)
 					}
 					
-					result := evalBlockStatement(astBody, extendedEnv)
+					result := evalFunctionBody(fn, astBody, extendedEnv)
 					
 					if errObj, ok := result.(*object.Error); ok {
 						return errObj
@@ -246,7 +250,7 @@ func registerArrayBuiltins() {
 			case *object.Builtin:
 				// Builtin function
 				filterFn = func(elem object.Object) object.Object {
-					result := fn.Fn(elem)
+					result := callBuiltin(fn, elem)
 					return result
 				}
 			default:
@@ -267,7 +271,7 @@ func registerArrayBuiltins() {
 								return createError("関数本体がBlockStatementではありません: %T", fn.ASTBody)
 							}
 							
-							result := evalBlockStatement(astBody, extendedEnv)
+							result := evalFunctionBody(fn, astBody, extendedEnv)
 							
 							if errObj, ok := result.(*object.Error); ok {
 								return errObj
@@ -282,7 +286,7 @@ func registerArrayBuiltins() {
 					case *object.Builtin:
 						// Builtin function
 						filterFn = func(elem object.Object) object.Object {
-							result := fn.Fn(elem)
+							result := callBuiltin(fn, elem)
 							return result
 						}
 					default:
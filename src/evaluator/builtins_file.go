@@ -0,0 +1,251 @@
+package evaluator
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/uncode/config"
+	"github.com/uncode/object"
+)
+
+// ファイルアクセスの種類
+const (
+	fileAccessRead  = "read"
+	fileAccessWrite = "write"
+)
+
+// checkFilePermission はパスへのアクセスが --allow-read / --allow-write で許可されているかを確認する
+// 許可されていない場合は許可に必要なフラグを含む権限エラーを返す
+func checkFilePermission(name string, access string, path string) *object.Error {
+	permission := &config.GlobalConfig.AllowRead
+	flagName := "--allow-read"
+	verb := "読み込み"
+	if access == fileAccessWrite {
+		permission = &config.GlobalConfig.AllowWrite
+		flagName = "--allow-write"
+		verb = "書き込み"
+	}
+
+	if permission.Allows(path) {
+		return nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return createError("権限エラー: %s関数: '%s' への%sは許可されていません (%s=%s で許可できます)", name, path, verb, flagName, abs)
+}
+
+// pathArg は組み込み関数の引数からパス文字列を取り出し、権限を確認する
+func pathArg(name string, access string, arg object.Object) (string, *object.Error) {
+	str, ok := arg.(*object.String)
+	if !ok {
+		return "", createError("%s関数のパスは文字列である必要があります: %s", name, arg.Type())
+	}
+	if str.Value == "" {
+		return "", createError("%s関数のパスが空です", name)
+	}
+	if err := checkFilePermission(name, access, str.Value); err != nil {
+		return "", err
+	}
+	return str.Value, nil
+}
+
+// newWriteFileBuiltin は write_file / append_file の組み込み関数を生成する
+// パイプラインで内容を渡せるように、🍕 が書き込む内容、第2引数がパスになる
+func newWriteFileBuiltin(name string, flags int) *object.Builtin {
+	return &object.Builtin{
+		Name: name,
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return createError("%s関数は2つの引数が必要です: %d個与えられました", name, len(args))
+			}
+
+			path, errObj := pathArg(name, fileAccessWrite, args[1])
+			if errObj != nil {
+				return errObj
+			}
+
+			f, err := os.OpenFile(path, flags, 0644)
+			if err != nil {
+				return createError("%s関数: ファイル '%s' を開けませんでした: %s", name, path, err)
+			}
+			defer f.Close()
+
+			if _, err := f.WriteString(stringValueOf(args[0])); err != nil {
+				return createError("%s関数: ファイル '%s' に書き込めませんでした: %s", name, path, err)
+			}
+
+			// パイプラインの連鎖を維持するため書き込んだ内容を返す
+			return args[0]
+		},
+		ReturnType: object.ANY_OBJ,
+		ParamTypes: []object.ObjectType{object.ANY_OBJ, object.STRING_OBJ},
+	}
+}
+
+// registerFileBuiltins はファイルシステム関連の組み込み関数を登録する
+// すべての関数は --allow-read / --allow-write で許可されたパスにのみアクセスできる
+func registerFileBuiltins() {
+	// ファイル全体を文字列として読み込む関数
+	Builtins["read_file"] = &object.Builtin{
+		Name: "read_file",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("read_file関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+
+			path, errObj := pathArg("read_file", fileAccessRead, args[0])
+			if errObj != nil {
+				return errObj
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return createError("read_file関数: ファイル '%s' を読み込めませんでした: %s", path, err)
+			}
+
+			return &object.String{Value: string(data)}
+		},
+		ReturnType: object.STRING_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ},
+	}
+
+	// ファイルを読み込み、行ごとの配列として返す関数
+	Builtins["read_lines"] = &object.Builtin{
+		Name: "read_lines",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("read_lines関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+
+			content := Builtins["read_file"].Fn(args[0])
+			if content.Type() == object.ERROR_OBJ {
+				return content
+			}
+
+			// 行の分割規則は lines 関数と同じ
+			return Builtins["lines"].Fn(content)
+		},
+		ReturnType: object.ARRAY_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ},
+	}
+
+	// ファイルを1行ずつ返すストリームを作成する関数
+	// ファイル全体を読み込まず、要素が要求されるたびに1行ずつ読み込む
+	Builtins["file_lines"] = &object.Builtin{
+		Name: "file_lines",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("file_lines関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+
+			path, errObj := pathArg("file_lines", fileAccessRead, args[0])
+			if errObj != nil {
+				return errObj
+			}
+
+			// 走査のたびにファイルを開き直すため、同じストリームを何度でも読める
+			return newLineStream("file_lines", func() (*bufio.Reader, func(), error) {
+				f, err := os.Open(path)
+				if err != nil {
+					return nil, nil, err
+				}
+				return bufio.NewReader(f), func() { f.Close() }, nil
+			})
+		},
+		ReturnType: object.STREAM_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ},
+	}
+
+	// ファイルを上書きで書き込む関数
+	Builtins["write_file"] = newWriteFileBuiltin("write_file", os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+
+	// ファイルの末尾に追記する関数
+	Builtins["append_file"] = newWriteFileBuiltin("append_file", os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+
+	// ディレクトリ内のエントリ名を名前順の配列として返す関数
+	Builtins["list_dir"] = &object.Builtin{
+		Name: "list_dir",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("list_dir関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+
+			path, errObj := pathArg("list_dir", fileAccessRead, args[0])
+			if errObj != nil {
+				return errObj
+			}
+
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return createError("list_dir関数: ディレクトリ '%s' を読み込めませんでした: %s", path, err)
+			}
+
+			names := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			sort.Strings(names)
+
+			elements := make([]object.Object, len(names))
+			for i, n := range names {
+				elements[i] = &object.String{Value: n}
+			}
+			return &object.Array{Elements: elements}
+		},
+		ReturnType: object.ARRAY_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ},
+	}
+
+	// パスが存在するかを返す関数（読み込み権限が必要）
+	Builtins["exists"] = &object.Builtin{
+		Name: "exists",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("exists関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+
+			path, errObj := pathArg("exists", fileAccessRead, args[0])
+			if errObj != nil {
+				return errObj
+			}
+
+			_, err := os.Stat(path)
+			if err == nil {
+				return TRUE
+			}
+			if os.IsNotExist(err) {
+				return FALSE
+			}
+			return createError("exists関数: '%s' の状態を取得できませんでした: %s", path, err)
+		},
+		ReturnType: object.BOOLEAN_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ},
+	}
+
+	// ファイルまたは空のディレクトリを削除する関数
+	Builtins["remove"] = &object.Builtin{
+		Name: "remove",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("remove関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+
+			path, errObj := pathArg("remove", fileAccessWrite, args[0])
+			if errObj != nil {
+				return errObj
+			}
+
+			if err := os.Remove(path); err != nil {
+				return createError("remove関数: '%s' を削除できませんでした: %s", path, err)
+			}
+			return NullObj
+		},
+		ReturnType: object.NULL_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ},
+	}
+}
@@ -0,0 +1,163 @@
+package evaluator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uncode/config"
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+)
+
+// withFilePermissions はテスト中だけファイルアクセスの権限を設定する
+func withFilePermissions(t *testing.T, read, write config.PathPermission) {
+	t.Helper()
+	prevRead, prevWrite := config.GlobalConfig.AllowRead, config.GlobalConfig.AllowWrite
+	config.GlobalConfig.AllowRead, config.GlobalConfig.AllowWrite = read, write
+	t.Cleanup(func() {
+		config.GlobalConfig.AllowRead, config.GlobalConfig.AllowWrite = prevRead, prevWrite
+	})
+}
+
+// TestFileBuiltins はファイルシステム関連の組み込み関数をテストする
+func TestFileBuiltins(t *testing.T) {
+	// テスト時はデバッグログを無効化
+	logger.SetLevel(logger.LevelError)
+
+	dir := t.TempDir()
+	allowed := config.PathPermission{Paths: []string{dir}}
+	withFilePermissions(t, allowed, allowed)
+
+	path := filepath.Join(dir, "memo.txt")
+
+	testStringObject(t, testEval(fmt.Sprintf(`"一行目\n" |> write_file %q;`, path)), "一行目\n")
+	testStringObject(t, testEval(fmt.Sprintf(`"二行目\n" |> append_file %q;`, path)), "二行目\n")
+	testStringObject(t, testEval(fmt.Sprintf(`%q |> read_file;`, path)), "一行目\n二行目\n")
+	testStringArray(t, testEval(fmt.Sprintf(`%q |> read_lines;`, path)), []string{"一行目", "二行目"})
+	testBooleanObject(t, testEval(fmt.Sprintf(`%q |> exists;`, path)), true)
+
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	testStringArray(t, testEval(fmt.Sprintf(`%q |> list_dir;`, dir)), []string{"a.txt", "memo.txt"})
+
+	testNullObject(t, testEval(fmt.Sprintf(`%q |> remove;`, path)))
+	testBooleanObject(t, testEval(fmt.Sprintf(`%q |> exists;`, path)), false)
+
+	// 存在しないファイルの読み込みはエラーになる
+	evaluated := testEval(fmt.Sprintf(`%q |> read_file;`, path))
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+	if !strings.HasPrefix(errObj.Message, "read_file関数: ファイル") {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+// TestFilePermissionErrors は許可されていないパスへのアクセスが権限エラーになることをテストする
+func TestFilePermissionErrors(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	dir := t.TempDir()
+	other := t.TempDir()
+	path := filepath.Join(other, "secret.txt")
+	if err := os.WriteFile(path, []byte("秘密"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	escaped := dir + string(filepath.Separator) + ".." + string(filepath.Separator) + filepath.Base(other) + string(filepath.Separator) + "secret.txt"
+
+	// 読み込みのみ dir 配下に許可する
+	withFilePermissions(t, config.PathPermission{Paths: []string{dir}}, config.PathPermission{})
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{
+			fmt.Sprintf(`%q |> read_file;`, path),
+			fmt.Sprintf("権限エラー: read_file関数: '%s' への読み込みは許可されていません (--allow-read=%s で許可できます)", path, path),
+		},
+		{
+			fmt.Sprintf(`%q |> exists;`, path),
+			fmt.Sprintf("権限エラー: exists関数: '%s' への読み込みは許可されていません (--allow-read=%s で許可できます)", path, path),
+		},
+		{
+			fmt.Sprintf(`"x" |> write_file %q;`, filepath.Join(dir, "out.txt")),
+			fmt.Sprintf("権限エラー: write_file関数: '%s' への書き込みは許可されていません (--allow-write=%s で許可できます)", filepath.Join(dir, "out.txt"), filepath.Join(dir, "out.txt")),
+		},
+		{
+			fmt.Sprintf(`%q |> remove;`, path),
+			fmt.Sprintf("権限エラー: remove関数: '%s' への書き込みは許可されていません (--allow-write=%s で許可できます)", path, path),
+		},
+		{
+			// ".." で許可ディレクトリの外に出ることはできない
+			fmt.Sprintf(`%q |> read_file;`, escaped),
+			fmt.Sprintf("権限エラー: read_file関数: '%s' への読み込みは許可されていません (--allow-read=%s で許可できます)", escaped, path),
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("拒否された remove でファイルが削除されました: %s", err)
+	}
+}
+
+// TestFilePermissionSymlinks は許可ディレクトリ内のシンボリックリンクで外のファイルにアクセスできないことをテストする
+func TestFilePermissionSymlinks(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	dir := t.TempDir()
+	other := t.TempDir()
+	secret := filepath.Join(other, "secret.txt")
+	if err := os.WriteFile(secret, []byte("秘密"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// dir/link は許可ディレクトリの外を指すディレクトリへのリンク、dir/dangling はまだ存在しない外のファイルへのリンク
+	link := filepath.Join(dir, "link")
+	dangling := filepath.Join(dir, "dangling")
+	if err := os.Symlink(other, link); err != nil {
+		t.Skipf("シンボリックリンクを作成できません: %s", err)
+	}
+	if err := os.Symlink(filepath.Join(other, "new.txt"), dangling); err != nil {
+		t.Fatal(err)
+	}
+
+	allowed := config.PathPermission{Paths: []string{dir}}
+	withFilePermissions(t, allowed, allowed)
+
+	denied := []string{
+		fmt.Sprintf(`%q |> read_file;`, filepath.Join(link, "secret.txt")),
+		fmt.Sprintf(`"x" |> write_file %q;`, filepath.Join(link, "new.txt")),
+		fmt.Sprintf(`"x" |> write_file %q;`, dangling),
+	}
+	for _, input := range denied {
+		errObj, ok := testEval(input).(*object.Error)
+		if !ok || !strings.HasPrefix(errObj.Message, "権限エラー: ") {
+			t.Errorf("%s: expected permission error, got=%v", input, errObj)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(other, "new.txt")); err == nil {
+		t.Errorf("拒否された write_file で許可ディレクトリの外にファイルが作成されました")
+	}
+
+	// 許可ディレクトリ内の新しいファイルへの書き込みは、親ディレクトリを解決して許可する
+	testStringObject(t, testEval(fmt.Sprintf(`"x" |> write_file %q;`, filepath.Join(dir, "new.txt"))), "x")
+}
@@ -0,0 +1,80 @@
+package evaluator
+
+import (
+	"github.com/uncode/object"
+)
+
+// registerHashBuiltins はハッシュ関連の組み込み関数を登録する
+// いずれもキーの挿入順で結果を返す
+func registerHashBuiltins() {
+	// キーの配列を返す関数
+	Builtins["keys"] = newHashListBuiltin("keys", func(pair object.HashPair) object.Object {
+		return pair.Key
+	})
+
+	// 値の配列を返す関数
+	Builtins["values"] = newHashListBuiltin("values", func(pair object.HashPair) object.Object {
+		return pair.Value
+	})
+
+	// [キー, 値] の配列を返す関数
+	Builtins["entries"] = newHashListBuiltin("entries", func(pair object.HashPair) object.Object {
+		return &object.Array{Elements: []object.Object{pair.Key, pair.Value}}
+	})
+}
+
+// newHashListBuiltin はハッシュの各ペアを変換した配列を返す組み込み関数を生成する
+func newHashListBuiltin(name string, convert func(object.HashPair) object.Object) *object.Builtin {
+	return &object.Builtin{
+		Name: name,
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("%s関数は1つの引数が必要です: %d個与えられました", name, len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return createError("%s関数の引数はハッシュである必要があります: %s", name, args[0].Type())
+			}
+
+			pairs := hash.Pairs()
+			elements := make([]object.Object, len(pairs))
+			for i, pair := range pairs {
+				elements[i] = convert(pair)
+			}
+			return &object.Array{Elements: elements}
+		},
+		ReturnType: object.ARRAY_OBJ,
+		ParamTypes: []object.ObjectType{object.HASH_OBJ},
+	}
+}
+
+// hashValues はハッシュの値を挿入順に並べた配列を返す
+func hashValues(hash *object.Hash) []object.Object {
+	pairs := hash.Pairs()
+	values := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		values[i] = pair.Value
+	}
+	return values
+}
+
+// hashWithValues は元のハッシュと同じキーに新しい値を対応させたハッシュを作成する
+// values は hashValues と同じ順序である必要がある
+func hashWithValues(hash *object.Hash, values []object.Object) *object.Hash {
+	result := object.NewHash()
+	for i, pair := range hash.Pairs() {
+		result.Set(pair.Key.(object.Hashable), values[i])
+	}
+	return result
+}
+
+// hashWithPairs は元のハッシュから指定した位置のペアだけを残したハッシュを作成する
+func hashWithPairs(hash *object.Hash, positions []int) *object.Hash {
+	pairs := hash.Pairs()
+	result := object.NewHash()
+	for _, i := range positions {
+		result.Set(pairs[i].Key.(object.Hashable), pairs[i].Value)
+	}
+	return result
+}
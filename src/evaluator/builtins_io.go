@@ -1,11 +1,25 @@
 package evaluator
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 	"github.com/uncode/logger"
 	"github.com/uncode/object"
 )
 
+// stdinReader は標準入力を読み込むための共有リーダー
+// 複数回の呼び出しで先読みしたデータを失わないように1つのリーダーを使い回す
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// SetInput は input や stdin_lines が読み込む入力元を設定する
+// *bufio.Reader を渡した場合はそのまま使うので、REPL のように呼び出し元と入力を共有できる
+func SetInput(r io.Reader) {
+	stdinReader = bufio.NewReader(r)
+}
+
 // registerIOBuiltins はIO関連の組み込み関数を登録する
 func registerIOBuiltins() {
 	// 標準出力に出力する関数
@@ -15,26 +29,28 @@ func registerIOBuiltins() {
 			// デバッグ情報：受け取った引数の詳細を出力
 			logIfEnabled(logger.LevelDebug, "print関数が受け取った引数: %d個", len(args))
 			for i, arg := range args {
-				logIfEnabled(logger.LevelDebug, "引数%d - タイプ: %s, 値: %s", i, arg.Type(), arg.Inspect())
+				if logger.ComponentEnabled(logger.ComponentBuiltin, logger.LevelDebug) {
+					logIfEnabled(logger.LevelDebug, "引数%d - タイプ: %s, 値: %s", i, arg.Type(), arg.Inspect())
+				}
 				
 				// arg.Inspect()ではなく実際の値を表示
 				switch arg.Type() {
 				case object.INTEGER_OBJ:
 					intVal := arg.(*object.Integer).Value
 					logIfEnabled(logger.LevelDebug, "整数値として %d を出力", intVal)
-					fmt.Println(intVal)
+					writeOutputLine(fmt.Sprint(intVal))
 				case object.STRING_OBJ:
 					strVal := arg.(*object.String).Value
 					logIfEnabled(logger.LevelDebug, "文字列として \"%s\" を出力", strVal)
-					fmt.Println(strVal)
+					writeOutputLine(strVal)
 				case object.BOOLEAN_OBJ:
 					boolVal := arg.(*object.Boolean).Value
 					logIfEnabled(logger.LevelDebug, "真偽値として %t を出力", boolVal)
-					fmt.Println(boolVal)
+					writeOutputLine(fmt.Sprint(boolVal))
 				default:
 					inspectVal := arg.Inspect()
 					logIfEnabled(logger.LevelDebug, "デフォルト - %s を出力", inspectVal)
-					fmt.Println(inspectVal)
+					writeOutputLine(inspectVal)
 				}
 			}
 			// 第一引数を返すように変更（パイプラインの連鎖を維持するため）
@@ -46,4 +62,103 @@ func registerIOBuiltins() {
 		ReturnType: object.ANY_OBJ,
 		ParamTypes: []object.ObjectType{object.ANY_OBJ},
 	}
+	// 標準エラー出力に表示する関数
+	// print と同じく第一引数を返すため、パイプラインの途中に挟んで使える
+	Builtins["eprint"] = &object.Builtin{
+		Name: "eprint",
+		Fn: func(args ...object.Object) object.Object {
+			for _, arg := range args {
+				writeErrorLine(stringValueOf(arg))
+			}
+			if len(args) > 0 {
+				return args[0]
+			}
+			return NullObj
+		},
+		ReturnType: object.ANY_OBJ,
+		ParamTypes: []object.ObjectType{object.ANY_OBJ},
+	}
+	// 要素を1つずつ出力する関数
+	// ストリームは配列に変換せず、要素が計算されるたびに出力してフラッシュする
+	Builtins["print_each"] = &object.Builtin{
+		Name: "print_each",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("print_each関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+			
+			switch arg := args[0].(type) {
+			case *object.Stream:
+				// 無限ストリームも出力し続けられるように、forEachInStream は使わずに走査する
+				next := arg.Iterate()
+				defer arg.Close()
+				for {
+					elem, ok := next()
+					if !ok {
+						break
+					}
+					if elem.Type() == object.ERROR_OBJ {
+						return elem
+					}
+					writeOutputLine(elem.Inspect())
+				}
+			case *object.Array:
+				for _, elem := range arg.Elements {
+					writeOutputLine(elem.Inspect())
+				}
+			case *object.Hash:
+				for _, value := range hashValues(arg) {
+					writeOutputLine(value.Inspect())
+				}
+			default:
+				writeOutputLine(arg.Inspect())
+			}
+			return NullObj
+		},
+		ReturnType: object.NULL_OBJ,
+		ParamTypes: []object.ObjectType{object.ANY_OBJ},
+	}
+	// 標準入力から1行読み込む関数
+	Builtins["input"] = &object.Builtin{
+		Name: "input",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return createError("input関数は0-1個の引数が必要です: %d個与えられました", len(args))
+			}
+			
+			// 引数がある場合はプロンプトとして改行なしで表示する
+			if len(args) == 1 {
+				writePrompt(programOutput, stringValueOf(args[0]))
+			}
+			
+			line, err := stdinReader.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return createError("標準入力の読み込みに失敗しました: %s", err)
+			}
+			// 入力の終端に達して何も読めなかった場合はnullを返す
+			if err == io.EOF && line == "" {
+				return NullObj
+			}
+			
+			return &object.String{Value: strings.TrimRight(line, "\r\n")}
+		},
+		ReturnType: object.STRING_OBJ,
+		ParamTypes: []object.ObjectType{object.ANY_OBJ},
+	}
+	// 標準入力を1行ずつ返すストリームを作成する関数
+	// 標準入力は一度しか読めないため、同じストリームを再度走査すると続きの行から読み込む
+	Builtins["stdin_lines"] = &object.Builtin{
+		Name: "stdin_lines",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return createError("stdin_lines関数は引数を取りません: %d個与えられました", len(args))
+			}
+			
+			return newLineStream("stdin_lines", func() (*bufio.Reader, func(), error) {
+				return stdinReader, func() {}, nil
+			})
+		},
+		ReturnType: object.STREAM_OBJ,
+		ParamTypes: []object.ObjectType{},
+	}
 }
@@ -0,0 +1,280 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/uncode/object"
+)
+
+// registerJSONBuiltins はJSON関連の組み込み関数を登録する
+func registerJSONBuiltins() {
+	// JSON文字列を値に変換する関数
+	Builtins["json_parse"] = &object.Builtin{
+		Name: "json_parse",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("json_parse関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return createError("json_parse関数の引数は文字列である必要があります: %s", args[0].Type())
+			}
+
+			return parseJSON(str.Value)
+		},
+		ReturnType: object.ANY_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ},
+	}
+
+	// 値をJSON文字列に変換する関数
+	// 第2引数でインデント（スペースの数または文字列）を指定できる
+	Builtins["json_stringify"] = &object.Builtin{
+		Name: "json_stringify",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 2 {
+				return createError("json_stringify関数は1-2個の引数が必要です: %d個与えられました", len(args))
+			}
+
+			indent := ""
+			if len(args) == 2 {
+				switch arg := args[1].(type) {
+				case *object.Integer:
+					if arg.Value < 0 {
+						return createError("json_stringify関数のインデントは0以上である必要があります: %d", arg.Value)
+					}
+					indent = strings.Repeat(" ", int(arg.Value))
+				case *object.String:
+					indent = arg.Value
+				default:
+					return createError("json_stringify関数のインデントは整数または文字列である必要があります: %s", args[1].Type())
+				}
+			}
+
+			var out strings.Builder
+			if err := writeJSON(&out, args[0], indent, 0); err != nil {
+				return err
+			}
+			return &object.String{Value: out.String()}
+		},
+		ReturnType: object.STRING_OBJ,
+		ParamTypes: []object.ObjectType{object.ANY_OBJ, object.ANY_OBJ},
+	}
+}
+
+// parseJSON はJSON文字列をオブジェクトに変換する
+// オブジェクトのキーの順序を保つため、トークン単位で読み込む
+func parseJSON(input string) object.Object {
+	dec := json.NewDecoder(strings.NewReader(input))
+	dec.UseNumber()
+
+	value, err := decodeJSONValue(dec)
+	if err != nil {
+		return jsonSyntaxError(input, dec, err)
+	}
+
+	// 値の後に余分なデータがないことを確認する
+	end := dec.InputOffset()
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			trailing := input[end:]
+			end += int64(len(trailing) - len(strings.TrimLeft(trailing, " \t\r\n")))
+			line, column := jsonPosition(input, end)
+			return createError("json_parse関数: JSONの構文エラー (%d行目 %d列目): JSONの値の後に余分なデータがあります", line, column)
+		}
+		return jsonSyntaxError(input, dec, err)
+	}
+
+	return value
+}
+
+// decodeJSONValue は次のJSONの値を1つ読み込む
+func decodeJSONValue(dec *json.Decoder) (object.Object, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '[' {
+			elements := []object.Object{}
+			for dec.More() {
+				elem, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, elem)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return &object.Array{Elements: elements}, nil
+		}
+
+		// '{' の場合（閉じ括弧は dec.More で消費されるためここには来ない）
+		hash := object.NewHash()
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(&object.String{Value: keyTok.(string)}, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return hash, nil
+	case json.Number:
+		// 整数として表せる場合は整数、それ以外は浮動小数点数にする
+		if !strings.ContainsAny(v.String(), ".eE") {
+			if i, err := v.Int64(); err == nil {
+				return &object.Integer{Value: i}, nil
+			}
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return &object.Float{Value: f}, nil
+	case string:
+		return &object.String{Value: v}, nil
+	case bool:
+		return &object.Boolean{Value: v}, nil
+	case nil:
+		return NullObj, nil
+	}
+
+	return nil, errors.New("不明なJSONトークンです")
+}
+
+// jsonSyntaxError はJSONの構文エラーを行と列の情報を含むエラーオブジェクトに変換する
+func jsonSyntaxError(input string, dec *json.Decoder, err error) *object.Error {
+	offset := dec.InputOffset()
+	message := err.Error()
+
+	var syntaxErr *json.SyntaxError
+	if err == io.ErrUnexpectedEOF || err == io.EOF || (errors.As(err, &syntaxErr) && syntaxErr.Error() == "unexpected end of JSON input") {
+		offset = int64(len(input))
+		message = "JSONが途中で終わっています"
+	} else if syntaxErr != nil {
+		// Offset はエラーが起きた文字を読み終えた位置を指す
+		offset = syntaxErr.Offset - 1
+	}
+
+	line, column := jsonPosition(input, offset)
+	return createError("json_parse関数: JSONの構文エラー (%d行目 %d列目): %s", line, column, message)
+}
+
+// jsonPosition はバイト位置を1始まりの行番号と列番号（文字単位）に変換する
+func jsonPosition(input string, offset int64) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(input)) {
+		offset = int64(len(input))
+	}
+
+	before := input[:offset]
+	line := strings.Count(before, "\n") + 1
+	lineStart := strings.LastIndex(before, "\n") + 1
+	return line, utf8.RuneCountInString(before[lineStart:]) + 1
+}
+
+// writeJSON はオブジェクトをJSONとして書き出す
+func writeJSON(out *strings.Builder, obj object.Object, indent string, depth int) *object.Error {
+	switch obj := obj.(type) {
+	case *object.Null:
+		out.WriteString("null")
+	case *object.Boolean:
+		out.WriteString(strconv.FormatBool(obj.Value))
+	case *object.Integer:
+		out.WriteString(strconv.FormatInt(obj.Value, 10))
+	case *object.Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return createError("json_stringify関数: %s はJSONで表現できません", obj.Inspect())
+		}
+		s := strconv.FormatFloat(obj.Value, 'g', -1, 64)
+		// 読み戻したときに浮動小数点数のままになるように小数点を付ける
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		out.WriteString(s)
+	case *object.String:
+		writeJSONString(out, obj.Value)
+	case *object.Array:
+		if len(obj.Elements) == 0 {
+			out.WriteString("[]")
+			return nil
+		}
+		out.WriteString("[")
+		for i, elem := range obj.Elements {
+			if i > 0 {
+				out.WriteString(",")
+			}
+			writeJSONIndent(out, indent, depth+1)
+			if err := writeJSON(out, elem, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		writeJSONIndent(out, indent, depth)
+		out.WriteString("]")
+	case *object.Hash:
+		pairs := obj.Pairs()
+		if len(pairs) == 0 {
+			out.WriteString("{}")
+			return nil
+		}
+		out.WriteString("{")
+		for i, pair := range pairs {
+			if i > 0 {
+				out.WriteString(",")
+			}
+			writeJSONIndent(out, indent, depth+1)
+			// JSONのキーは文字列のみなので、文字列以外のキーは文字列に変換する
+			writeJSONString(out, stringValueOf(pair.Key))
+			out.WriteString(":")
+			if indent != "" {
+				out.WriteString(" ")
+			}
+			if err := writeJSON(out, pair.Value, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		writeJSONIndent(out, indent, depth)
+		out.WriteString("}")
+	default:
+		return createError("json_stringify関数: %s 型はJSONに変換できません", obj.Type())
+	}
+	return nil
+}
+
+// writeJSONIndent はインデントが指定されている場合に改行とインデントを書き出す
+func writeJSONIndent(out *strings.Builder, indent string, depth int) {
+	if indent == "" {
+		return
+	}
+	out.WriteString("\n")
+	out.WriteString(strings.Repeat(indent, depth))
+}
+
+// writeJSONString は文字列をJSONの文字列リテラルとして書き出す
+func writeJSONString(out *strings.Builder, s string) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// <, >, & はそのまま出力する
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	out.WriteString(strings.TrimSuffix(buf.String(), "\n"))
+}
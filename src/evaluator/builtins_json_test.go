@@ -0,0 +1,117 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+)
+
+// TestJSONParse は json_parse 関数が各JSONの値を対応するオブジェクトに変換することをテストする
+func TestJSONParse(t *testing.T) {
+	// テスト時はデバッグログを無効化
+	logger.SetLevel(logger.LevelError)
+
+	parse := func(input string) object.Object {
+		return Builtins["json_parse"].Fn(&object.String{Value: input})
+	}
+
+	testIntegerObject(t, parse("42"), 42)
+	testIntegerObject(t, parse("-7"), -7)
+	testStringObject(t, parse(`"寿司\n🍣"`), "寿司\n🍣")
+	testBooleanObject(t, parse("true"), true)
+	testNullObject(t, parse(" null "))
+
+	// 小数点や指数を含む数値は浮動小数点数になる
+	for input, expected := range map[string]float64{"2.5": 2.5, "1e3": 1000, "3.0": 3} {
+		f, ok := parse(input).(*object.Float)
+		if !ok || f.Value != expected {
+			t.Errorf("json_parse(%q) should be Float %g. got=%#v", input, expected, parse(input))
+		}
+	}
+
+	arr, ok := parse(`[1, "a", [true], {}]`).(*object.Array)
+	if !ok || len(arr.Elements) != 4 {
+		t.Fatalf("array not parsed correctly. got=%#v", arr)
+	}
+	testIntegerObject(t, arr.Elements[0], 1)
+	testStringObject(t, arr.Elements[1], "a")
+	if _, ok := arr.Elements[3].(*object.Hash); !ok {
+		t.Errorf("empty object should be Hash. got=%T", arr.Elements[3])
+	}
+
+	// オブジェクトのキーは出現順に保たれる
+	hash, ok := parse(`{"z": 1, "a": {"y": null}, "m": [], "z": 2}`).(*object.Hash)
+	if !ok {
+		t.Fatalf("object not parsed as Hash. got=%T", hash)
+	}
+	if hash.Inspect() != "{z: 2, a: {y: null}, m: []}" {
+		t.Errorf("wrong hash. got=%s", hash.Inspect())
+	}
+	value, ok := hash.Get(&object.String{Value: "z"})
+	if !ok {
+		t.Fatalf("key z not found")
+	}
+	testIntegerObject(t, value, 2)
+}
+
+// TestJSONParseErrors は不正なJSONが行と列を含むエラーになることをテストする
+func TestJSONParseErrors(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"{\"a\": 1,\n  \"b\": tru}", "json_parse関数: JSONの構文エラー (2行目 11列目): invalid character '}' in literal true (expecting 'e')"},
+		{`{"a" 1}`, "json_parse関数: JSONの構文エラー (1行目 6列目): invalid character '1' after object key"},
+		{`[1, 2`, "json_parse関数: JSONの構文エラー (1行目 6列目): JSONが途中で終わっています"},
+		{``, "json_parse関数: JSONの構文エラー (1行目 1列目): JSONが途中で終わっています"},
+		{"1\n  2", "json_parse関数: JSONの構文エラー (2行目 3列目): JSONの値の後に余分なデータがあります"},
+		{`["寿司", x]`, "json_parse関数: JSONの構文エラー (1行目 8列目): invalid character 'x' looking for beginning of value"},
+	}
+
+	for _, tt := range tests {
+		evaluated := Builtins["json_parse"].Fn(&object.String{Value: tt.input})
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+// TestJSONStringify は json_stringify 関数をテストする
+func TestJSONStringify(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, "a<b>", true] |> json_stringify;`, `[1,"a<b>",true]`},
+		{`"改行\n" |> json_stringify;`, `"改行\n"`},
+		{`[] |> json_stringify 2;`, `[]`},
+		{`[1, [2]] |> json_stringify 2;`, "[\n  1,\n  [\n    2\n  ]\n]"},
+		// json_parse との往復でキーの順序と整数・浮動小数点数の区別が保たれる
+		{`"{\"b\": 1, \"a\": [2.0, null], \"c\": {}}" |> json_parse |> json_stringify;`, `{"b":1,"a":[2.0,null],"c":{}}`},
+		{`"{\"b\": 1, \"a\": {\"c\": 2}}" |> json_parse |> json_stringify "\t";`, "{\n\t\"b\": 1,\n\t\"a\": {\n\t\t\"c\": 2\n\t}\n}"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
+
+	evaluated := Builtins["json_stringify"].Fn(&object.Builtin{Name: "print"})
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "json_stringify関数: BUILTIN 型はJSONに変換できません" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
@@ -19,8 +19,14 @@ func registerMathBuiltins() {
 			if str, ok := args[0].(*object.String); ok {
 				logIfEnabled(logger.LevelDebug, "add関数: 文字列連結モード")
 				
-				// 第2引数があれば文字列に変換して連結
+				// 第2引数があれば文字列に変換して連結（厳密モードでは文字列以外はエラー）
 				if len(args) > 1 {
+					if _, ok := args[1].(*object.String); !ok {
+						if strictMode {
+							return createTypeError("厳密モード: add関数: 文字列と%sは連結できません (to_string で明示的に変換してください)", args[1].Type())
+						}
+						warnCoercion(statementLine, "add関数の第2引数を文字列に変換しました", args[1])
+					}
 					var rightStr string
 					switch right := args[1].(type) {
 					case *object.String:
@@ -46,7 +52,9 @@ func registerMathBuiltins() {
 			
 			// 第2引数がない場合は値をそのまま返す
 			if len(args) == 1 {
-				logIfEnabled(logger.LevelDebug, "add関数: 単一引数 %d をそのまま返します", left.Value)
+				if logger.ComponentEnabled(logger.ComponentBuiltin, logger.LevelDebug) {
+					logIfEnabled(logger.LevelDebug, "add関数: 単一引数 %d をそのまま返します", left.Value)
+				}
 				return left
 			}
 			
@@ -57,7 +65,9 @@ func registerMathBuiltins() {
 			}
 			
 			result := &object.Integer{Value: left.Value + right.Value}
-			logIfEnabled(logger.LevelDebug, "add関数: %d + %d = %d", left.Value, right.Value, result.Value)
+			if logger.ComponentEnabled(logger.ComponentBuiltin, logger.LevelDebug) {
+				logIfEnabled(logger.LevelDebug, "add関数: %d + %d = %d", left.Value, right.Value, result.Value)
+			}
 			return result
 		},
 		ReturnType: object.ANY_OBJ, // 文字列または整数を返す可能性あり
@@ -0,0 +1,107 @@
+package evaluator
+
+import (
+	"github.com/uncode/object"
+)
+
+// streamAwareBuiltins はストリームをそのまま受け取る組み込み関数
+// それ以外の組み込み関数には、ストリームを配列に変換してから渡す
+var streamAwareBuiltins = map[string]bool{
+	"take":     true,
+	"to_array": true,
+	"length":   true,
+	"print_each": true,
+}
+
+// registerStreamBuiltins はストリーム関連の組み込み関数を登録する
+func registerStreamBuiltins() {
+	// 先頭から指定した数の要素を取り出す関数
+	// ストリームに対しては遅延評価のまま、配列に対しては配列を返す
+	Builtins["take"] = &object.Builtin{
+		Name: "take",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return createError("take関数は2つの引数が必要です: %d個与えられました", len(args))
+			}
+
+			n, ok := args[1].(*object.Integer)
+			if !ok {
+				return createError("take関数の要素数は整数である必要があります: %s", args[1].Type())
+			}
+			if n.Value < 0 {
+				return createError("take関数の要素数は0以上である必要があります: %d", n.Value)
+			}
+
+			switch source := args[0].(type) {
+			case *object.Stream:
+				return takeStream(source, n.Value)
+			case *object.Array:
+				count := n.Value
+				if count > int64(len(source.Elements)) {
+					count = int64(len(source.Elements))
+				}
+				elements := make([]object.Object, count)
+				copy(elements, source.Elements[:count])
+				return &object.Array{Elements: elements}
+			default:
+				return createError("take関数の引数は配列またはストリームである必要があります: %s", args[0].Type())
+			}
+		},
+		ReturnType: object.ANY_OBJ,
+		ParamTypes: []object.ObjectType{object.ANY_OBJ, object.INTEGER_OBJ},
+	}
+
+	// ストリームを配列に変換する関数
+	Builtins["to_array"] = &object.Builtin{
+		Name: "to_array",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("to_array関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Stream:
+				return materialize(arg)
+			case *object.Array:
+				return arg
+			case *object.Hash:
+				return &object.Array{Elements: hashValues(arg)}
+			default:
+				return &object.Array{Elements: []object.Object{arg}}
+			}
+		},
+		ReturnType: object.ARRAY_OBJ,
+		ParamTypes: []object.ObjectType{object.ANY_OBJ},
+	}
+}
+
+// materializeStreamArguments はストリームを受け取らない組み込み関数を、引数のストリームを配列に変換してから呼び出すようにする
+// print や文字列・配列の関数など、既存の組み込み関数はストリームを意識せずに実装できる
+func materializeStreamArguments() {
+	for name, builtin := range Builtins {
+		if streamAwareBuiltins[name] {
+			continue
+		}
+
+		fn := builtin.Fn
+		builtin.Fn = func(args ...object.Object) object.Object {
+			copied := false
+			for i, arg := range args {
+				if arg == nil || arg.Type() != object.STREAM_OBJ {
+					continue
+				}
+				array := materialize(arg)
+				if isError(array) {
+					return array
+				}
+				// 呼び出し元の引数のスライスを書き換えないようにコピーする
+				if !copied {
+					args = append([]object.Object(nil), args...)
+					copied = true
+				}
+				args[i] = array
+			}
+			return fn(args...)
+		}
+	}
+}
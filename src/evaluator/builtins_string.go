@@ -3,9 +3,15 @@ package evaluator
 import (
 	"fmt"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 	"github.com/uncode/object"
 )
 
+// maxStringBytes は repeat や pad_left などの組み込み関数で作成できる文字列の上限（バイト数）
+// 大きすぎる指定でインタプリタ全体のメモリを使い切らないようにする
+const maxStringBytes = 64 << 20
+
 // registerStringBuiltins は文字列関連の組み込み関数を登録する
 func registerStringBuiltins() {
 	// 文字列を作成する関数
@@ -16,16 +22,10 @@ func registerStringBuiltins() {
 				return createError("to_string関数は1つの引数が必要です: %d個与えられました", len(args))
 			}
 			
-			switch arg := args[0].(type) {
-			case *object.String:
-				return arg // 既に文字列
-			case *object.Integer:
-				return &object.String{Value: fmt.Sprintf("%d", arg.Value)}
-			case *object.Boolean:
-				return &object.String{Value: fmt.Sprintf("%t", arg.Value)}
-			default:
-				return &object.String{Value: arg.Inspect()}
+			if str, ok := args[0].(*object.String); ok {
+				return str // 既に文字列
 			}
+			return &object.String{Value: stringValueOf(args[0])}
 		},
 		ReturnType: object.STRING_OBJ,
 		ParamTypes: []object.ObjectType{object.ANY_OBJ},
@@ -41,11 +41,24 @@ func registerStringBuiltins() {
 			
 			switch arg := args[0].(type) {
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				// 日本語や絵文字を考慮して文字（rune）単位で数える
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(arg.Len())}
+			case *object.Stream:
+				// 配列を作らずに要素を数える
+				count := int64(0)
+				if err := forEachInStream(arg, func(object.Object) object.Object {
+					count++
+					return nil
+				}); err != nil {
+					return err
+				}
+				return &object.Integer{Value: count}
 			default:
-				return createError("length関数は文字列または配列に対してのみ使用できます: %s", args[0].Type())
+				return createError("length関数は文字列、配列、ハッシュまたはストリームに対してのみ使用できます: %s", args[0].Type())
 			}
 		},
 		ReturnType: object.INTEGER_OBJ,
@@ -108,14 +121,16 @@ func registerStringBuiltins() {
 			}
 			start, _ := args[1].(*object.Integer)
 			
-			// 文字列の長さを取得
-			strLen := int64(len(str.Value))
+			// 文字（rune）単位で扱う
+			runes := []rune(str.Value)
+			strLen := int64(len(runes))
 			
-			// 開始位置のバリデーション
-			if start.Value < 0 {
-				start.Value = 0
+			// 開始位置のバリデーション（引数オブジェクトは書き換えない）
+			startPos := start.Value
+			if startPos < 0 {
+				startPos = 0
 			}
-			if start.Value >= strLen {
+			if startPos >= strLen {
 				return &object.String{Value: ""}
 			}
 			
@@ -127,18 +142,19 @@ func registerStringBuiltins() {
 				end, _ := args[2].(*object.Integer)
 				
 				// 終了位置のバリデーション
-				if end.Value < start.Value {
+				endPos := end.Value
+				if endPos < startPos {
 					return &object.String{Value: ""}
 				}
-				if end.Value > strLen {
-					end.Value = strLen
+				if endPos > strLen {
+					endPos = strLen
 				}
 				
-				return &object.String{Value: str.Value[start.Value:end.Value]}
+				return &object.String{Value: string(runes[startPos:endPos])}
 			}
 			
 			// 第3引数がない場合は文字列の最後まで
-			return &object.String{Value: str.Value[start.Value:]}
+			return &object.String{Value: string(runes[startPos:])}
 		},
 		ReturnType: object.STRING_OBJ,
 		ParamTypes: []object.ObjectType{object.STRING_OBJ, object.INTEGER_OBJ, object.INTEGER_OBJ},
@@ -181,4 +197,414 @@ func registerStringBuiltins() {
 		ReturnType: object.STRING_OBJ,
 		ParamTypes: []object.ObjectType{object.STRING_OBJ},
 	}
+
+	// 配列の要素を区切り文字で連結する関数
+	Builtins["join"] = &object.Builtin{
+		Name: "join",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 2 {
+				return createError("join関数は1-2個の引数が必要です: %d個与えられました", len(args))
+			}
+			
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return createError("join関数の第1引数は配列である必要があります: %s", args[0].Type())
+			}
+			
+			// 区切り文字は省略可能（省略時は空文字）
+			sep := ""
+			if len(args) == 2 {
+				sepStr, ok := args[1].(*object.String)
+				if !ok {
+					return createError("join関数の第2引数は文字列である必要があります: %s", args[1].Type())
+				}
+				sep = sepStr.Value
+			}
+			
+			parts := make([]string, len(arr.Elements))
+			for i, elem := range arr.Elements {
+				parts[i] = stringValueOf(elem)
+			}
+			
+			return &object.String{Value: strings.Join(parts, sep)}
+		},
+		ReturnType: object.STRING_OBJ,
+		ParamTypes: []object.ObjectType{object.ARRAY_OBJ, object.STRING_OBJ},
+	}
+
+	// 前後の空白（または指定した文字）を取り除く関数
+	Builtins["trim"] = newTrimBuiltin("trim", strings.TrimSpace, strings.Trim)
+	Builtins["trim_left"] = newTrimBuiltin("trim_left", func(s string) string {
+		return strings.TrimLeftFunc(s, unicode.IsSpace)
+	}, strings.TrimLeft)
+	Builtins["trim_right"] = newTrimBuiltin("trim_right", func(s string) string {
+		return strings.TrimRightFunc(s, unicode.IsSpace)
+	}, strings.TrimRight)
+
+	// 部分文字列を置換する関数
+	Builtins["replace"] = &object.Builtin{
+		Name: "replace",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 3 || len(args) > 4 {
+				return createError("replace関数は3-4個の引数が必要です: %d個与えられました", len(args))
+			}
+			
+			strs, err := stringArgs("replace", args[:3])
+			if err != nil {
+				return err
+			}
+			
+			// 第4引数がある場合は置換回数（省略時はすべて置換）
+			n := -1
+			if len(args) == 4 {
+				count, ok := args[3].(*object.Integer)
+				if !ok {
+					return createError("replace関数の第4引数は整数である必要があります: %s", args[3].Type())
+				}
+				n = int(count.Value)
+			}
+			
+			return &object.String{Value: strings.Replace(strs[0], strs[1], strs[2], n)}
+		},
+		ReturnType: object.STRING_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ, object.STRING_OBJ, object.INTEGER_OBJ},
+	}
+
+	// 部分文字列の判定を行う関数
+	Builtins["contains"] = newStringPredicateBuiltin("contains", strings.Contains)
+	Builtins["starts_with"] = newStringPredicateBuiltin("starts_with", strings.HasPrefix)
+	Builtins["ends_with"] = newStringPredicateBuiltin("ends_with", strings.HasSuffix)
+
+	// 部分文字列が最初に現れる位置を返す関数（見つからない場合は-1）
+	Builtins["index_of"] = &object.Builtin{
+		Name: "index_of",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return createError("index_of関数は2つの引数が必要です: %d個与えられました", len(args))
+			}
+			
+			strs, err := stringArgs("index_of", args)
+			if err != nil {
+				return err
+			}
+			
+			byteIndex := strings.Index(strs[0], strs[1])
+			if byteIndex < 0 {
+				return &object.Integer{Value: -1}
+			}
+			// バイト位置ではなく文字（rune）位置を返す
+			return &object.Integer{Value: int64(utf8.RuneCountInString(strs[0][:byteIndex]))}
+		},
+		ReturnType: object.INTEGER_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ},
+	}
+
+	// 文字列を指定回数繰り返す関数
+	Builtins["repeat"] = &object.Builtin{
+		Name: "repeat",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return createError("repeat関数は2つの引数が必要です: %d個与えられました", len(args))
+			}
+			
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return createError("repeat関数の第1引数は文字列である必要があります: %s", args[0].Type())
+			}
+			count, ok := args[1].(*object.Integer)
+			if !ok {
+				return createError("repeat関数の第2引数は整数である必要があります: %s", args[1].Type())
+			}
+			if count.Value < 0 {
+				return createError("repeat関数の繰り返し回数は0以上である必要があります: %d", count.Value)
+			}
+			if count.Value > 0 && int64(len(str.Value)) > maxStringBytes/count.Value {
+				return createError("repeat関数の結果が大きすぎます: %dバイトを超えます", maxStringBytes)
+			}
+			
+			return &object.String{Value: strings.Repeat(str.Value, int(count.Value))}
+		},
+		ReturnType: object.STRING_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ, object.INTEGER_OBJ},
+	}
+
+	// 指定した幅になるまで左側・右側を埋める関数
+	Builtins["pad_left"] = newPadBuiltin("pad_left", true)
+	Builtins["pad_right"] = newPadBuiltin("pad_right", false)
+
+	// 文字列を行ごとに分割する関数
+	Builtins["lines"] = &object.Builtin{
+		Name: "lines",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("lines関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+			
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return createError("lines関数の引数は文字列である必要があります: %s", args[0].Type())
+			}
+			
+			elements := []object.Object{}
+			if str.Value == "" {
+				return &object.Array{Elements: elements}
+			}
+			
+			// 末尾の改行は空行として扱わない
+			parts := strings.Split(strings.TrimSuffix(str.Value, "\n"), "\n")
+			for _, part := range parts {
+				elements = append(elements, &object.String{Value: strings.TrimSuffix(part, "\r")})
+			}
+			
+			return &object.Array{Elements: elements}
+		},
+		ReturnType: object.ARRAY_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ},
+	}
+
+	// 文字列を1文字ずつの配列に分割する関数
+	Builtins["chars"] = &object.Builtin{
+		Name: "chars",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("chars関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+			
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return createError("chars関数の引数は文字列である必要があります: %s", args[0].Type())
+			}
+			
+			elements := []object.Object{}
+			for _, r := range str.Value {
+				elements = append(elements, &object.String{Value: string(r)})
+			}
+			
+			return &object.Array{Elements: elements}
+		},
+		ReturnType: object.ARRAY_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ},
+	}
+
+	// printf形式で文字列を整形する関数
+	Builtins["format"] = &object.Builtin{
+		Name: "format",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 {
+				return createError("format関数は1つ以上の引数が必要です: %d個与えられました", len(args))
+			}
+			
+			formatStr, ok := args[0].(*object.String)
+			if !ok {
+				return createError("format関数の第1引数は文字列である必要があります: %s", args[0].Type())
+			}
+			
+			// 書式の指定と引数を照らし合わせてから fmt.Sprintf に渡す
+			values, err := formatValues(formatStr.Value, args[1:])
+			if err != nil {
+				return err
+			}
+			
+			return &object.String{Value: fmt.Sprintf(formatStr.Value, values...)}
+		},
+		ReturnType: object.STRING_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ, object.ANY_OBJ},
+	}
+}
+
+// stringValueOf はオブジェクトを文字列に変換する（to_string・join・文字列補間で共通の規則）
+// 文字列はそのままの値を、それ以外はInspectの結果を使う
+func stringValueOf(obj object.Object) string {
+	if str, ok := obj.(*object.String); ok {
+		return str.Value
+	}
+	return obj.Inspect()
+}
+
+// stringArgs はすべての引数が文字列であることを確認し、その値を返す
+func stringArgs(name string, args []object.Object) ([]string, *object.Error) {
+	values := make([]string, len(args))
+	for i, arg := range args {
+		str, ok := arg.(*object.String)
+		if !ok {
+			return nil, createError("%s関数の第%d引数は文字列である必要があります: %s", name, i+1, arg.Type())
+		}
+		values[i] = str.Value
+	}
+	return values, nil
+}
+
+// newTrimBuiltin はtrim系の組み込み関数を作成する
+// 第2引数を省略した場合は空白を、指定した場合はその文字集合を取り除く
+func newTrimBuiltin(name string, trimSpace func(string) string, trimCutset func(string, string) string) *object.Builtin {
+	return &object.Builtin{
+		Name: name,
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 2 {
+				return createError("%s関数は1-2個の引数が必要です: %d個与えられました", name, len(args))
+			}
+			
+			strs, err := stringArgs(name, args)
+			if err != nil {
+				return err
+			}
+			
+			if len(strs) == 2 {
+				return &object.String{Value: trimCutset(strs[0], strs[1])}
+			}
+			return &object.String{Value: trimSpace(strs[0])}
+		},
+		ReturnType: object.STRING_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ},
+	}
+}
+
+// newStringPredicateBuiltin は2つの文字列を受け取り真偽値を返す組み込み関数を作成する
+func newStringPredicateBuiltin(name string, predicate func(string, string) bool) *object.Builtin {
+	return &object.Builtin{
+		Name: name,
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return createError("%s関数は2つの引数が必要です: %d個与えられました", name, len(args))
+			}
+			
+			strs, err := stringArgs(name, args)
+			if err != nil {
+				return err
+			}
+			
+			return &object.Boolean{Value: predicate(strs[0], strs[1])}
+		},
+		ReturnType: object.BOOLEAN_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ},
+	}
+}
+
+// newPadBuiltin はpad_left/pad_rightの組み込み関数を作成する
+// 幅は文字（rune）単位で数え、埋め文字を省略した場合は空白を使う
+func newPadBuiltin(name string, left bool) *object.Builtin {
+	return &object.Builtin{
+		Name: name,
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 2 || len(args) > 3 {
+				return createError("%s関数は2-3個の引数が必要です: %d個与えられました", name, len(args))
+			}
+			
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return createError("%s関数の第1引数は文字列である必要があります: %s", name, args[0].Type())
+			}
+			width, ok := args[1].(*object.Integer)
+			if !ok {
+				return createError("%s関数の第2引数は整数である必要があります: %s", name, args[1].Type())
+			}
+			
+			pad := []rune(" ")
+			if len(args) == 3 {
+				padStr, ok := args[2].(*object.String)
+				if !ok {
+					return createError("%s関数の第3引数は文字列である必要があります: %s", name, args[2].Type())
+				}
+				if padStr.Value == "" {
+					return createError("%s関数の埋め文字は空にできません", name)
+				}
+				pad = []rune(padStr.Value)
+			}
+			
+			missing := width.Value - int64(utf8.RuneCountInString(str.Value))
+			if missing <= 0 {
+				return str
+			}
+			
+			// 埋め文字が複数文字の場合は繰り返して必要な文字数だけ使う
+			cycles, rest := missing/int64(len(pad)), int(missing%int64(len(pad)))
+			padStr, restStr := string(pad), string(pad[:rest])
+			if cycles > (maxStringBytes-int64(len(str.Value)+len(restStr)))/int64(len(padStr)) {
+				return createError("%s関数の結果が大きすぎます: %dバイトを超えます", name, maxStringBytes)
+			}
+			fill := strings.Repeat(padStr, int(cycles)) + restStr
+			
+			if left {
+				return &object.String{Value: fill + str.Value}
+			}
+			return &object.String{Value: str.Value + fill}
+		},
+		ReturnType: object.STRING_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ, object.INTEGER_OBJ, object.STRING_OBJ},
+	}
+}
+
+// formatValues は format 関数の書式の指定（%d など）と引数を照らし合わせ、fmt.Sprintf に渡す値を返す
+// 指定と引数の数が合わない場合や、指定に合わない型の引数がある場合はエラーを返す
+func formatValues(format string, args []object.Object) ([]interface{}, *object.Error) {
+	values := []interface{}{}
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		// フラグ・幅・精度を読み飛ばして変換の指定を探す
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0123456789.", format[i]) >= 0 {
+			i++
+		}
+		if i >= len(format) {
+			return nil, createError("format関数の書式が %% で終わっています: %q", format[start:])
+		}
+		verb := format[i]
+		if verb == '%' {
+			if i != start+1 {
+				return nil, createError("format関数の書式 %s は使えません", format[start:i+1])
+			}
+			continue
+		}
+		if len(values) >= len(args) {
+			return nil, createError("format関数の書式 %s に対応する引数がありません", format[start:i+1])
+		}
+		value, ok := formatValue(verb, args[len(values)])
+		if !ok {
+			if strings.IndexByte("vtsqdbocxXUeEfFgG", verb) < 0 {
+				return nil, createError("format関数の書式 %s は使えません", format[start:i+1])
+			}
+			return nil, createError("format関数の書式 %s に %s の値は使えません", format[start:i+1], args[len(values)].Type())
+		}
+		values = append(values, value)
+	}
+	if len(values) != len(args) {
+		return nil, createError("format関数の引数が多すぎます: 書式の指定は%d個、引数は%d個です", len(values), len(args))
+	}
+	return values, nil
+}
+
+// formatValue は書式の変換の指定に合わせて、引数を fmt.Sprintf に渡す値に変換する
+// %v と %s・%q はどの値にも使え、文字列以外は to_string と同じ文字列にする
+func formatValue(verb byte, arg object.Object) (interface{}, bool) {
+	switch verb {
+	case 'v', 's', 'q':
+		return stringValueOf(arg), true
+	case 't':
+		if b, ok := arg.(*object.Boolean); ok {
+			return b.Value, true
+		}
+	case 'd', 'b', 'o', 'c', 'U':
+		if n, ok := arg.(*object.Integer); ok {
+			return n.Value, true
+		}
+	case 'x', 'X':
+		switch v := arg.(type) {
+		case *object.Integer:
+			return v.Value, true
+		case *object.String:
+			return v.Value, true
+		}
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		// 整数は浮動小数点数として整形する
+		switch v := arg.(type) {
+		case *object.Float:
+			return v.Value, true
+		case *object.Integer:
+			return float64(v.Value), true
+		}
+	}
+	return nil, false
 }
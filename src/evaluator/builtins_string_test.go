@@ -0,0 +1,112 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+)
+
+// TestStringLibraryFunctions は文字列ライブラリの組み込み関数をテストする
+func TestStringLibraryFunctions(t *testing.T) {
+	// テスト時はデバッグログを無効化
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// 日本語・絵文字は文字（rune）単位で扱う
+		{`"こんにちは世界" |> length;`, int64(7)},
+		{`"こんにちは世界" |> substring 2 5;`, "にちは"},
+		{`["a", "b", 1] |> join ", ";`, "a, b, 1"},
+		{`["寿", "司"] |> join;`, "寿司"},
+		{`"  寿司 🍣  " |> trim;`, "寿司 🍣"},
+		{`"xx寿司xx" |> trim_left "x";`, "寿司xx"},
+		{`"xx寿司xx" |> trim_right "x";`, "xx寿司"},
+		{`"a-b-c" |> replace "-" "+";`, "a+b+c"},
+		{`"a-b-c" |> replace "-" "+" 1;`, "a+b-c"},
+		{`"ラーメン" |> contains "メン";`, true},
+		{`"ラーメン" |> starts_with "ラー";`, true},
+		{`"ラーメン" |> ends_with "ラー";`, false},
+		{`"🍕とピザ" |> index_of "ピザ";`, int64(2)},
+		{`"🍕とピザ" |> index_of "寿司";`, int64(-1)},
+		{`"ab" |> repeat 3;`, "ababab"},
+		{`"猫" |> pad_left 4 "*";`, "***猫"},
+		{`"猫" |> pad_right 3;`, "猫  "},
+		{`"猫" |> pad_left 4 "ab";`, "aba猫"},
+		{`"%s は %d 歳" |> format "太郎" 20;`, "太郎 は 20 歳"},
+		{`format("%5.2f%% %v %s", 3, [1, 2], true);`, " 3.00% [1, 2] true"},
+		{`"%x" |> format 255;`, "ff"},
+		{`"" |> repeat 0;`, ""},
+		{`pad_left("猫", -3, "*");`, "猫"},
+		{`def shout() { 🍕 |> trim |> to_upper >> 💩 }; " hi " |> shout;`, "HI"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int64:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+// TestStringSplittingFunctions は文字列を配列に分割する組み込み関数をテストする
+func TestStringSplittingFunctions(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"\"一\\n二\\r\\n三\\n\" |> lines;", []string{"一", "二", "三"}},
+		{`"" |> lines;`, []string{}},
+		{`"🍕💩あ" |> chars;`, []string{"🍕", "💩", "あ"}},
+	}
+
+	for _, tt := range tests {
+		testStringArray(t, testEval(tt.input), tt.expected)
+	}
+}
+
+// TestStringLibraryErrors は文字列ライブラリのエラーケースをテストする
+func TestStringLibraryErrors(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`"abc" |> repeat "x";`, "repeat関数の第2引数は整数である必要があります: STRING"},
+		{`"abc" |> contains 1;`, "contains関数の第2引数は文字列である必要があります: INTEGER"},
+		{`"abc" |> pad_left 5 "";`, "pad_left関数の埋め文字は空にできません"},
+		{`repeat("abc", -1);`, "repeat関数の繰り返し回数は0以上である必要があります: -1"},
+		{`"abc" |> repeat 9223372036854775807;`, "repeat関数の結果が大きすぎます: 67108864バイトを超えます"},
+		{`"abc" |> pad_left 9223372036854775807 "ab";`, "pad_left関数の結果が大きすぎます: 67108864バイトを超えます"},
+		{`"%d 歳" |> format "二十";`, "format関数の書式 %d に STRING の値は使えません"},
+		{`"%s と %s" |> format "a";`, "format関数の書式 %s に対応する引数がありません"},
+		{`"%s" |> format "a" "b";`, "format関数の引数が多すぎます: 書式の指定は1個、引数は2個です"},
+		{`"%y" |> format 1;`, "format関数の書式 %y は使えません"},
+		{`"100%" |> format;`, "format関数の書式が % で終わっています: \"%\""},
+		{`1 |> join;`, "join関数の第1引数は配列である必要があります: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package evaluator
+
+import (
+	"os"
+
+	"github.com/uncode/config"
+	"github.com/uncode/object"
+)
+
+// registerSystemBuiltins は環境変数やプログラムの終了など、実行環境に関する組み込み関数を登録する
+func registerSystemBuiltins() {
+	// 環境変数を読み込む関数
+	// --allow-env で許可された環境変数だけを読み込める。設定されていない場合は null を返す
+	Builtins["env"] = &object.Builtin{
+		Name: "env",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("env関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+
+			name, ok := args[0].(*object.String)
+			if !ok {
+				return createError("env関数の引数は文字列である必要があります: %s", args[0].Type())
+			}
+			if !config.GlobalConfig.AllowEnv.Allows(name.Value) {
+				return createError("権限エラー: env関数: 環境変数 '%s' の読み込みは許可されていません (--allow-env=%s で許可できます)", name.Value, name.Value)
+			}
+
+			value, found := os.LookupEnv(name.Value)
+			if !found {
+				return NullObj
+			}
+			return &object.String{Value: value}
+		},
+		ReturnType: object.ANY_OBJ,
+		ParamTypes: []object.ObjectType{object.STRING_OBJ},
+	}
+
+	// プログラムを終了する関数
+	// 残りの文は評価せず、指定した終了コードでプロセスを終了する
+	Builtins["exit"] = &object.Builtin{
+		Name: "exit",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return createError("exit関数は0-1個の引数が必要です: %d個与えられました", len(args))
+			}
+
+			code := int64(0)
+			if len(args) == 1 {
+				integer, ok := args[0].(*object.Integer)
+				if !ok {
+					return createError("exit関数の終了コードは整数である必要があります: %s", args[0].Type())
+				}
+				code = integer.Value
+			}
+			if code < 0 || code > 255 {
+				return createError("exit関数の終了コードは0から255の範囲である必要があります: %d", code)
+			}
+			return &object.Error{Message: "exit", Exit: true, ExitCode: int(code)}
+		},
+		ReturnType: object.NULL_OBJ,
+		ParamTypes: []object.ObjectType{object.INTEGER_OBJ},
+	}
+}
@@ -0,0 +1,98 @@
+package evaluator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/uncode/config"
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+)
+
+// TestEnvBuiltin は --allow-env で許可された環境変数だけを読み込めることをテストする
+func TestEnvBuiltin(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+	t.Setenv("UNCODE_TEST_VALUE", "こんにちは")
+
+	prev := config.GlobalConfig.AllowEnv
+	t.Cleanup(func() { config.GlobalConfig.AllowEnv = prev })
+
+	config.GlobalConfig.AllowEnv = config.NamePermission{}
+	evaluated := testEval(`"UNCODE_TEST_VALUE" |> env;`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+	expected := "権限エラー: env関数: 環境変数 'UNCODE_TEST_VALUE' の読み込みは許可されていません (--allow-env=UNCODE_TEST_VALUE で許可できます)"
+	if errObj.Message != expected {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+
+	config.GlobalConfig.AllowEnv = config.NamePermission{Names: []string{"UNCODE_TEST_VALUE", "UNCODE_TEST_MISSING"}}
+	testStringObject(t, testEval(`"UNCODE_TEST_VALUE" |> env;`), "こんにちは")
+	testNullObject(t, testEval(`"UNCODE_TEST_MISSING" |> env;`))
+
+	config.GlobalConfig.AllowEnv = config.NamePermission{All: true}
+	testStringObject(t, testEval(`env "UNCODE_TEST_VALUE";`), "こんにちは")
+}
+
+// TestExitBuiltin は exit 関数がプログラムの評価を打ち切り、トップレベルの💩が終了コードを記録することをテストする
+func TestExitBuiltin(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+	out := captureOutput(t)
+
+	tests := []struct {
+		input        string
+		expectedCode int
+	}{
+		{`"a" |> print; exit 3; "b" |> print;`, 3},
+		{`def stop() { 🍕 |> exit; }; 5 |> stop; "b" |> print;`, 5},
+		{`[1, 2, 3] +> exit;`, 1},
+		{`exit 0;`, 0},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || !errObj.Exit {
+			t.Errorf("input %q: expected exit. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.ExitCode != tt.expectedCode {
+			t.Errorf("input %q: wrong exit code. expected=%d, got=%d", tt.input, tt.expectedCode, errObj.ExitCode)
+		}
+	}
+	if got := out.String(); got != "a\n" {
+		t.Errorf("statements after exit should not be evaluated. output=%q", got)
+	}
+
+	// トップレベルで💩に代入した値は終了コードとして記録し、残りの文も評価する
+	evaluated := testEval(`2 >> 💩; "b" |> print; 4 >> 💩; "c" |> print;`)
+	returnValue, ok := evaluated.(*object.ReturnValue)
+	if !ok {
+		t.Fatalf("expected ReturnValue. got=%T (%+v)", evaluated, evaluated)
+	}
+	testIntegerObject(t, returnValue.Value, 4)
+	if got := out.String(); got != "a\nb\nc\n" {
+		t.Errorf("statements after 💩 should be evaluated. output=%q", got)
+	}
+
+	// 💩に代入した後でも exit やエラーではそこで終了する
+	evaluated = testEval(`2 >> 💩; exit 7; "d" |> print;`)
+	if errObj, ok := evaluated.(*object.Error); !ok || !errObj.Exit || errObj.ExitCode != 7 {
+		t.Errorf("expected exit 7 after 💩. got=%+v", evaluated)
+	}
+
+	evaluated = testEval(`exit 256;`)
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Exit || errObj.Message != "exit関数の終了コードは0から255の範囲である必要があります: 256" {
+		t.Errorf("wrong result for out of range exit code. got=%+v", evaluated)
+	}
+
+	// トップレベルの💩の終了コードも0から255の範囲だけを受け付ける
+	for _, input := range []string{`300 >> 💩;`, `0 - 1 >> 💩;`} {
+		evaluated = testEval(input)
+		if errObj, ok := evaluated.(*object.Error); !ok || errObj.Exit || !strings.HasPrefix(errObj.Message, "トップレベルの💩の終了コードは0から255の範囲である必要があります: ") {
+			t.Errorf("input %q: wrong result for out of range exit code. got=%+v", input, evaluated)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package evaluator
 
 import (
+	"math"
+	"strconv"
+	"strings"
+
 	"github.com/uncode/object"
 )
 
@@ -14,22 +18,7 @@ func registerTypeBuiltins() {
 				return createError("eq関数は2つの引数が必要です: %d個与えられました", len(args))
 			}
 			
-			switch left := args[0].(type) {
-			case *object.Integer:
-				if right, ok := args[1].(*object.Integer); ok {
-					return &object.Boolean{Value: left.Value == right.Value}
-				}
-			case *object.String:
-				if right, ok := args[1].(*object.String); ok {
-					return &object.Boolean{Value: left.Value == right.Value}
-				}
-			case *object.Boolean:
-				if right, ok := args[1].(*object.Boolean); ok {
-					return &object.Boolean{Value: left.Value == right.Value}
-				}
-			}
-			
-			return &object.Boolean{Value: false}
+			return &object.Boolean{Value: objectsEqual(args[0], args[1])}
 		},
 		ReturnType: object.BOOLEAN_OBJ,
 		ParamTypes: []object.ObjectType{object.ANY_OBJ, object.ANY_OBJ},
@@ -76,4 +65,269 @@ func registerTypeBuiltins() {
 		ReturnType: object.STRING_OBJ,
 		ParamTypes: []object.ObjectType{object.ANY_OBJ},
 	}
+
+	// 非等価判定関数
+	Builtins["ne"] = &object.Builtin{
+		Name: "ne",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return createError("ne関数は2つの引数が必要です: %d個与えられました", len(args))
+			}
+			return &object.Boolean{Value: !objectsEqual(args[0], args[1])}
+		},
+		ReturnType: object.BOOLEAN_OBJ,
+		ParamTypes: []object.ObjectType{object.ANY_OBJ, object.ANY_OBJ},
+	}
+
+	// 大小比較関数（🍕 lt 3 のように第1引数を第2引数と比較する）
+	Builtins["lt"] = newComparisonBuiltin("lt", func(c int) bool { return c < 0 })
+	Builtins["le"] = newComparisonBuiltin("le", func(c int) bool { return c <= 0 })
+	Builtins["gt"] = newComparisonBuiltin("gt", func(c int) bool { return c > 0 })
+	Builtins["ge"] = newComparisonBuiltin("ge", func(c int) bool { return c >= 0 })
+
+	// 論理積・論理和
+	Builtins["and"] = newLogicalBuiltin("and", func(a, b bool) bool { return a && b })
+	Builtins["or"] = newLogicalBuiltin("or", func(a, b bool) bool { return a || b })
+
+	// 整数に変換する関数
+	Builtins["to_int"] = &object.Builtin{
+		Name: "to_int",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("to_int関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+			
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+			case *object.Float:
+				// 小数点以下は0方向に切り捨てる
+				if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+					return createError("to_int関数: %s は整数に変換できません", arg.Inspect())
+				}
+				return &object.Integer{Value: int64(arg.Value)}
+			case *object.String:
+				value, err := strconv.ParseInt(strings.TrimSpace(arg.Value), 10, 64)
+				if err != nil {
+					return createError("to_int関数: 文字列 \"%s\" は整数に変換できません", arg.Value)
+				}
+				return &object.Integer{Value: value}
+			case *object.Boolean:
+				if arg.Value {
+					return &object.Integer{Value: 1}
+				}
+				return &object.Integer{Value: 0}
+			default:
+				return createError("to_int関数: %s 型の値は整数に変換できません", args[0].Type())
+			}
+		},
+		ReturnType: object.INTEGER_OBJ,
+		ParamTypes: []object.ObjectType{object.ANY_OBJ},
+	}
+
+	// 浮動小数点数に変換する関数
+	Builtins["to_float"] = &object.Builtin{
+		Name: "to_float",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("to_float関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+			
+			switch arg := args[0].(type) {
+			case *object.Float:
+				return arg
+			case *object.Integer:
+				return &object.Float{Value: float64(arg.Value)}
+			case *object.String:
+				value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+				if err != nil {
+					return createError("to_float関数: 文字列 \"%s\" は浮動小数点数に変換できません", arg.Value)
+				}
+				return &object.Float{Value: value}
+			case *object.Boolean:
+				if arg.Value {
+					return &object.Float{Value: 1}
+				}
+				return &object.Float{Value: 0}
+			default:
+				return createError("to_float関数: %s 型の値は浮動小数点数に変換できません", args[0].Type())
+			}
+		},
+		ReturnType: object.FLOAT_OBJ,
+		ParamTypes: []object.ObjectType{object.ANY_OBJ},
+	}
+
+	// 真偽値に変換する関数
+	Builtins["to_bool"] = &object.Builtin{
+		Name: "to_bool",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return createError("to_bool関数は1つの引数が必要です: %d個与えられました", len(args))
+			}
+			
+			switch arg := args[0].(type) {
+			case *object.Boolean:
+				return arg
+			case *object.Integer:
+				return &object.Boolean{Value: arg.Value != 0}
+			case *object.Float:
+				return &object.Boolean{Value: arg.Value != 0}
+			case *object.String:
+				// "true"/"false"（および "1"/"0" など strconv.ParseBool が受け付ける表記）のみ変換できる
+				value, err := strconv.ParseBool(strings.TrimSpace(arg.Value))
+				if err != nil {
+					return createError("to_bool関数: 文字列 \"%s\" は真偽値に変換できません", arg.Value)
+				}
+				return &object.Boolean{Value: value}
+			case *object.Null:
+				return &object.Boolean{Value: false}
+			default:
+				return createError("to_bool関数: %s 型の値は真偽値に変換できません", args[0].Type())
+			}
+		},
+		ReturnType: object.BOOLEAN_OBJ,
+		ParamTypes: []object.ObjectType{object.ANY_OBJ},
+	}
+}
+
+// objectsEqual は2つの値が等しいかを判定する
+// 整数と浮動小数点数は数値として比較し、それ以外は同じ型同士のみ等しくなりうる
+func objectsEqual(left, right object.Object) bool {
+	// 整数同士は compareObjects と同じく精度を落とさないようにそのまま比較する
+	if l, r, ok := integerPair(left, right); ok {
+		return l == r
+	}
+	if l, r, ok := numericPair(left, right); ok {
+		return l == r
+	}
+	
+	switch l := left.(type) {
+	case *object.String:
+		if r, ok := right.(*object.String); ok {
+			return l.Value == r.Value
+		}
+	case *object.Boolean:
+		if r, ok := right.(*object.Boolean); ok {
+			return l.Value == r.Value
+		}
+	case *object.Null:
+		_, ok := right.(*object.Null)
+		return ok
+	}
+	
+	return false
+}
+
+// integerPair は2つの値がどちらも整数の場合にその値を返す
+func integerPair(left, right object.Object) (int64, int64, bool) {
+	l, ok := left.(*object.Integer)
+	if !ok {
+		return 0, 0, false
+	}
+	r, ok := right.(*object.Integer)
+	if !ok {
+		return 0, 0, false
+	}
+	return l.Value, r.Value, true
+}
+
+// numericPair は2つの値がどちらも数値の場合に float64 として返す
+func numericPair(left, right object.Object) (float64, float64, bool) {
+	l, ok := numericValue(left)
+	if !ok {
+		return 0, 0, false
+	}
+	r, ok := numericValue(right)
+	if !ok {
+		return 0, 0, false
+	}
+	return l, r, true
+}
+
+// numericValue は整数または浮動小数点数を float64 として返す
+func numericValue(obj object.Object) (float64, bool) {
+	switch v := obj.(type) {
+	case *object.Integer:
+		return float64(v.Value), true
+	case *object.Float:
+		return v.Value, true
+	}
+	return 0, false
+}
+
+// compareObjects は2つの値を比較して負・0・正の値を返す
+// 数値同士と文字列同士のみ比較でき、それ以外はエラーになる
+func compareObjects(name string, left, right object.Object) (int, *object.Error) {
+	// 整数同士は精度を落とさないようにそのまま比較する
+	if l, r, ok := integerPair(left, right); ok {
+		switch {
+		case l < r:
+			return -1, nil
+		case l > r:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	
+	if l, r, ok := numericPair(left, right); ok {
+		switch {
+		case l < r:
+			return -1, nil
+		case l > r:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	
+	if l, ok := left.(*object.String); ok {
+		if r, ok := right.(*object.String); ok {
+			return strings.Compare(l.Value, r.Value), nil
+		}
+	}
+	
+	return 0, createError("%s関数は数値同士または文字列同士でのみ比較できます: %s と %s", name, left.Type(), right.Type())
+}
+
+// newComparisonBuiltin は大小比較を行う組み込み関数を作成する
+func newComparisonBuiltin(name string, test func(int) bool) *object.Builtin {
+	return &object.Builtin{
+		Name: name,
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return createError("%s関数は2つの引数が必要です: %d個与えられました", name, len(args))
+			}
+			
+			c, err := compareObjects(name, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			return &object.Boolean{Value: test(c)}
+		},
+		ReturnType: object.BOOLEAN_OBJ,
+		ParamTypes: []object.ObjectType{object.ANY_OBJ, object.ANY_OBJ},
+	}
+}
+
+// newLogicalBuiltin は真偽値同士の論理演算を行う組み込み関数を作成する
+func newLogicalBuiltin(name string, op func(bool, bool) bool) *object.Builtin {
+	return &object.Builtin{
+		Name: name,
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return createError("%s関数は2つの引数が必要です: %d個与えられました", name, len(args))
+			}
+			
+			values := make([]bool, 2)
+			for i, arg := range args {
+				b, ok := arg.(*object.Boolean)
+				if !ok {
+					return createError("%s関数の第%d引数は真偽値である必要があります: %s", name, i+1, arg.Type())
+				}
+				values[i] = b.Value
+			}
+			return &object.Boolean{Value: op(values[0], values[1])}
+		},
+		ReturnType: object.BOOLEAN_OBJ,
+		ParamTypes: []object.ObjectType{object.BOOLEAN_OBJ, object.BOOLEAN_OBJ},
+	}
 }
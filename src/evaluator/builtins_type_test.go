@@ -0,0 +1,182 @@
+package evaluator
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+)
+
+// TestConversionBuiltins は型変換の組み込み関数をテストする
+func TestConversionBuiltins(t *testing.T) {
+	// テスト時はデバッグログを無効化
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"42" |> to_int;`, int64(42)},
+		{`" -7 " |> to_int;`, int64(-7)},
+		{`3.9 |> to_int;`, int64(3)},
+		{`true |> to_int;`, int64(1)},
+		{`to_int("7");`, int64(7)},
+		{`"2.5" |> to_float;`, 2.5},
+		{`2 |> to_float;`, 2.0},
+		{`"true" |> to_bool;`, true},
+		{`0 |> to_bool;`, false},
+		{`"x" |> to_string;`, "x"},
+		{`1.5 |> to_string;`, "1.5"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int64:
+			testIntegerObject(t, evaluated, expected)
+		case float64:
+			result, ok := evaluated.(*object.Float)
+			if !ok {
+				t.Errorf("object is not Float. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if result.Value != expected {
+				t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+			}
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+// TestComparisonBuiltins は比較・論理演算の組み込み関数をテストする
+func TestComparisonBuiltins(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`5 |> eq 5;`, true},
+		{`eq(1, 1.0);`, true},
+		{`"a" |> eq 1;`, false},
+		{`5 |> ne 3;`, true},
+		{`2 |> lt 3;`, true},
+		{`3 |> le 3;`, true},
+		{`2.5 |> gt 2;`, true},
+		{`"b" |> ge "a";`, true},
+		{`lt("あ", "い");`, true},
+		{`true |> and false;`, false},
+		{`or(false, true);`, true},
+		{`true |> not;`, false},
+		// 2^53 を超える整数も eq と lt で結果が食い違わない
+		{`9007199254740993 |> eq 9007199254740992;`, false},
+		{`9007199254740992 |> lt 9007199254740993;`, true},
+		{`9007199254740993 |> ne 9007199254740992;`, true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+// TestComparisonBuiltinsInFilter は比較関数をfilterの条件として使えることをテストする
+func TestComparisonBuiltinsInFilter(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input    string
+		expected []int64
+	}{
+		{`[1..6] ?> gt 3;`, []int64{4, 5, 6}},
+		{`[1..6] ?> le 2;`, []int64{1, 2}},
+		{`[1, 2, 1] ?> eq 1;`, []int64{1, 1}},
+		{`[1, 2, 1] ?> ne 1;`, []int64{2}},
+	}
+
+	for _, tt := range tests {
+		testIntegerArray(t, testEval(tt.input), tt.expected)
+	}
+}
+
+// TestConversionAndComparisonErrors は不正な入力に対してエラーオブジェクトが返ることをテストする
+func TestConversionAndComparisonErrors(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`"abc" |> to_int;`, `to_int関数: 文字列 "abc" は整数に変換できません`},
+		{`"abc" |> to_float;`, `to_float関数: 文字列 "abc" は浮動小数点数に変換できません`},
+		{`"abc" |> to_bool;`, `to_bool関数: 文字列 "abc" は真偽値に変換できません`},
+		{`[1] |> to_int;`, `to_int関数: ARRAY 型の値は整数に変換できません`},
+		{`"x" |> gt 1;`, `gt関数は数値同士または文字列同士でのみ比較できます: STRING と INTEGER`},
+		{`1 |> and true;`, `and関数の第1引数は真偽値である必要があります: INTEGER`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+// TestInputBuiltin は標準入力から1行ずつ読み込めることをテストする
+func TestInputBuiltin(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	original := stdinReader
+	defer func() { stdinReader = original }()
+	stdinReader = bufio.NewReader(strings.NewReader("太郎\r\n42\n"))
+
+	testStringObject(t, testEval(`input();`), "太郎")
+	testIntegerObject(t, testEval(`input() |> to_int;`), 42)
+	testNullObject(t, testEval(`input();`))
+}
+
+// promptRecorder は読み込まれた時点での出力先の内容を記録する io.Reader
+type promptRecorder struct {
+	out     *bytes.Buffer
+	input   *strings.Reader
+	visible string
+}
+
+func (r *promptRecorder) Read(p []byte) (int, error) {
+	r.visible = r.out.String()
+	return r.input.Read(p)
+}
+
+// TestInputPromptFlushed は input のプロンプトが標準入力を読む前にフラッシュされることをテストする
+func TestInputPromptFlushed(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	// -output の tee のようにバッファを持つ出力先
+	var out bytes.Buffer
+	prev := programOutput
+	SetOutput(bufio.NewWriter(&out))
+	defer SetOutput(prev)
+
+	recorder := &promptRecorder{out: &out, input: strings.NewReader("太郎\n")}
+	original := stdinReader
+	defer func() { stdinReader = original }()
+	stdinReader = bufio.NewReader(recorder)
+
+	testStringObject(t, testEval(`input("名前: ");`), "太郎")
+	if recorder.visible != "名前: " {
+		t.Errorf("prompt should be flushed before reading stdin. got=%q", recorder.visible)
+	}
+}
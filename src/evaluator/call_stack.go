@@ -0,0 +1,112 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/uncode/ast"
+	"github.com/uncode/config"
+	"github.com/uncode/object"
+	"github.com/uncode/token"
+)
+
+// maxCallDepth は関数呼び出しの深さの上限（0 の場合は上限なし）
+var maxCallDepth = config.DefaultMaxCallDepth
+
+// callDepth は現在の関数呼び出しの深さ
+var callDepth = 0
+
+// SetMaxCallDepth は関数呼び出しの深さの上限を設定する（0 の場合は上限なし）
+func SetMaxCallDepth(depth int) {
+	maxCallDepth = depth
+}
+
+// evalFunctionBody はユーザー定義関数の本体を評価する
+// 呼び出しの深さが上限を超えた場合は上限超過のエラーを返し、
+// 本体からエラーが伝わった場合はその関数を呼び出し履歴に追加する
+func evalFunctionBody(fn *object.Function, body *ast.BlockStatement, env *object.Environment) object.Object {
+	if maxCallDepth > 0 && callDepth >= maxCallDepth {
+		err := createError("関数呼び出しの深さが上限 (%d) を超えました。無限に再帰していないか確認してください (--max-depth で変更できます)", maxCallDepth)
+		err.Kind = object.LimitError
+		return err
+	}
+
+	if hook != nil {
+		hook.EnterFunction(fn, env)
+	}
+	if profiler != nil {
+		input, _ := env.Get("🍕")
+		profiler.Enter(functionFrame(fn, body), input)
+	}
+	callDepth++
+	result := evalBlockStatement(body, env)
+	callDepth--
+	if profiler != nil {
+		profiler.Exit(result)
+	}
+	if hook != nil {
+		hook.ExitFunction(fn, result)
+	}
+
+	if err, ok := result.(*object.Error); ok && !err.Exit {
+		err.Stack = append(err.Stack, object.StackFrame{Function: functionName(fn)})
+	}
+	return result
+}
+
+// functionName は呼び出し履歴に表示する関数名を返す
+// 条件付き関数は "名前#番号" で登録されているため、番号を取り除く
+func functionName(fn *object.Function) string {
+	name, ok := fn.Name()
+	if !ok {
+		return "<無名関数>"
+	}
+	if i := strings.Index(name, "#"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// annotateError はエラーに発生した文の位置を記録する
+// 最初に通過した文がエラーの発生位置になり、関数から伝わってきた場合は呼び出し元の位置を記録する
+func annotateError(result object.Object, statement ast.Statement) {
+	err, ok := result.(*object.Error)
+	if !ok || err.Exit {
+		return
+	}
+	tok, ok := statementToken(statement)
+	if !ok {
+		return
+	}
+
+	if err.Line == 0 {
+		err.Line, err.Column = tok.Line, tok.Column
+	}
+	if n := len(err.Stack); n > 0 && err.Stack[n-1].Line == 0 {
+		err.Stack[n-1].Line, err.Stack[n-1].Column = tok.Line, tok.Column
+	}
+}
+
+// statementToken は文の位置を表すトークンを返す
+func statementToken(statement ast.Statement) (token.Token, bool) {
+	switch stmt := statement.(type) {
+	case *ast.ExpressionStatement:
+		return stmt.Token, true
+	case *ast.AssignStatement:
+		return stmt.Token, true
+	case *ast.PipeStatement:
+		return stmt.Token, true
+	case *ast.GlobalStatement:
+		return stmt.Token, true
+	case *ast.CaseStatement:
+		return stmt.Token, true
+	case *ast.DefaultCaseStatement:
+		return stmt.Token, true
+	}
+	return token.Token{}, false
+}
+
+// isUnrecoverable はcase文の本体で発生しても次のcaseへ進まず、呼び出し元へ伝えるべき結果かどうかを判定する
+func isUnrecoverable(obj object.Object) bool {
+	err, ok := obj.(*object.Error)
+	return ok && (err.Exit || err.Kind == object.LimitError)
+}
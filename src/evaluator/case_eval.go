@@ -29,7 +29,9 @@ func logCaseDebug(format string, args ...interface{}) {
 
 // evalCaseStatement はcase文を評価
 func evalCaseStatement(node *ast.CaseStatement, env *object.Environment) object.Object {
-	logCaseDebug("case文の評価を開始: %s", node.Condition.String())
+	if isCaseDebugEnabled() {
+		logCaseDebug("case文の評価を開始: %s", node.Condition.String())
+	}
 	
 	// 🍕変数の存在確認と取得
 	pizzaVal, ok := getPizzaValueFromEnv(env)
@@ -38,8 +40,10 @@ func evalCaseStatement(node *ast.CaseStatement, env *object.Environment) object.
 		return createError("case文の評価中に🍕変数が見つかりませんでした")
 	}
 	
-	logCaseDebug("case文の評価: 条件=%s, 🍕値=%s", 
-		node.Condition.String(), pizzaVal.Inspect())
+	if isCaseDebugEnabled() {
+		logCaseDebug("case文の評価: 条件=%s, 🍕値=%s", 
+			node.Condition.String(), pizzaVal.Inspect())
+	}
 	
 	// 条件式評価中のフラグを設定
 	if currentFunction != nil {
@@ -53,25 +57,36 @@ func evalCaseStatement(node *ast.CaseStatement, env *object.Environment) object.
 	// 条件式を評価
 	condition := Eval(node.Condition, env)
 	if isError(condition) {
-		logCaseDebug("case文の条件評価でエラー: %s", condition.Inspect())
+		if isCaseDebugEnabled() {
+			logCaseDebug("case文の条件評価でエラー: %s", condition.Inspect())
+		}
 		// エラーを返して、evalBlockStatementでハンドリングする
 		return condition
 	}
 	
 	// 条件式の結果を詳細にログ
-	logCaseDebug("条件評価結果: タイプ=%s, 値=%s, isTruthy=%v", 
-		condition.Type(), condition.Inspect(), isTruthy(condition))
+	if isCaseDebugEnabled() {
+		logCaseDebug("条件評価結果: タイプ=%s, 値=%s, isTruthy=%v", 
+			condition.Type(), condition.Inspect(), isTruthy(condition))
+	}
 	
 	// 条件が真の場合、ブロックを実行
 	if isTruthy(condition) {
 		logCaseDebug("条件が真: ブロックを実行")
+		if hook != nil {
+			hook.SelectCase(node, env)
+		}
 		if node.Body != nil {
 			result := evalBlockStatement(node.Body, env)
-			logCaseDebug("case文のブロック評価結果: %s", result.Inspect())
+			if isCaseDebugEnabled() {
+				logCaseDebug("case文のブロック評価結果: %s", result.Inspect())
+			}
 			return result
 		} else if node.Consequence != nil {
 			result := evalBlockStatement(node.Consequence, env)
-			logCaseDebug("case文の結果ブロック評価結果: %s", result.Inspect())
+			if isCaseDebugEnabled() {
+				logCaseDebug("case文の結果ブロック評価結果: %s", result.Inspect())
+			}
 			return result
 		}
 		logCaseDebug("警告: case文に実行可能なブロックがありません")
@@ -87,22 +102,31 @@ func evalCaseStatement(node *ast.CaseStatement, env *object.Environment) object.
 func evalDefaultCaseStatement(node *ast.DefaultCaseStatement, env *object.Environment) object.Object {
 	logCaseDebug("default文の評価を開始")
 	// 条件チェックなし、常にブロックを実行
+	if hook != nil {
+		hook.SelectCase(node, env)
+	}
 	result := evalBlockStatement(node.Body, env)
-	logCaseDebug("default文の評価結果: %s", result.Inspect())
+	if isCaseDebugEnabled() {
+		logCaseDebug("default文の評価結果: %s", result.Inspect())
+	}
 	return result
 }
 
 // 🍕変数の取得補助関数
 func getPizzaValueFromEnv(env *object.Environment) (object.Object, bool) {
 	if obj, ok := env.Get("🍕"); ok {
-		logCaseDebug("環境から🍕値を取得: %s", obj.Inspect())
+		if isCaseDebugEnabled() {
+			logCaseDebug("環境から🍕値を取得: %s", obj.Inspect())
+		}
 		return obj, true
 	}
 	
 	// 現在の関数からの取得を試みる
 	if currentFunction != nil {
 		if pizzaVal := currentFunction.GetPizzaValue(); pizzaVal != nil {
-			logCaseDebug("現在の関数から🍕値を取得: %s", pizzaVal.Inspect())
+			if isCaseDebugEnabled() {
+				logCaseDebug("現在の関数から🍕値を取得: %s", pizzaVal.Inspect())
+			}
 			return pizzaVal, true
 		}
 	}
@@ -44,11 +44,15 @@ func evalConditionalExpression(fn *object.Function, args []object.Object, env *o
 	// 🍕メンバーの設定（重要な改善点）
 	if len(args) > 0 {
 		// 1. 関数オブジェクトに🍕値を直接設定
-		logConditionDebug("関数オブジェクトに🍕値を設定: %s (%s)", args[0].Inspect(), args[0].Type())
+		if isConditionDebugEnabled() {
+			logConditionDebug("関数オブジェクトに🍕値を設定: %s (%s)", args[0].Inspect(), args[0].Type())
+		}
 		fn.SetPizzaValue(args[0])
 		
 		// 2. 環境にも🍕値を設定（互換性維持のため）
-		logConditionDebug("条件評価環境にも🍕値を設定: %s", args[0].Inspect())
+		if isConditionDebugEnabled() {
+			logConditionDebug("条件評価環境にも🍕値を設定: %s", args[0].Inspect())
+		}
 		condEnv.Set("🍕", args[0])
 	} else {
 		logConditionDebug("引数が指定されていないため、🍕値は設定されません")
@@ -69,14 +73,18 @@ func evalConditionalExpression(fn *object.Function, args []object.Object, env *o
 		
 		// 環境内の🍕値の状態表示
 		if pizzaVal, ok := condEnv.Get("🍕"); ok {
-			logConditionDebug("環境内の🍕変数: タイプ=%s, 値=%s", pizzaVal.Type(), pizzaVal.Inspect())
+			if isConditionDebugEnabled() {
+				logConditionDebug("環境内の🍕変数: タイプ=%s, 値=%s", pizzaVal.Type(), pizzaVal.Inspect())
+			}
 		} else {
 			logConditionDebug("環境内の🍕変数: 未設定")
 		}
 		
 		// 関数オブジェクト内の🍕値の状態表示
 		if pizzaVal := fn.GetPizzaValue(); pizzaVal != nil {
-			logConditionDebug("関数オブジェクト内の🍕値: タイプ=%s, 値=%s", pizzaVal.Type(), pizzaVal.Inspect())
+			if isConditionDebugEnabled() {
+				logConditionDebug("関数オブジェクト内の🍕値: タイプ=%s, 値=%s", pizzaVal.Type(), pizzaVal.Inspect())
+			}
 		} else {
 			logConditionDebug("関数オブジェクト内の🍕値: nil")
 		}
@@ -95,7 +103,9 @@ func evalConditionalExpression(fn *object.Function, args []object.Object, env *o
 	currentFunction = prevFunction
 	
 	if condResult.Type() == object.ERROR_OBJ {
-		logConditionDebug("条件評価でエラーが発生しました: %s", condResult.Inspect())
+		if isConditionDebugEnabled() {
+			logConditionDebug("条件評価でエラーが発生しました: %s", condResult.Inspect())
+		}
 		return false, condResult
 	}
 	
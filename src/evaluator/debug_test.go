@@ -70,7 +70,7 @@ func TestSpecificDebugFlags(t *testing.T) {
 	testIntegerObject(t, evaluated, 10)
 	
 	// builtinデバッグも有効化（実装がなければスキップ）
-	input = `len("hello")`
+	input = `"hello" |> length`
 	evaluated = testEval(input)
 	testIntegerObject(t, evaluated, 5)
 }
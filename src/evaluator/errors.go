@@ -3,6 +3,7 @@ package evaluator
 import (
 	"fmt"
 
+	"github.com/uncode/config"
 	"github.com/uncode/logger"
 	"github.com/uncode/object"
 )
@@ -10,10 +11,25 @@ import (
 // createEvalError creates an evaluation error with formatted message and logs it
 func createEvalError(format string, a ...interface{}) *object.Error {
 	msg := fmt.Sprintf(format, a...)
-	logger.Error("評価エラー: %s", msg)
+	if errorLogsEnabled() {
+		logger.Error("評価エラー: %s", msg)
+	}
 	return createError(msg)
 }
 
+// errorLogsEnabled はエラーを作成したときにログに記録するかどうかを返す
+// --diagnostics=json ではエラーを診断として標準エラー出力に JSON で出力するため、標準出力のログには書かない
+func errorLogsEnabled() bool {
+	return config.GlobalConfig.Diagnostics != config.DiagnosticsJSON
+}
+
+// createTypeError は型注釈や演算子の型が合わない場合の型エラーを作成する
+func createTypeError(format string, a ...interface{}) *object.Error {
+	err := createError(format, a...)
+	err.Kind = object.TypeError
+	return err
+}
+
 // isError checks if the given object is an error object
 func isError(obj object.Object) bool {
 	if obj != nil {
@@ -21,3 +37,10 @@ func isError(obj object.Object) bool {
 	}
 	return false
 }
+
+// isExit は exit 関数によるプログラムの終了かどうかを判定する
+// 終了はエラーと同じ経路で呼び出し元に伝わり、プログラムの評価を打ち切る
+func isExit(obj object.Object) bool {
+	err, ok := obj.(*object.Error)
+	return ok && err.Exit
+}
@@ -42,8 +42,18 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 		env = object.NewEnvironment()
 	}
 
-	logger.Debug("評価中のノード: %T", node)
-	logger.EvalDebug("<<<評価器デバッグ専用ログ>>> 評価中のノード: %T", node)
+	if hook != nil {
+		if n, ok := node.(ast.Node); ok {
+			hook.BeforeEval(n, env)
+		}
+	}
+
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("評価中のノード: %T", node)
+	}
+	if logger.Enabled(logger.LevelEvalDebug) {
+		logger.EvalDebug("<<<評価器デバッグ専用ログ>>> 評価中のノード: %T", node)
+	}
 
 	switch node := node.(type) {
 	case *ast.Program:
@@ -52,6 +62,7 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 
 	case *ast.ExpressionStatement:
 		logger.Debug("式文ノードを評価")
+		defer enterStatement(node.Token.Line)()
 		return Eval(node.Expression, env)
 		
 	case *ast.BlockStatement:
@@ -70,31 +81,42 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 		logger.Debug("文字列リテラルを評価")
 		return &object.String{Value: node.Value}
 
+	case *ast.InterpolatedString:
+		logger.Debug("補間文字列を評価")
+		return evalInterpolatedString(node, env)
+
 	case *ast.IntegerLiteral:
 		logger.Debug("整数リテラルを評価")
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		logger.Debug("浮動小数点リテラルを評価")
+		return &object.Float{Value: node.Value}
+
 	case *ast.BooleanLiteral:
 		logger.Debug("真偽値リテラルを評価")
 		return &object.Boolean{Value: node.Value}
 		
 	case *ast.ArrayLiteral:
-		logger.Debug("配列リテラル [%v] を評価", node.Elements)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("配列リテラル [%v] を評価", node.Elements)
+		}
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) > 0 && elements[0].Type() == object.ERROR_OBJ {
 			return elements[0]
 		}
 		
+		result := &object.Array{Elements: elements}
 		// 結果の表示
-		var elemStrs []string
-		for _, e := range elements {
-			elemStrs = append(elemStrs, e.Inspect())
+		if logger.Enabled(logger.LevelDebug) {
+			var elemStrs []string
+			for _, e := range elements {
+				elemStrs = append(elemStrs, e.Inspect())
+			}
+			logger.Debug("配列リテラルの評価完了: [%s], 要素数=%d", strings.Join(elemStrs, ", "), len(elements))
+			// NOTE: ここで明示的に Array を返していることを確認
+			logger.Debug("配列オブジェクトを返します: %s (Type=%s)", result.Inspect(), result.Type())
 		}
-		logger.Debug("配列リテラルの評価完了: [%s], 要素数=%d", strings.Join(elemStrs, ", "), len(elements))
-		
-		result := &object.Array{Elements: elements}
-		// NOTE: ここで明示的に Array を返していることを確認
-		logger.Debug("配列オブジェクトを返します: %s (Type=%s)", result.Inspect(), result.Type())
 		return result
 	
 	case *ast.RangeExpression:
@@ -123,14 +145,18 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 		// 優先順位1: 関数オブジェクトから🍕値を取得
 		if currentFunction != nil {
 			if pizzaVal := currentFunction.GetPizzaValue(); pizzaVal != nil {
-				logger.Debug("関数オブジェクトから🍕値を取得: %s", pizzaVal.Inspect())
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("関数オブジェクトから🍕値を取得: %s", pizzaVal.Inspect())
+				}
 				return pizzaVal
 			}
 		}
 
 		// 優先順位2: 環境から🍕値を取得（バックアップ）
 		if val, ok := env.Get("🍕"); ok {
-			logger.Debug("環境から🍕値を取得しました: %s", val.Inspect())
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("環境から🍕値を取得しました: %s", val.Inspect())
+			}
 			return val
 		}
 		
@@ -139,6 +165,14 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 
 	case *ast.PooLiteral:
 		logger.Debug("💩リテラルを評価")
+
+		// fold演算子の中では💩に累積値が束縛されている
+		if val, ok := env.Get("💩"); ok {
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("環境から💩値を取得しました: %s", val.Inspect())
+			}
+			return val
+		}
 		logger.Debug("💩リテラルを検出: 空の戻り値オブジェクトを生成します")
 
 		// Return空のReturnValueオブジェクト
@@ -146,7 +180,9 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 		return &object.ReturnValue{}
 
 	case *ast.PrefixExpression:
-		logger.Debug("前置式を評価: %s", node.Operator)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("前置式を評価: %s", node.Operator)
+		}
 		right := Eval(node.Right, env)
 		if right.Type() == object.ERROR_OBJ {
 			return right
@@ -202,19 +238,25 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 		return function
 
 	case *ast.InfixExpression:
-		logger.Debug("中置式を評価: %s", node.Operator)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("中置式を評価: %s", node.Operator)
+		}
 		
 		// 別ファイルに移動した中置式評価関数を使用
 		return evalInfixExpressionWithNode(node, env)
 
 	case *ast.CallExpression:
 		logger.Debug("関数呼び出し式を評価")
-		logger.Trace("関数: %T, 引数の数: %d", node.Function, len(node.Arguments))
+		if logger.Enabled(logger.LevelTrace) {
+			logger.Trace("関数: %T, 引数の数: %d", node.Function, len(node.Arguments))
+		}
 
 		// 関数呼び出しが直接識別子（関数名）の場合、条件付き関数を検索
 		if ident, ok := node.Function.(*ast.Identifier); ok {
 			// 識別子名で関数を検索
-			logger.Debug("識別子 '%s' で関数を検索します", ident.Value)
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("識別子 '%s' で関数を検索します", ident.Value)
+			}
 
 			// 引数を評価
 			args := evalExpressions(node.Arguments, env)
@@ -223,9 +265,13 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 			}
 
 			// デバッグ出力
-			logger.Debug("関数 '%s' の引数: %d 個", ident.Value, len(args))
-			for i, arg := range args {
-				logger.Trace("  引数 %d: %s", i, arg.Inspect())
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("関数 '%s' の引数: %d 個", ident.Value, len(args))
+			}
+			if logger.Enabled(logger.LevelTrace) {
+				for i, arg := range args {
+					logger.Trace("  引数 %d: %s", i, arg.Inspect())
+				}
 			}
 
 			// 環境内の同名のすべての関数を検索し、条件に合う関数を適用
@@ -256,7 +302,9 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 
 			// 引数を環境にバインド
 			for i, param := range fn.Parameters {
-				logger.Debug("  引数 '%s' に値 '%s' をバインドします", param.Value, args[i].Inspect())
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("  引数 '%s' に値 '%s' をバインドします", param.Value, args[i].Inspect())
+				}
 				extendedEnv.Set(param.Value, args[i])
 			}
 
@@ -270,12 +318,16 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 			}
 			
 			logger.Debug("  関数本体を評価します")
-			result := evalBlockStatement(astBody, extendedEnv)
-			logger.Debug("  関数本体の評価結果: %T", result)
+			result := evalFunctionBody(fn, astBody, extendedEnv)
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("  関数本体の評価結果: %T", result)
+			}
 
 			// ReturnValue オブジェクトの処理
 			if returnValue, ok := result.(*object.ReturnValue); ok {
-				logger.Debug("  関数から戻り値を受け取りました: %s", returnValue.Inspect())
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("  関数から戻り値を受け取りました: %s", returnValue.Inspect())
+				}
 				// Valueフィールドがnilの場合は空のオブジェクトを返す
 				if returnValue.Value == nil {
 					logger.Debug("  戻り値が nil です、NULL を返します")
@@ -284,10 +336,12 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 				return returnValue.Value
 			}
 			
-			logger.Debug("  通常の評価結果を返します: %s", result.Inspect())
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("  通常の評価結果を返します: %s", result.Inspect())
+			}
 			return result
 		} else if builtin, ok := function.(*object.Builtin); ok {
-			return builtin.Fn(args...)
+			return callBuiltin(builtin, args...)
 		}
 
 		return createError("関数ではありません: %s", function.Type())
@@ -298,6 +352,7 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 
 	case *ast.AssignStatement:
 		logger.Debug("代入文を評価")
+		defer enterStatement(node.Token.Line)()
 
 		// 右辺を評価
 		right := Eval(node.Value, env)
@@ -307,7 +362,10 @@ func Eval(node interface{}, env *object.Environment) object.Object {
 
 		// 左辺が識別子の場合は変数に代入
 		if ident, ok := node.Left.(*ast.Identifier); ok {
-			logger.Debug("変数 %s に代入します", ident.Value)
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("変数 %s に代入します", ident.Value)
+			}
+			right = storedValue(right)
 			env.Set(ident.Value, right)
 			return right
 		} else {
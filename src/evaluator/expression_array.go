@@ -7,6 +7,7 @@ import (
 )
 
 // evalRangeExpression evaluates range expressions
+// 範囲式は配列を作らず、要素を順に計算するストリームを返す
 func evalRangeExpression(node *ast.RangeExpression, env *object.Environment) object.Object {
 	logger.Debug("レンジ式を評価: %v..%v", node.Start, node.End)
 	var startObj, endObj object.Object
@@ -14,10 +15,14 @@ func evalRangeExpression(node *ast.RangeExpression, env *object.Environment) obj
 	if node.Start != nil {
 		startObj = Eval(node.Start, env)
 		if startObj.Type() == object.ERROR_OBJ {
-			logger.Debug("レンジ式の開始値評価でエラー: %s", startObj.Inspect())
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("レンジ式の開始値評価でエラー: %s", startObj.Inspect())
+			}
 			return startObj
 		}
-		logger.Debug("レンジ式の開始値: %s", startObj.Inspect())
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("レンジ式の開始値: %s", startObj.Inspect())
+		}
 	} else {
 		// Default start for [..end] is 1
 		startObj = &object.Integer{Value: 1}
@@ -27,49 +32,42 @@ func evalRangeExpression(node *ast.RangeExpression, env *object.Environment) obj
 	if node.End != nil {
 		endObj = Eval(node.End, env)
 		if endObj.Type() == object.ERROR_OBJ {
-			logger.Debug("レンジ式の終了値評価でエラー: %s", endObj.Inspect())
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("レンジ式の終了値評価でエラー: %s", endObj.Inspect())
+			}
 			return endObj
 		}
-		logger.Debug("レンジ式の終了値: %s", endObj.Inspect())
-	} else {
-		// Default end for [start..] is startObj + 10 (just a convention for this language)
-		if startObj.Type() == object.INTEGER_OBJ {
-			endObj = &object.Integer{Value: startObj.(*object.Integer).Value + 9}
-			logger.Debug("レンジ式の終了値がないため、開始値+9の値 %d を使用", endObj.(*object.Integer).Value)
-		} else {
-			// If start is not an integer, default to empty array
-			logger.Debug("レンジ式の開始値が整数でないため、空の配列を返します")
-			return &object.Array{Elements: []object.Object{}}
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("レンジ式の終了値: %s", endObj.Inspect())
 		}
+	} else if start, ok := startObj.(*object.Integer); ok {
+		// [start..] は終わりのない範囲になる
+		logger.Debug("レンジ式の終了値がないため、%d から始まる無限ストリームを作成", start.Value)
+		return newIntRangeStream(start.Value, 0, true)
+	} else {
+		return createError("サポートされていない範囲式の型: 終了値のない範囲の開始値は整数である必要があります: %s", startObj.Type())
 	}
 	
 	// Create integer range
-	if startObj != nil && endObj != nil {
-		if startObj.Type() == object.INTEGER_OBJ && endObj.Type() == object.INTEGER_OBJ {
-			start := startObj.(*object.Integer).Value
-			end := endObj.(*object.Integer).Value
-			logger.Debug("整数レンジを作成: %d..%d", start, end)
-			
-			var elements []object.Object
-			if start <= end {
-				for i := start; i <= end; i++ {
-					elements = append(elements, &object.Integer{Value: i})
-				}
-			} else {
-				for i := start; i >= end; i-- {
-					elements = append(elements, &object.Integer{Value: i})
-				}
-			}
-			
-			result := &object.Array{Elements: elements}
-			logger.Debug("レンジ式の評価結果: %s (要素数: %d)", result.Inspect(), len(elements))
-			return result
+	if startObj.Type() == object.INTEGER_OBJ && endObj.Type() == object.INTEGER_OBJ {
+		start := startObj.(*object.Integer).Value
+		end := endObj.(*object.Integer).Value
+		logger.Debug("整数レンジを作成: %d..%d", start, end)
+		return newIntRangeStream(start, end, false)
+	}
+	
+	// Create character range ["a".."e"]
+	startStr, startOk := startObj.(*object.String)
+	endStr, endOk := endObj.(*object.String)
+	if startOk && endOk {
+		startRunes, endRunes := []rune(startStr.Value), []rune(endStr.Value)
+		if len(startRunes) == 1 && len(endRunes) == 1 {
+			logger.Debug("文字レンジを作成: %s..%s", startStr.Value, endStr.Value)
+			return newRuneRangeStream(startRunes[0], endRunes[0])
 		}
 	}
 	
-	// Empty array as default
-	logger.Debug("レンジ式がinteger型でないため、空の配列を返します")
-	return &object.Array{Elements: []object.Object{}}
+	return createError("サポートされていない範囲式の型: %s..%s", startObj.Type(), endObj.Type())
 }
 
 // evalIndexExpression evaluates index expressions
@@ -79,11 +77,34 @@ func evalIndexExpression(left, index object.Object, env *object.Environment) obj
 		return evalArrayIndexExpression(left, index)
 	case left.Type() == object.STRING_OBJ:
 		return evalStringIndexExpression(left, index)
+	case left.Type() == object.HASH_OBJ:
+		return evalHashIndexExpression(left, index)
+	case left.Type() == object.STREAM_OBJ:
+		if idx, ok := index.(*object.Integer); ok {
+			return streamIndex(left.(*object.Stream), idx.Value)
+		}
+		return createError("Array index must be an integer")
 	default:
 		return createError("Index operator not supported")
 	}
 }
 
+// evalHashIndexExpression evaluates hash index expressions
+// 存在しないキーの場合はnullを返す
+func evalHashIndexExpression(hash, index object.Object) object.Object {
+	hashObj := hash.(*object.Hash)
+
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return createError("ハッシュのキーとして使用できない型です: %s", index.Type())
+	}
+
+	if value, ok := hashObj.Get(key); ok {
+		return value
+	}
+	return NullObj
+}
+
 // evalArrayIndexExpression evaluates array index expressions
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObj := array.(*object.Array)
@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -35,6 +36,38 @@ func evalStandardInfixExpression(node *ast.InfixExpression, env *object.Environm
 		return right
 	}
 	
+	return evalInfixExpressionAt(node, left, right)
+}
+
+// evalInfixExpressionAt は構文木の位置情報を使って中置式を評価する
+// 文字列と整数の混在する演算は、厳密モードではエラーに、それ以外では暗黙の型変換として警告する
+func evalInfixExpressionAt(node *ast.InfixExpression, left, right object.Object) object.Object {
+	// ストリームは配列に変換してから演算する
+	if left = materialize(left); isError(left) {
+		return left
+	}
+	if right = materialize(right); isError(right) {
+		return right
+	}
+
+	warnCoercedInteger(node.Token.Line, left, right)
+
+	if isStringIntegerPair(left, right) {
+		if strictMode {
+			return createTypeError("厳密モード: %d行目: 文字列と整数は演算できません: %s %s %s",
+				node.Token.Line, left.Type(), node.Operator, right.Type())
+		}
+
+		// 文字列が数値として解釈できる場合のみ変換が行われる
+		str := left
+		if right.Type() == object.STRING_OBJ {
+			str = right
+		}
+		if _, err := strconv.ParseInt(str.(*object.String).Value, 10, 64); err == nil {
+			warnCoercion(node.Token.Line, fmt.Sprintf("演算子 %s の文字列オペランドを整数に変換しました", node.Operator), str)
+		}
+	}
+
 	return evalInfixExpression(node.Operator, left, right)
 }
 
@@ -63,7 +96,7 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	
 	// 型の不一致
 	if left.Type() != right.Type() {
-		return createError("型の不一致: %s %s %s", left.Type(), operator, right.Type())
+		return createTypeError("型の不一致: %s %s %s", left.Type(), operator, right.Type())
 	}
 	
 	return createError("未知の演算子: %s %s %s", left.Type(), operator, right.Type())
@@ -185,7 +218,7 @@ func evalStringIntegerInfixExpression(operator string, left, right object.Object
 			case "!=":
 				return TRUE // 文字列と数値は常に異なる
 			default:
-				return createError("型の不一致による比較: %s %s %s", left.Type(), operator, right.Type())
+				return createTypeError("型の不一致による比較: %s %s %s", left.Type(), operator, right.Type())
 			}
 		}
 		// 文字列が数値として解釈できる場合、数値比較として扱う
@@ -203,7 +236,7 @@ func evalStringIntegerInfixExpression(operator string, left, right object.Object
 			case "!=":
 				return TRUE // 数値と文字列は常に異なる
 			default:
-				return createError("型の不一致による比較: %s %s %s", left.Type(), operator, right.Type())
+				return createTypeError("型の不一致による比較: %s %s %s", left.Type(), operator, right.Type())
 			}
 		}
 		// 文字列が数値として解釈できる場合、数値比較として扱う
@@ -236,7 +269,9 @@ func evalBooleanInfixExpression(operator string, left, right object.Object) obje
 
 // evalPrefixExpression は前置式を評価する
 func evalPrefixExpression(operator string, right object.Object) object.Object {
-	logger.EvalDebug("<<<評価器デバッグ専用ログ>>> 前置式を評価します: operator=%s, right=%s", operator, right.Inspect())
+	if logger.Enabled(logger.LevelEvalDebug) {
+		logger.EvalDebug("<<<評価器デバッグ専用ログ>>> 前置式を評価します: operator=%s, right=%s", operator, right.Inspect())
+	}
 	
 	switch operator {
 	case "!":
@@ -11,7 +11,9 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
 		// 関数呼び出しの実装
-		logger.Debug("関数を呼び出します: %s", fn.Inspect())
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("関数を呼び出します: %s", fn.Inspect())
+		}
 
 		// 修正: 引数は1つまでだけ許可（パイプライン以外）
 		if len(fn.Parameters) > 1 {
@@ -25,10 +27,12 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 
 		// 入力型のチェック（パラメータが定義されている型と一致するか）
 		if len(args) > 0 && fn.InputType != "" {
-			logger.Debug("入力型チェック: 関数=%s, 入力型=%s, 実際=%s", 
-				fn.Inspect(), fn.InputType, args[0].Type())
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("入力型チェック: 関数=%s, 入力型=%s, 実際=%s", 
+					fn.Inspect(), fn.InputType, args[0].Type())
+			}
 			if ok, err := checkInputType(args[0], fn.InputType); !ok {
-				return createError("%s", err.Error())
+				return createTypeError("%s", err.Error())
 			}
 		}
 		
@@ -42,7 +46,9 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 
 		// case文のために第一引数を🍕として設定
 		if len(args) > 0 {
-			logger.Debug("🍕値を環境に設定: %s", args[0].Inspect())
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("🍕値を環境に設定: %s", args[0].Inspect())
+			}
 			extendedEnv.Set("🍕", args[0])
 			
 			// 関数オブジェクトにも🍕値を設定（将来の参照用）
@@ -62,7 +68,7 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 			currentFunction = oldCurrentFunction
 			return createError("関数の本体がBlockStatementではありません")
 		}
-		result := evalBlockStatement(astBody, extendedEnv)
+		result := evalFunctionBody(fn, astBody, extendedEnv)
 
 		// 一時的な変数を元に戻す
 		currentFunction = oldCurrentFunction
@@ -71,10 +77,12 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 		if obj, ok := result.(*object.ReturnValue); ok {
 			// 戻り値の型チェック
 			if fn.ReturnType != "" {
-				logger.Debug("戻り値型チェック: 関数=%s, 戻り値型=%s, 実際=%s",
-					fn.Inspect(), fn.ReturnType, obj.Value.Type())
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("戻り値型チェック: 関数=%s, 戻り値型=%s, 実際=%s",
+						fn.Inspect(), fn.ReturnType, obj.Value.Type())
+				}
 				if ok, err := checkReturnType(obj.Value, fn.ReturnType); !ok {
-					return createError("%s", err.Error())
+					return createTypeError("%s", err.Error())
 				}
 			}
 			return obj.Value
@@ -86,7 +94,7 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 		if len(args) > 1 && fn.Name != "print" && fn.Name != "range" && fn.Name != "sum" {
 			logger.Debug("ビルトイン関数 %s は引数を1つしか取れません: 実際の引数数=%d\n", fn.Name, len(args))
 		}
-		return fn.Fn(args...)
+		return callBuiltin(fn, args...)
 
 	default:
 		return createError("関数ではありません: %s", fn.Type())
@@ -105,7 +113,9 @@ func applyCaseBare(fn *object.Function, args []object.Object) object.Object {
 	
 	// 🍕変数を設定
 	if len(args) > 0 {
-		logCaseDebug("🍕値を環境に設定: %s", args[0].Inspect())
+		if isCaseDebugEnabled() {
+			logCaseDebug("🍕値を環境に設定: %s", args[0].Inspect())
+		}
 		extendedEnv.Set("🍕", args[0])
 		
 		// 関数オブジェクトにも🍕値を設定
@@ -132,7 +142,7 @@ func applyCaseBare(fn *object.Function, args []object.Object) object.Object {
 		currentFunction = oldCurrentFunction
 		return createError("関数の本体がBlockStatementではありません")
 	}
-	result := evalBlockStatement(astBody, extendedEnv)
+	result := evalFunctionBody(fn, astBody, extendedEnv)
 	
 	// 一時的な変数を元に戻す
 	currentFunction = oldCurrentFunction
@@ -11,14 +11,18 @@ import (
 // 同じ名前で複数の関数が存在する場合は、条件に基づいて適切な関数を選択する
 func applyNamedFunction(env *object.Environment, name string, args []object.Object) object.Object {
 	logger.Debug("***** applyNamedFunction が呼び出されました *****")
-	logger.Debug("関数名: %s、引数の数: %d\n", name, len(args))
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("関数名: %s、引数の数: %d\n", name, len(args))
+	}
 
-	// デバッグ: 環境内のすべての変数を表示
-	logger.Debug("現在の環境に登録されている変数:")
-	for k, v := range env.GetVariables() {
-		logger.Debug("  %s: %s", k, v.Type())
+	// デバッグ: 環境内のすべての変数を表示（環境の走査は重いため、ログが有効な場合だけ行う）
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("現在の環境に登録されている変数:")
+		for k, v := range env.GetVariables() {
+			logger.Debug("  %s: %s", k, v.Type())
+		}
+		logger.Debug("")
 	}
-	logger.Debug("")
 
 	// 修正: 引数の数を制限（パイプライン以外）
 	// パイプラインではない通常の呼び出しの場合、引数は1つだけ
@@ -30,7 +34,7 @@ func applyNamedFunction(env *object.Environment, name string, args []object.Obje
 	// ビルトイン関数を確認
 	if builtin, ok := Builtins[name]; ok {
 		logger.Debug("ビルトイン関数 '%s' を呼び出します\n", name)
-		return builtin.Fn(args...)
+		return callBuiltin(builtin, args...)
 	}
 
 	// 環境から同名のすべての関数を検索
@@ -41,12 +45,18 @@ func applyNamedFunction(env *object.Environment, name string, args []object.Obje
 	}
 
 	// デバッグ情報
-	logger.Debug("関数 '%s' を呼び出します: %d 個の候補が見つかりました\n", name, len(functions))
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("関数 '%s' を呼び出します: %d 個の候補が見つかりました\n", name, len(functions))
+	}
 	for i, fn := range functions {
 		if fn.Condition != nil {
-			logger.Debug("  関数候補 %d: 条件=あり\n", i+1)
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("  関数候補 %d: 条件=あり\n", i+1)
+			}
 		} else {
-			logger.Debug("  関数候補 %d: 条件=なし\n", i+1)
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("  関数候補 %d: 条件=なし\n", i+1)
+			}
 		}
 	}
 
@@ -56,81 +66,116 @@ func applyNamedFunction(env *object.Environment, name string, args []object.Obje
 
 	// 🍕 を設定（もし引数があれば）
 	if len(args) > 0 {
-		logger.Debug("関数適用の環境で🍕に値 %s を設定します\n", args[0].Inspect())
-		logger.Debug("🍕の値のタイプ: %s\n", args[0].Type())
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("関数適用の環境で🍕に値 %s を設定します\n", args[0].Inspect())
+			logger.Debug("🍕の値のタイプ: %s\n", args[0].Type())
+		}
 		funcEnv.Set("🍕", args[0])
 	} else {
 		logger.Debug("引数が見つからないため、🍕は設定しません")
 	}
 
-	// 関数が1つだけの場合は直接適用
+	fn, errObj := selectFunction(env, name, functions, args)
+	if errObj != nil {
+		return errObj
+	}
+	return applyCaseBare(fn, args)
+}
+
+// selectFunction は同じ名前の関数の中から、引数に対して適用する関数を選択する
+// 条件が真になった最初の条件付き関数を優先し、なければ条件なしの関数（デフォルト関数）を返す
+func selectFunction(env *object.Environment, name string, functions []*object.Function, args []object.Object) (*object.Function, object.Object) {
+	// 関数が1つだけの場合はそのまま選択
 	if len(functions) == 1 {
 		logger.Debug("関数が1つだけ見つかりました")
-		// case文対応: applyCaseBare を使用して呼び出す
-		logCaseDebug("単独関数をcase文対応で実行: %s", functions[0].Inspect())
-		return applyCaseBare(functions[0], args)
+		if isCaseDebugEnabled() {
+			logCaseDebug("単独関数をcase文対応で実行: %s", functions[0].Inspect())
+		}
+		return functions[0], nil
 	}
 
-	logger.Debug("複数の関数が見つかりました: %d", len(functions))
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("複数の関数が見つかりました: %d", len(functions))
+	}
 
 	// 条件付き関数と条件なし関数を正確にグループ化
 	var conditionalFuncs []*object.Function
 	var defaultFuncs []*object.Function
 
 	// デバッグ情報
-	logger.Debug("関数 '%s' を %d 個の候補から分類します", name, len(functions))
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("関数 '%s' を %d 個の候補から分類します", name, len(functions))
+	}
 
 	for i, fn := range functions {
 		// デバッグ情報: 関数の詳細
-		logger.Debug("  関数候補 %d の詳細: Condition=%v, Addr=%p", i+1, fn.Condition, fn)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("  関数候補 %d の詳細: Condition=%v, Addr=%p", i+1, fn.Condition, fn)
+		}
 		
 		// 厳密なnilチェックで条件式の有無を判定（重要）
 		hasCondition := fn.Condition != nil
-		logger.Debug("  条件式判定: %v (nilチェック結果: %v)", fn.Condition, hasCondition)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("  条件式判定: %v (nilチェック結果: %v)", fn.Condition, hasCondition)
+		}
 		
 		if hasCondition {
 			// 条件付き関数のみを条件付き関数として分類
 			conditionalFuncs = append(conditionalFuncs, fn)
-			logger.Debug("  関数候補 %d: 条件付き関数として分類（条件式: %v）", i+1, fn.Condition)
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("  関数候補 %d: 条件付き関数として分類（条件式: %v）", i+1, fn.Condition)
+			}
 			// 追加デバッグ - 関数のすべての属性を表示
 			params := ""
 			for _, p := range fn.Parameters {
 				params += p.Value + ", "
 			}
-			logger.Debug("    詳細: 入力型=%s, 戻り値型=%s, パラメータ=[%s]", 
-				fn.InputType, fn.ReturnType, params)
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("    詳細: 入力型=%s, 戻り値型=%s, パラメータ=[%s]", 
+					fn.InputType, fn.ReturnType, params)
+			}
 		} else {
 			// 条件式がないものはデフォルト関数として分類
 			defaultFuncs = append(defaultFuncs, fn)
-			logger.Debug("  関数候補 %d: デフォルト関数として分類（条件式なし）- アドレス: %p", i+1, fn)
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("  関数候補 %d: デフォルト関数として分類（条件式なし）- アドレス: %p", i+1, fn)
+			}
 			// 追加デバッグ - 関数のすべての属性を表示
 			params := ""
 			for _, p := range fn.Parameters {
 				params += p.Value + ", "
 			}
-			logger.Debug("    詳細: 入力型=%s, 戻り値型=%s, パラメータ=[%s]", 
-				fn.InputType, fn.ReturnType, params)
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("    詳細: 入力型=%s, 戻り値型=%s, パラメータ=[%s]", 
+					fn.InputType, fn.ReturnType, params)
+			}
 		}
 	}
 	
-	logger.Debug("分類結果: 条件付き関数=%d個, デフォルト関数=%d個", 
-		len(conditionalFuncs), len(defaultFuncs))
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("分類結果: 条件付き関数=%d個, デフォルト関数=%d個", 
+			len(conditionalFuncs), len(defaultFuncs))
+	}
 
 	// まず条件付き関数を検索して評価
-	logger.Debug("条件付き関数を %d 個見つけました\n", len(conditionalFuncs))
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("条件付き関数を %d 個見つけました\n", len(conditionalFuncs))
+	}
 	
 	// 条件が真となった関数を格納する変数
 	var matchedCondFunc *object.Function
 	
 	for i, fn := range conditionalFuncs {
-		logger.Debug("条件付き関数候補 %d を評価中...\n", i+1)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("条件付き関数候補 %d を評価中...\n", i+1)
+		}
 
 		// 条件式評価の共通関数を使用
 		isTrue, condResult := evalConditionalExpression(fn, args, env)
 		
 		// エラーが発生した場合、そのエラーを返す
 		if condResult != nil && condResult.Type() == object.ERROR_OBJ {
-			return condResult
+			return nil, condResult
 		}
 
 		// 条件が真なら、この関数を使用
@@ -143,16 +188,19 @@ func applyNamedFunction(env *object.Environment, name string, args []object.Obje
 		}
 	}
 	
-	// 条件に一致する関数が見つかった場合、その関数を実行
+	// 条件に一致する関数が見つかった場合、その関数を選択
 	if matchedCondFunc != nil {
 		logger.Debug("条件に一致する関数を実行します")
-		// case文対応: applyCaseBare を使用して呼び出す
-		logCaseDebug("条件付き関数をcase文対応で実行: %s", matchedCondFunc.Inspect())
-		return applyCaseBare(matchedCondFunc, args)
+		if isCaseDebugEnabled() {
+			logCaseDebug("条件付き関数をcase文対応で実行: %s", matchedCondFunc.Inspect())
+		}
+		return matchedCondFunc, nil
 	}
 
 	// 条件付き関数が該当しなかった場合、デフォルト関数を使用
-	logger.Debug("デフォルト関数を %d 個見つけました", len(defaultFuncs))
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("デフォルト関数を %d 個見つけました", len(defaultFuncs))
+	}
 	
 	// ステップ1: 明示的に宣言されたデフォルト関数を探す
 	if len(defaultFuncs) == 0 {
@@ -193,16 +241,19 @@ func applyNamedFunction(env *object.Environment, name string, args []object.Obje
 		}
 	}
 	
-	// 見つかったデフォルト関数を実行
+	// 見つかったデフォルト関数を選択
 	if len(defaultFuncs) > 0 {
-		logger.Debug("デフォルト関数を使用します: %s", name)
-		// case文対応: applyCaseBare を使用して呼び出す
-		logCaseDebug("デフォルト関数をcase文対応で実行: %s", defaultFuncs[0].Inspect())
-		return applyCaseBare(defaultFuncs[0], args)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("デフォルト関数を使用します: %s", name)
+		}
+		if isCaseDebugEnabled() {
+			logCaseDebug("デフォルト関数をcase文対応で実行: %s", defaultFuncs[0].Inspect())
+		}
+		return defaultFuncs[0], nil
 	} else {
 		// どのような関数も見つからなかった場合、エラーを返す
 		logger.Debug("適切なデフォルト関数が見つかりませんでした")
-		return createEvalError("条件に一致する関数 '%s' が見つかりません", name)
+		return nil, createEvalError("条件に一致する関数 '%s' が見つかりません", name)
 	}
 
 	// この行は実行されません（上記のif-elseで必ずreturnするため）
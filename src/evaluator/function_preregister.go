@@ -21,17 +21,19 @@ func PreregisterFunctions(program *ast.Program, env *object.Environment) {
 
 	// 関数名のマップを作成して二重登録を防止
 	registeredFunctions := make(map[string]bool)
+	// 第一パスで登録した関数定義を第二パスで再び登録しないように、登録済みの関数リテラルを記録する
+	registeredLiterals := make(map[*ast.FunctionLiteral]bool)
 	// 第一パス: すべてのトップレベル関数定義を処理
 	for i, stmt := range program.Statements {
 		logger.Debug("関数事前登録: ステートメント %d を処理中 (%T)", i+1, stmt)
-		registerFunctionsInStatement(stmt, env, registeredFunctions)
+		registerFunctionsInStatement(stmt, env, registeredFunctions, registeredLiterals)
 	}
 	// 第二パス: すべてのステートメント内のネストされた関数定義を再帰的に処理
 	logger.Debug("関数事前登録: 第二パス - ネストされた関数定義を検索")
 	// すべてのステートメントを走査
 	for i, stmt := range program.Statements {
 		logger.Debug("関数事前登録: ネスト走査 - ステートメント %d (%T)", i+1, stmt)
-		findNestedFunctions(stmt, env, registeredFunctions)
+		findNestedFunctions(stmt, env, registeredFunctions, registeredLiterals)
 	}
 	
 	logger.Debug("関数事前登録: 完了 - すべての関数が登録されました")
@@ -45,7 +47,7 @@ func PreregisterFunctions(program *ast.Program, env *object.Environment) {
 }
 
 // registerFunctionsInStatement はステートメント内の関数定義を処理して登録する
-func registerFunctionsInStatement(stmt ast.Statement, env *object.Environment, registered map[string]bool) {
+func registerFunctionsInStatement(stmt ast.Statement, env *object.Environment, registered map[string]bool, done map[*ast.FunctionLiteral]bool) {
 	if stmt == nil {
 		return
 	}
@@ -61,7 +63,7 @@ func registerFunctionsInStatement(stmt ast.Statement, env *object.Environment, r
 		
 		// 式文の中身が関数リテラルの場合
 		if fn, ok := s.Expression.(*ast.FunctionLiteral); ok {
-			registerFunction(fn, env, registered)
+			registerFunction(fn, env, registered, done)
 		}
 		
 	case *ast.AssignStatement:
@@ -74,16 +76,17 @@ func registerFunctionsInStatement(stmt ast.Statement, env *object.Environment, r
 					logger.Debug("関数事前登録: 代入文から関数名 '%s' を設定しました", ident.Value)
 				}
 			}
-			registerFunction(fn, env, registered)
+			registerFunction(fn, env, registered, done)
 		}
 	}
 }
 
 // registerFunction は関数リテラルを環境に登録する
-func registerFunction(fn *ast.FunctionLiteral, env *object.Environment, registered map[string]bool) {
-	if fn == nil || fn.Name == nil || fn.Name.Value == "" {
+func registerFunction(fn *ast.FunctionLiteral, env *object.Environment, registered map[string]bool, done map[*ast.FunctionLiteral]bool) {
+	if fn == nil || fn.Name == nil || fn.Name.Value == "" || done[fn] {
 		return
 	}
+	done[fn] = true
 	
 	// 関数名を取得
 	funcName := fn.Name.Value
@@ -155,85 +158,30 @@ func createAndRegisterFunction(fn *ast.FunctionLiteral, name string, env *object
 	env.Set(name, function)
 }
 
-// findNestedFunctions はステートメント内にネストされた関数定義を再帰的に検索する
-func findNestedFunctions(node interface{}, env *object.Environment, registered map[string]bool) {
-	if node == nil {
-		return
-	}
-	
-	switch n := node.(type) {
-	case *ast.Program:
-		// プログラム内のすべてのステートメントを処理
-		for _, stmt := range n.Statements {
-			findNestedFunctions(stmt, env, registered)
-		}
-		
-	case *ast.BlockStatement:
-		// ブロック内のすべてのステートメントを処理
-		for _, stmt := range n.Statements {
-			findNestedFunctions(stmt, env, registered)
-		}
-		
-	case *ast.ExpressionStatement:
-		// 式文の式を処理
-		findNestedFunctions(n.Expression, env, registered)
-		
-	case *ast.AssignStatement:
-		// 代入文の左辺と右辺を処理
-		findNestedFunctions(n.Left, env, registered)
-		findNestedFunctions(n.Value, env, registered)
-		
-		// 右辺が関数リテラルの場合
-		if fn, ok := n.Value.(*ast.FunctionLiteral); ok {
-			// 関数名が未設定の場合、左辺の識別子を関数名として使用
-			if fn.Name == nil {
+// findNestedFunctions はステートメント内にネストされた関数定義を検索して登録する
+// ブロック式・範囲式・配列・case 文など、どの位置に書かれた関数定義も見つける
+func findNestedFunctions(node ast.Node, env *object.Environment, registered map[string]bool, done map[*ast.FunctionLiteral]bool) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStatement:
+			// 右辺が関数リテラルの場合、子を走査する前に左辺の識別子を関数名として設定しておく
+			if fn, ok := n.Value.(*ast.FunctionLiteral); ok && fn.Name == nil {
 				if ident, ok := n.Left.(*ast.Identifier); ok {
 					fn.Name = ident
 					logger.Debug("関数事前登録(ネスト): 代入文から関数名 '%s' を設定しました", ident.Value)
 				}
 			}
-			registerFunction(fn, env, registered)
-		}
-		
-	case *ast.FunctionLiteral:
-		// 関数リテラルを登録
-		if n.Name != nil && n.Name.Value != "" {
-			logger.Debug("関数事前登録(ネスト): 関数リテラル '%s' を発見", n.Name.Value)
-			registerFunction(n, env, registered)
-		}
-		
-		// 関数本体内もネストされた関数を検索
-		if n.Body != nil {
-			findNestedFunctions(n.Body, env, registered)
-		}
-		
-	case *ast.PrefixExpression:
-		// 前置式の右辺を処理
-		findNestedFunctions(n.Right, env, registered)
-		
-	case *ast.InfixExpression:
-		// 中置式の左辺と右辺を処理
-		findNestedFunctions(n.Left, env, registered)
-		findNestedFunctions(n.Right, env, registered)
-		
-	// ifを使ったケースを削除または修正
-		
-	case *ast.CallExpression:
-		// 関数式と引数を処理
-		findNestedFunctions(n.Function, env, registered)
-		for _, arg := range n.Arguments {
-			findNestedFunctions(arg, env, registered)
-		}
-		
-	case *ast.IndexExpression:
-		// 配列とインデックス式を処理
-		findNestedFunctions(n.Left, env, registered)
-		findNestedFunctions(n.Index, env, registered)
-		
-	case *ast.ArrayLiteral:
-		// 配列の各要素を処理
-		for _, elem := range n.Elements {
-			findNestedFunctions(elem, env, registered)
+
+		case *ast.FunctionLiteral:
+			if n.Name != nil && n.Name.Value != "" {
+				logger.Debug("関数事前登録(ネスト): 関数リテラル '%s' を発見", n.Name.Value)
+				registerFunction(n, env, registered, done)
+			}
+
+		case *ast.ClassLiteral:
+			// メソッドはクラスに属するため、関数として登録しない
+			return false
 		}
-	}
+		return true
+	})
 }
@@ -0,0 +1,47 @@
+package evaluator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/uncode/lexer"
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+	"github.com/uncode/parser"
+)
+
+// TestPreregisterNestedFunctions はブロック式や case 文の中で定義した関数も事前登録されることをテストする
+func TestPreregisterNestedFunctions(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	input := strings.Join([]string{
+		"def outer() {",
+		"  case 🍕 > 0: {",
+		"    def helper() { 🍕 * 2 >> 💩; }",
+		"    🍕 |> helper >> 💩;",
+		"  }",
+		"}",
+		"3 |> { def viaBlock() { 🍕 >> 💩; } };",
+		"[def inArray() { 🍕 >> 💩; }] >> fs;",
+		"def sign() if 🍕 > 0 { \"plus\" >> 💩; }",
+	}, "\n")
+	tokens, _ := lexer.NewLexer(input).Tokenize()
+	program, err := parser.NewParser(tokens).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %s", err)
+	}
+	env := object.NewEnvironment()
+	PreregisterFunctions(program, env)
+
+	for _, name := range []string{"outer", "helper", "viaBlock", "inArray", "sign", "sign#0"} {
+		if obj, ok := env.Get(name); !ok {
+			t.Errorf("関数 %s が登録されていません", name)
+		} else if _, ok := obj.(*object.Function); !ok {
+			t.Errorf("%s が関数ではありません: %T", name, obj)
+		}
+	}
+	// トップレベルの条件付き関数を第二パスで二重に登録しない
+	if _, ok := env.Get("sign#1"); ok {
+		t.Errorf("条件付き関数 sign が二重に登録されています")
+	}
+}
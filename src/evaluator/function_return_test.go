@@ -16,7 +16,7 @@ func TestFunctionReturnValues(t *testing.T) {
 		{
 			name: "直接の戻り値 (💩による戻り値)",
 			input: `
-				func add(x, y) {
+				def add(x, y) {
 					x + y >> 💩
 				}
 				add(5, 3)
@@ -26,7 +26,7 @@ func TestFunctionReturnValues(t *testing.T) {
 		{
 			name: "暗黙の戻り値 (最後の式の結果)",
 			input: `
-				func multiply(x, y) {
+				def multiply(x, y) {
 					x * y
 				}
 				multiply(4, 5)
@@ -36,20 +36,20 @@ func TestFunctionReturnValues(t *testing.T) {
 		{
 			name: "高階関数と戻り値 (mapに渡す関数)",
 			input: `
-				func double(x) {
-					x * 2
-				}
-				[1, 2, 3].map(double)
+				def double() {
+					🍕 * 2 >> 💩
+				};
+				[1, 2, 3] +> double
 			`,
 			expected: []int64{2, 4, 6},
 		},
 		{
 			name: "複数の関数呼び出しと戻り値",
 			input: `
-				func add(x, y) {
+				def add(x, y) {
 					x + y >> 💩
 				}
-				func multiply(x, y) {
+				def multiply(x, y) {
 					x * y >> 💩
 				}
 				add(multiply(2, 3), multiply(4, 5))
@@ -59,11 +59,11 @@ func TestFunctionReturnValues(t *testing.T) {
 		{
 			name: "関数のパイプライン適用と戻り値",
 			input: `
-				func double(x) {
-					x * 2 >> 💩
+				def double() {
+					🍕 * 2 >> 💩
 				}
-				func add5(x) {
-					x + 5 >> 💩
+				def add5() {
+					🍕 + 5 >> 💩
 				}
 				10 |> double |> add5
 			`,
@@ -72,11 +72,12 @@ func TestFunctionReturnValues(t *testing.T) {
 		{
 			name: "条件分岐がある関数",
 			input: `
-				func abs(x) {
-					if x < 0 {
-						-x >> 💩
-					} else {
-						x >> 💩
+				def abs() {
+					case 🍕 < 0: {
+						-🍕 >> 💩
+					}
+					default: {
+						🍕 >> 💩
 					}
 				}
 				abs(-10)
@@ -86,18 +87,15 @@ func TestFunctionReturnValues(t *testing.T) {
 		{
 			name: "入れ子のブロック文",
 			input: `
-				func complexFunc(x) {
-					let result = 0
-					if x > 0 {
-						{
-							let temp = x * 2
-							result = temp + 1
-						}
-					} else {
-						{
-							let temp = -x
-							result = temp * 2
-						}
+				def complexFunc() {
+					0 >> result;
+					case 🍕 > 0: {
+						🍕 * 2 >> temp;
+						temp + 1 >> result;
+					}
+					default: {
+						-🍕 >> temp;
+						temp * 2 >> result;
 					}
 					result >> 💩
 				}
@@ -0,0 +1,62 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+)
+
+// hashSource はテスト用のハッシュを作成するソースコード
+const hashSource = `"{\"z\": 3, \"a\": 1, \"m\": 2}" |> json_parse >> h; `
+
+// TestHashIteration はハッシュの走査がキーの挿入順で行われることをテストする
+func TestHashIteration(t *testing.T) {
+	// テスト時はデバッグログを無効化
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"h;", "{z: 3, a: 1, m: 2}"},
+		{"h |> keys;", "[z, a, m]"},
+		{"h |> values;", "[3, 1, 2]"},
+		{"h |> entries;", "[[z, 3], [a, 1], [m, 2]]"},
+		// map はキーを保ったまま値を変換する
+		{"h +> add 10;", "{z: 13, a: 11, m: 12}"},
+		// filter は条件を満たす値のペアだけを残す
+		{"h ?> gt 1;", "{z: 3, m: 2}"},
+		{"def big() { 🍕 > 1 >> 💩 }; h ?> big;", "{z: 3, m: 2}"},
+	}
+
+	for _, tt := range tests {
+		// 何度評価しても同じ順序になる
+		for i := 0; i < 5; i++ {
+			evaluated := testEval(hashSource + tt.input)
+			if evaluated == nil || evaluated.Inspect() != tt.expected {
+				t.Errorf("input %q: expected=%s, got=%v", tt.input, tt.expected, evaluated)
+				break
+			}
+		}
+	}
+
+	testIntegerObject(t, testEval(hashSource+"h /> add;"), 6)
+	testIntegerObject(t, testEval(hashSource+"h |> length;"), 3)
+	testIntegerObject(t, testEval(hashSource+`h["a"];`), 1)
+	testNullObject(t, testEval(hashSource+`h["x"];`))
+}
+
+// TestHashBuiltinErrors はハッシュ関数に不正な引数を渡した場合のエラーをテストする
+func TestHashBuiltinErrors(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	evaluated := testEval("[1, 2] |> keys;")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "keys関数の引数はハッシュである必要があります: ARRAY" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
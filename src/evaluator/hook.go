@@ -0,0 +1,39 @@
+package evaluator
+
+import (
+	"github.com/uncode/ast"
+	"github.com/uncode/object"
+)
+
+// Hook は評価の進行を外部から観察するためのインターフェース
+// デバッガなどが SetHook で登録する。登録されていない場合のコストは nil の確認だけになる
+type Hook interface {
+	// BeforeEval は Eval がノードを評価する直前に呼び出される
+	BeforeEval(node ast.Node, env *object.Environment)
+	// BeforeStage はパイプラインの段 (|> +> ?> />) で、左辺の値を右辺に渡す直前に呼び出される
+	// 左辺の段から順に呼び出されるため、実行の順序どおりに段を追うことができる
+	BeforeStage(node *ast.InfixExpression, input object.Object, env *object.Environment)
+	// AfterStage はパイプラインの段の評価が終わった直後に、その結果とともに呼び出される
+	// 実行が打ち切られた場合は result が nil になる
+	AfterStage(node *ast.InfixExpression, result object.Object)
+	// EnterFunction はユーザー定義関数の本体を評価する直前に呼び出される
+	EnterFunction(fn *object.Function, env *object.Environment)
+	// ExitFunction はユーザー定義関数の本体の評価が終わった直後に、その結果とともに呼び出される
+	ExitFunction(fn *object.Function, result object.Object)
+	// SelectCase は条件に一致した case 文 (*ast.CaseStatement) か default 文 (*ast.DefaultCaseStatement) の
+	// ブロックを評価する直前に呼び出される
+	SelectCase(arm ast.Statement, env *object.Environment)
+}
+
+// hook は登録されているフック（登録されていなければ nil）
+var hook Hook
+
+// SetHook は評価の進行を観察するフックを登録する。nil を指定すると解除する
+func SetHook(h Hook) {
+	hook = h
+}
+
+// FunctionName は関数の名前を返す。名前のない関数は "<無名関数>" になる
+func FunctionName(fn *object.Function) string {
+	return functionName(fn)
+}
@@ -32,6 +32,12 @@ func evalInfixExpressionWithNode(node *ast.InfixExpression, env *object.Environm
 		}
 		// filter関数の処理を実行
 		return evalFilterOperation(node, env)
+	case "/>", "fold": // fold演算子
+		if logger.IsLevelEnabled(mapFilterDebugLevel) {
+			logger.Log(mapFilterDebugLevel, "fold パイプ演算子 (%s) を検出しました", node.Operator)
+		}
+		// fold関数の処理を実行
+		return evalFoldOperation(node, env)
 	}
 
 	// ピザリテラルが含まれる場合のチェック
@@ -41,7 +47,9 @@ func evalInfixExpressionWithNode(node *ast.InfixExpression, env *object.Environm
 		if currentFunction != nil {
 			if pizzaVal := currentFunction.GetPizzaValue(); pizzaVal != nil {
 				// 🍕の値を左辺として使用
-				logger.Debug("中置式の左辺に🍕を使用: %s", pizzaVal.Inspect())
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("中置式の左辺に🍕を使用: %s", pizzaVal.Inspect())
+				}
 				left := pizzaVal
 
 				// 右辺を評価
@@ -51,7 +59,7 @@ func evalInfixExpressionWithNode(node *ast.InfixExpression, env *object.Environm
 				}
 
 				// 演算子を適用
-				return evalInfixExpression(node.Operator, left, right)
+				return evalInfixExpressionAt(node, left, right)
 			}
 		}
 
@@ -66,7 +74,7 @@ func evalInfixExpressionWithNode(node *ast.InfixExpression, env *object.Environm
 			return right
 		}
 
-		return evalInfixExpression(node.Operator, left, right)
+		return evalInfixExpressionAt(node, left, right)
 	}
 
 	// 右辺がピザリテラルの場合
@@ -81,17 +89,19 @@ func evalInfixExpressionWithNode(node *ast.InfixExpression, env *object.Environm
 		if currentFunction != nil {
 			if pizzaVal := currentFunction.GetPizzaValue(); pizzaVal != nil {
 				// 🍕の値を右辺として使用
-				logger.Debug("中置式の右辺に🍕を使用: %s", pizzaVal.Inspect())
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("中置式の右辺に🍕を使用: %s", pizzaVal.Inspect())
+				}
 				right := pizzaVal
 
 				// 演算子を適用
-				return evalInfixExpression(node.Operator, left, right)
+				return evalInfixExpressionAt(node, left, right)
 			}
 		}
 
 		// 環境から🍕を取得（バックアップ）
 		if right, ok := env.Get("🍕"); ok {
-			return evalInfixExpression(node.Operator, left, right)
+			return evalInfixExpressionAt(node, left, right)
 		}
 
 		return createError("🍕が定義されていません")
@@ -0,0 +1,46 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/uncode/ast"
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+)
+
+// evalInterpolatedString は補間文字列を評価する
+// 埋め込まれた式の値は to_string と同じ規則で文字列に変換される
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+
+	for _, part := range node.Parts {
+		if str, ok := part.(*ast.StringLiteral); ok {
+			out.WriteString(str.Value)
+			continue
+		}
+
+		value := Eval(part, env)
+		if value.Type() == object.ERROR_OBJ {
+			return value
+		}
+
+		// 💩などが戻り値オブジェクトを返した場合は中身を使う
+		if rv, ok := value.(*object.ReturnValue); ok {
+			if rv.Value == nil {
+				return createError("文字列補間 ${%s} の値がありません", part.String())
+			}
+			value = rv.Value
+		}
+		// ストリームは to_string に渡したときと同じく配列に変換する（無限ストリームはエラー）
+		if value = materialize(value); isError(value) {
+			return value
+		}
+
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("文字列補間: ${%s} => %s", part.String(), value.Inspect())
+		}
+		out.WriteString(stringValueOf(value))
+	}
+
+	return &object.String{Value: out.String()}
+}
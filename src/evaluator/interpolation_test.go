@@ -0,0 +1,48 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+)
+
+// TestInterpolatedStrings は文字列補間の評価をテストする
+func TestInterpolatedStrings(t *testing.T) {
+	// テスト時はデバッグログを無効化
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`5 >> n; "total: ${n * 2}!";`, "total: 10!"},
+		// to_string と同じ規則で変換される
+		{`"${[1, 2]} ${true} ${"文字"}";`, "[1, 2] true 文字"},
+		// エスケープと式の中の文字列
+		{`5 >> n; "\${n} \"q\" ${"a" |> add "}"}";`, `${n} "q" a}`},
+		// 関数の中では🍕を参照できる
+		{`def show() { "値は${🍕 * 2}です" >> 💩 }; 21 |> show;`, "値は42です"},
+		// fold の中では💩（累積値）を参照できる
+		{`def concat() { "${💩}${🍕}" >> 💩 }; ["a", "b", "c"] /> concat;`, "abc"},
+		// パイプラインの引数として使える
+		{`3 >> n; "a" |> add "${n}";`, "a3"},
+		// ストリームは to_string と同じく配列として表示される
+		{`"arr ${[1..3]}";`, "arr [1, 2, 3]"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
+
+	// 段を重ねたストリームも to_string の結果と一致する
+	interpolated := testEval(`"${[1..3] +> mul 2}";`).Inspect()
+	if converted := testEval(`[1..3] +> mul 2 |> to_string;`).Inspect(); interpolated != converted {
+		t.Errorf("interpolation should match to_string. got=%s, to_string=%s", interpolated, converted)
+	}
+
+	// 無限ストリームは文字列にできない
+	if _, ok := testEval(`"${[1..]}";`).(*object.Error); !ok {
+		t.Errorf("interpolating an infinite stream should be an error")
+	}
+}
@@ -0,0 +1,60 @@
+package evaluator
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// programOutput はプログラムの出力（print など）の書き込み先
+var programOutput io.Writer = os.Stdout
+
+// programErrorOutput はプログラムのエラー出力（eprint）の書き込み先
+var programErrorOutput io.Writer = os.Stderr
+
+// SetOutput はプログラムの出力先を設定する
+// -output による tee や --quiet、テストや組み込み先での出力の取得に使用する
+func SetOutput(w io.Writer) {
+	programOutput = w
+}
+
+// Output は現在のプログラムの出力先を返す
+func Output() io.Writer {
+	return programOutput
+}
+
+// SetErrorOutput はプログラムのエラー出力先を設定する
+func SetErrorOutput(w io.Writer) {
+	programErrorOutput = w
+}
+
+// writeOutputLine はプログラムの出力に1行書き込み、すぐにフラッシュする
+// ストリームを1要素ずつ出力する場合でも、出力が溜まらずに順次書き出される
+func writeOutputLine(s string) {
+	writeLine(programOutput, s)
+}
+
+// writeErrorLine はプログラムのエラー出力に1行書き込み、すぐにフラッシュする
+func writeErrorLine(s string) {
+	writeLine(programErrorOutput, s)
+}
+
+// writeLine は w に1行書き込み、w がバッファを持っていればフラッシュする
+func writeLine(w io.Writer, s string) {
+	fmt.Fprintln(w, s)
+	flushWriter(w)
+}
+
+// writePrompt は w に改行なしで書き込み、w がバッファを持っていればフラッシュする
+// 入力を待つ前にプロンプトが表示されるようにする
+func writePrompt(w io.Writer, s string) {
+	fmt.Fprint(w, s)
+	flushWriter(w)
+}
+
+// flushWriter は w がバッファを持っていればフラッシュする
+func flushWriter(w io.Writer) {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+}
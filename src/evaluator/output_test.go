@@ -0,0 +1,47 @@
+package evaluator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/uncode/logger"
+)
+
+// captureOutput はテスト中のプログラムの出力を記録するバッファを返す
+func captureOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := programOutput
+	SetOutput(&buf)
+	t.Cleanup(func() { SetOutput(prev) })
+	return &buf
+}
+
+// captureErrorOutput はテスト中のプログラムのエラー出力を記録するバッファを返す
+func captureErrorOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := programErrorOutput
+	SetErrorOutput(&buf)
+	t.Cleanup(func() { SetErrorOutput(prev) })
+	return &buf
+}
+
+// TestOutputWriters は print と eprint がそれぞれ設定された書き込み先に出力することをテストする
+func TestOutputWriters(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+	out := captureOutput(t)
+	errOut := captureErrorOutput(t)
+
+	// print も eprint も第一引数を返すのでパイプラインを続けられる
+	testIntegerObject(t, testEval(`1 |> print |> add 1 |> eprint;`), 2)
+	testStringObject(t, testEval(`"こんにちは" |> print;`), "こんにちは")
+	testEval(`[1, 2] |> eprint;`)
+
+	if got := out.String(); got != "1\nこんにちは\n" {
+		t.Errorf("wrong output. got=%q", got)
+	}
+	if got := errOut.String(); got != "2\n[1, 2]\n" {
+		t.Errorf("wrong error output. got=%q", got)
+	}
+}
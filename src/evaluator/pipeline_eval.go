@@ -47,41 +47,81 @@ func maybeConvertToInteger(obj object.Object) object.Object {
 	return obj
 }
 
+// evalPipelineSource はパイプラインの左辺を評価する
+// stdin_lines のように引数を取らない組み込み関数の名前が左辺に書かれた場合は、
+// 関数そのものではなく呼び出した結果をパイプラインに流す
+func evalPipelineSource(node ast.Expression, env *object.Environment) object.Object {
+	left := Eval(node, env)
+	if _, ok := node.(*ast.Identifier); !ok {
+		return left
+	}
+	if builtin, ok := left.(*object.Builtin); ok && builtin.ParamTypes != nil && len(builtin.ParamTypes) == 0 {
+		return callBuiltin(builtin)
+	}
+	return left
+}
+
 // evalPipeline は|>演算子のパイプライン処理を評価する
-func evalPipeline(node *ast.InfixExpression, env *object.Environment) object.Object {
+func evalPipeline(node *ast.InfixExpression, env *object.Environment) (stageResult object.Object) {
 	logger.Debug("パイプライン演算子を検出しました")
 	
 	// 現在の🍕変数の値を保存（もし存在すれば）
 	originalPizza, hasPizza := env.Get("🍕")
-	if hasPizza {
-		logger.Debug("元の🍕変数の値を保存: %s", originalPizza.Inspect())
+	if hasPizza && logger.Enabled(logger.LevelDebug) {
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("元の🍕変数の値を保存: %s", originalPizza.Inspect())
+		}
 	}
 	
 	// |>演算子の場合、左辺の結果を右辺の関数に渡す
 	// 左辺には別のパイプライン式が含まれている可能性があります
-	left := Eval(node.Left, env)
+	left := evalPipelineSource(node.Left, env)
 	if left.Type() == object.ERROR_OBJ {
 		return left
 	}
 
-	logger.Debug("パイプラインの左辺評価結果: タイプ=%s, 値=%s", left.Type(), left.Inspect())
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("パイプラインの左辺評価結果: タイプ=%s, 値=%s", left.Type(), left.Inspect())
+	}
+	if hook != nil {
+		hook.BeforeStage(node, left, env)
+		defer func() { hook.AfterStage(node, stageResult) }()
+	}
+	if profiler != nil {
+		profiler.Enter(stageFrame(node), left)
+		defer func() { profiler.Exit(stageResult) }()
+	}
 
 	// パイプライン処理のための一時環境を作成
 	tempEnv := object.NewEnclosedEnvironment(env)
 	
 	// 明示的に🍕変数に左辺の値を設定（条件式の評価で必要）
-	logger.Debug("パイプラインで🍕に値を明示的に設定します: %s (%s)\n", left.Inspect(), left.Type())
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("パイプラインで🍕に値を明示的に設定します: %s (%s)\n", left.Inspect(), left.Type())
+	}
 	// nullを無視（printの結果などがnullの場合に問題が発生）
 	if left.Type() != object.NULL_OBJ {
-		// 文字列から整数への変換を試みる
-		convertedValue := maybeConvertToInteger(left)
+		// 文字列から整数への変換を試みる（厳密モードでは変換しない）
+		convertedValue := left
+		if !strictMode {
+			convertedValue = maybeConvertToInteger(left)
+			// 変換した整数が演算などで使われたときだけ警告する
+			if integer, ok := convertedValue.(*object.Integer); ok && convertedValue != left {
+				coercedPizza[integer] = coercion{source: left, line: node.Token.Line}
+				defer delete(coercedPizza, integer)
+			}
+		}
 		tempEnv.Set("🍕", convertedValue)
 		
 		// パイプラインの入力の型と内容を詳細に記録
 		if convertedValue.Type() == object.STRING_OBJ {
-			logger.Debug("パイプライン入力は文字列型です: %s", convertedValue.Inspect())
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("パイプライン入力は文字列型です: %s", convertedValue.Inspect())
+			}
 		} else if convertedValue.Type() == object.INTEGER_OBJ {
-			logger.Debug("パイプライン入力は整数型です: %d", convertedValue.(*object.Integer).Value)
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("パイプライン入力は整数型です: %d", convertedValue.(*object.Integer).Value)
+			}
 		}
 	} else {
 		logger.Debug("左辺値がnullのため、🍕の設定をスキップします")
@@ -96,8 +136,10 @@ func evalPipeline(node *ast.InfixExpression, env *object.Environment) object.Obj
 	} else {
 		// 右辺が識別子の場合（関数名のみ）
 		if ident, ok := node.Right.(*ast.Identifier); ok {
-			logger.Debug("識別子としてのパイプライン先: %s\n", ident.Value)
-			logger.Debug("パイプラインから 関数を呼び出します (関数名: %s)\n", ident.Value)
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("識別子としてのパイプライン先: %s\n", ident.Value)
+				logger.Debug("パイプラインから 関数を呼び出します (関数名: %s)\n", ident.Value)
+			}
 
 			// 環境変数 🍕 を設定して関数呼び出しへ処理を委譲
 			// ここで左辺の値を唯一の引数として渡す
@@ -105,15 +147,21 @@ func evalPipeline(node *ast.InfixExpression, env *object.Environment) object.Obj
 
 			// 組み込み関数を直接取得して呼び出す (特にmapやfilterの場合)
 			if builtin, ok := Builtins[ident.Value]; ok {
-				logger.Debug("ビルトイン関数 '%s' を実行します\n", ident.Value)
-				result = builtin.Fn(args...)
-				logger.Debug("ビルトイン関数 '%s' の実行結果: タイプ=%s, 値=%s\n",
-					ident.Value, result.Type(), result.Inspect())
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("ビルトイン関数 '%s' を実行します\n", ident.Value)
+				}
+				result = callBuiltin(builtin, args...)
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("ビルトイン関数 '%s' の実行結果: タイプ=%s, 値=%s\n",
+						ident.Value, result.Type(), result.Inspect())
+				}
 			} else {
 				// 名前付き関数を適用する
 				result = applyNamedFunction(tempEnv, ident.Value, args)
-				logger.Debug("パイプライン: 関数 '%s' の実行結果: タイプ=%s, 値=%s\n",
-					ident.Value, result.Type(), result.Inspect())
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("パイプライン: 関数 '%s' の実行結果: タイプ=%s, 値=%s\n",
+						ident.Value, result.Type(), result.Inspect())
+				}
 			}
 		} else {
 			// その他の場合は処理できない
@@ -123,11 +171,15 @@ func evalPipeline(node *ast.InfixExpression, env *object.Environment) object.Obj
 
 	// 元の🍕変数を環境に戻す（必要に応じて）
 	if hasPizza {
-		logger.Debug("元の🍕変数を復元します: %s", originalPizza.Inspect())
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("元の🍕変数を復元します: %s", originalPizza.Inspect())
+		}
 		env.Set("🍕", originalPizza)
 	}
 
-	logger.Debug("パイプラインの最終結果: タイプ=%s, 値=%s", result.Type(), result.Inspect())
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("パイプラインの最終結果: タイプ=%s, 値=%s", result.Type(), result.Inspect())
+	}
 	return result
 }
 
@@ -140,7 +192,9 @@ func evalPipelineWithCallExpression(left object.Object, callExpr *ast.CallExpres
 	if ident, ok := callExpr.Function.(*ast.Identifier); ok {
 		// 関数名を取得
 		funcName = ident.Value
-		logger.Debug("関数呼び出し式の関数名: %s\n", funcName)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("関数呼び出し式の関数名: %s\n", funcName)
+		}
 	} else {
 		logger.Debug("関数呼び出し式が識別子ではありません: %T\n", callExpr.Function)
 	}
@@ -154,36 +208,54 @@ func evalPipelineWithCallExpression(left object.Object, callExpr *ast.CallExpres
 		}
 	}
 
+	return applyPipelineCall(funcName, left, args, env)
+}
+
+// applyPipelineCall は評価済みの引数の前に左辺の値を加えて、関数呼び出し式の関数を呼び出す
+func applyPipelineCall(funcName string, left object.Object, args []object.Object, env *object.Environment) object.Object {
 	// デバッグ出力
-	logger.Debug("パイプラインの関数名: %s, 左辺値: %s, 引数: %v\n",
-		funcName, left.Inspect(), args)
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("パイプラインの関数名: %s, 左辺値: %s, 引数: %v\n",
+			funcName, left.Inspect(), args)
+	}
 
 	// 通常の関数呼び出しの場合（例: 左辺 |> func arg1 arg2）
 	// 全引数リストを作成（第一引数は左辺の値、第二引数以降は関数呼び出しの引数）
 	logger.Debug("通常の関数呼び出し: 引数リストを作成します\n")
-	allArgs := []object.Object{left}
+	allArgs := make([]object.Object, 0, len(args)+1)
+	allArgs = append(allArgs, left)
 	allArgs = append(allArgs, args...)
 	args = allArgs
 
 	// デバッグ: 最終的な引数リストを表示
-	logger.Debug("関数呼び出しに渡す最終引数リスト: %d 個\n", len(args))
-	for i, arg := range args {
-		logger.Debug("  引数 %d: タイプ=%s, 値=%s\n", i, arg.Type(), arg.Inspect())
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("関数呼び出しに渡す最終引数リスト: %d 個\n", len(args))
+	}
+	if logger.Enabled(logger.LevelDebug) {
+		for i, arg := range args {
+			logger.Debug("  引数 %d: タイプ=%s, 値=%s\n", i, arg.Type(), arg.Inspect())
+		}
 	}
 
 	var result object.Object
 
 	// 組み込み関数を直接取得して呼び出す
 	if builtin, ok := Builtins[funcName]; ok {
-		logger.Debug("ビルトイン関数 '%s' を実行: 全引数 %d 個\n", funcName, len(args))
-		result = builtin.Fn(args...)
-		logger.Debug("ビルトイン関数 '%s' の結果: タイプ=%s, 値=%s\n", 
-			funcName, result.Type(), result.Inspect())
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("ビルトイン関数 '%s' を実行: 全引数 %d 個\n", funcName, len(args))
+		}
+		result = callBuiltin(builtin, args...)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("ビルトイン関数 '%s' の結果: タイプ=%s, 値=%s\n", 
+				funcName, result.Type(), result.Inspect())
+		}
 	} else {
 		// 名前付き関数（ユーザー定義関数）を適用する
 		result = applyNamedFunction(env, funcName, args)
-		logger.Debug("関数 '%s' の適用結果: タイプ=%s, 値=%s\n", 
-			funcName, result.Type(), result.Inspect())
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("関数 '%s' の適用結果: タイプ=%s, 値=%s\n", 
+				funcName, result.Type(), result.Inspect())
+		}
 	}
 
 	return result
@@ -52,117 +52,75 @@ func LogArgumentBinding(funcName string, paramName string, value object.Object)
 
 // evalMapOperation はmap演算子(+>)を処理する
 // 単一値と配列の両方に対応するように修正
-func evalMapOperation(node *ast.InfixExpression, env *object.Environment) object.Object {
+func evalMapOperation(node *ast.InfixExpression, env *object.Environment) (stageResult object.Object) {
 	logger.Debug("mapパイプライン演算子(+>)の処理を開始")
 
 	// 左辺値の評価
-	left := Eval(node.Left, env)
+	left := evalPipelineSource(node.Left, env)
 	if left == nil {
 		return createError("mapオペレーション: 左辺の評価結果がnilです")
 	}
 	if left.Type() == object.ERROR_OBJ {
 		return left
 	}
+	if hook != nil {
+		hook.BeforeStage(node, left, env)
+		defer func() { hook.AfterStage(node, stageResult) }()
+	}
+	if profiler != nil {
+		profiler.Enter(stageFrame(node), left)
+		defer func() { profiler.Exit(stageResult) }()
+	}
+
+	// 右辺から各要素に適用する関数を作成
+	apply, errObj := pipelineElementFunction(node.Right, env, "map")
+	if errObj != nil {
+		return errObj
+	}
+
+	// ストリームの場合は要素を計算せずに段を重ねる
+	if stream, ok := left.(*object.Stream); ok {
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("+> 左辺の評価結果: ストリーム %s に map の段を追加します", left.Inspect())
+		}
+		return mapStream(stream, profileStreamStage(node, apply))
+	}
 	
 	// 配列か単一の値かを確認し、適切な処理を行う
 	var elements []object.Object
 	var isSingleValue bool
 	
+	hashObj, isHash := left.(*object.Hash)
 	if arrayObj, ok := left.(*object.Array); ok {
 		// 配列の場合はその要素を使用
 		elements = arrayObj.Elements
 		isSingleValue = false
-		logger.Debug("+> 左辺の評価結果: 配列 %s (タイプ: %s)", left.Inspect(), left.Type())
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("+> 左辺の評価結果: 配列 %s (タイプ: %s)", left.Inspect(), left.Type())
+		}
+	} else if isHash {
+		// ハッシュの場合は挿入順の値を使用し、結果は同じキーのハッシュにする
+		elements = hashValues(hashObj)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("+> 左辺の評価結果: ハッシュ %s (タイプ: %s)", left.Inspect(), left.Type())
+		}
 	} else {
 		// 単一の値の場合は要素1つの配列として扱う
 		elements = []object.Object{left}
 		isSingleValue = true
-		logger.Debug("+> 左辺の評価結果: 単一値 %s (タイプ: %s) を要素1つの配列として扱います", left.Inspect(), left.Type())
-	}
-
-	// 右辺値の評価（関数または関数呼び出し）
-	var funcName string
-	var funcArgs []object.Object
-
-	switch right := node.Right.(type) {
-	case *ast.Identifier:
-		// 識別子の場合、関数名として扱う
-		logger.Debug("右辺が識別子: %s", right.Value)
-		funcName = right.Value
-	case *ast.CallExpression:
-		logger.Debug("右辺が関数呼び出し式")
-		
-		// 関数名を取得
-		if ident, ok := right.Function.(*ast.Identifier); ok {
-			funcName = ident.Value
-			logger.Debug("関数名: %s", funcName)
-			
-			// 追加引数を評価
-			funcArgs = evalExpressions(right.Arguments, env)
-			if len(funcArgs) > 0 && funcArgs[0] != nil && funcArgs[0].Type() == object.ERROR_OBJ {
-				return funcArgs[0]
-			}
-		} else {
-			return createError("関数呼び出し式の関数部分が識別子ではありません: %T", right.Function)
-		}
-		
-		// CallExpressionの場合、各要素に対してevalPipelineWithCallExpressionを適用
-		resultElements := make([]object.Object, 0, len(elements))
-		for _, element := range elements {
-			result := evalPipelineWithCallExpression(element, right, env)
-			resultElements = append(resultElements, result)
-		}
-		
-		// 単一値モードの場合は最初の結果だけを返す
-		if isSingleValue && len(resultElements) > 0 {
-			return resultElements[0]
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("+> 左辺の評価結果: 単一値 %s (タイプ: %s) を要素1つの配列として扱います", left.Inspect(), left.Type())
 		}
-		return &object.Array{Elements: resultElements}
-	default:
-		return createError("map演算子の右辺が関数または識別子ではありません: %T", node.Right)
 	}
 
 	// 直接各要素に対して処理を行う
 	resultElements := make([]object.Object, 0, len(elements))
 	
 	for _, elem := range elements {
-		// 一時環境を作成し、🍕に要素をセット
-		tempEnv := object.NewEnclosedEnvironment(env)
-		tempEnv.Set("🍕", elem)
-		
-		// 現在の要素に対して適切な関数を選択・実行
-		// 引数にはelemを含める
-		args := []object.Object{elem}
-		if funcArgs != nil {
-			args = append(args, funcArgs...)
-		}
-		
-		// 関数を取得（環境から検索）
-		functions := env.GetAllFunctionsByName(funcName)
-		if len(functions) == 0 {
-			// 組み込み関数を確認
-			if builtin, ok := Builtins[funcName]; ok {
-				logger.Debug("ビルトイン関数 '%s' をマップ操作で呼び出します", funcName)
-				result := builtin.Fn(args...)
-				if result == nil || result.Type() == object.ERROR_OBJ {
-					return result
-				}
-				resultElements = append(resultElements, result)
-				continue
-			}
-			return createError("関数 '%s' が見つかりません", funcName)
-		}
-		
-		// 関数を適用 (case文サポート)
-		logger.Debug("要素 %s に対して関数 %s を適用", elem.Inspect(), funcName)
-		logCaseDebug("map演算子: case文対応で関数 %s を呼び出します", funcName)
-		result := applyCaseBare(functions[0], args)
-		
+		result := apply(elem)
 		if result == nil || result.Type() == object.ERROR_OBJ {
-			logger.Debug("関数 %s の適用中にエラーが発生: %s", funcName, result.Inspect())
 			return result
 		}
-		
 		resultElements = append(resultElements, result)
 	}
 	
@@ -170,107 +128,164 @@ func evalMapOperation(node *ast.InfixExpression, env *object.Environment) object
 	if isSingleValue && len(resultElements) > 0 {
 		return resultElements[0]
 	}
+	if isHash {
+		return hashWithValues(hashObj, resultElements)
+	}
 	
 	return &object.Array{Elements: resultElements}
 }
 
 // evalFilterOperation はfilter演算子(?>)を処理する
 // 左辺が単一値の場合のサポートも追加
-func evalFilterOperation(node *ast.InfixExpression, env *object.Environment) object.Object {
+func evalFilterOperation(node *ast.InfixExpression, env *object.Environment) (stageResult object.Object) {
 	if logger.IsLevelEnabled(mapFilterDebugLevel) {
 		logger.Debug("filter演算子(?>)の処理を開始")
 	}
 
 	// 左辺値の評価
-	left := Eval(node.Left, env)
+	left := evalPipelineSource(node.Left, env)
 	if left == nil {
 		return createError("filterオペレーション: 左辺の評価結果がnilです")
 	}
 	if left.Type() == object.ERROR_OBJ {
 		return left
 	}
+	if hook != nil {
+		hook.BeforeStage(node, left, env)
+		defer func() { hook.AfterStage(node, stageResult) }()
+	}
+	if profiler != nil {
+		profiler.Enter(stageFrame(node), left)
+		defer func() { profiler.Exit(stageResult) }()
+	}
+
+	// 右辺から各要素を判定する関数を作成
+	apply, errObj := pipelineElementFunction(node.Right, env, "filter")
+	if errObj != nil {
+		return errObj
+	}
+	stream, isStream := left.(*object.Stream)
+	if isStream {
+		apply = profileStreamStage(node, apply)
+	}
+	predicate := func(elem object.Object) (bool, object.Object) {
+		result := apply(elem)
+		if result == nil {
+			return false, nil
+		}
+		if result.Type() == object.ERROR_OBJ {
+			return false, result
+		}
+		// 結果がtruthyな場合のみ結果に含める
+		return isTruthy(result), nil
+	}
+
+	// ストリームの場合は要素を計算せずに段を重ねる
+	if isStream {
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("?> 左辺の評価結果: ストリーム %s に filter の段を追加します", left.Inspect())
+		}
+		return filterStream(stream, predicate)
+	}
 	
 	// 配列か単一の値かを確認し、適切な処理を行う
 	var elements []object.Object
 	var isSingleValue bool
 	
+	hashObj, isHash := left.(*object.Hash)
 	if arrayObj, ok := left.(*object.Array); ok {
 		// 配列の場合はその要素を使用
 		elements = arrayObj.Elements
 		isSingleValue = false
-		logger.Debug("?> 左辺の評価結果: 配列 %s (タイプ: %s)", left.Inspect(), left.Type())
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("?> 左辺の評価結果: 配列 %s (タイプ: %s)", left.Inspect(), left.Type())
+		}
+	} else if isHash {
+		// ハッシュの場合は挿入順の値で判定し、条件を満たすペアを残したハッシュにする
+		elements = hashValues(hashObj)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("?> 左辺の評価結果: ハッシュ %s (タイプ: %s)", left.Inspect(), left.Type())
+		}
 	} else {
 		// 単一の値の場合は要素1つの配列として扱う
 		elements = []object.Object{left}
 		isSingleValue = true
-		logger.Debug("?> 左辺の評価結果: 単一値 %s (タイプ: %s) を要素1つの配列として扱います", left.Inspect(), left.Type())
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("?> 左辺の評価結果: 単一値 %s (タイプ: %s) を要素1つの配列として扱います", left.Inspect(), left.Type())
+		}
 	}
 
-	// 右辺値の評価（関数または関数呼び出し）
+	// 直接配列の各要素に対して処理を行う
+	resultElements := make([]object.Object, 0)
+	kept := make([]int, 0)
+	
+	for i, elem := range elements {
+		keep, err := predicate(elem)
+		if err != nil {
+			return err
+		}
+		if keep {
+			resultElements = append(resultElements, elem)
+			kept = append(kept, i)
+		}
+	}
+	
+	// 単一値モードの場合、結果があれば元の値を、なければnullを返す
+	if isSingleValue {
+		if len(resultElements) > 0 {
+			return left // 元の単一値を返す
+		}
+		return NULL
+	}
+	if isHash {
+		return hashWithPairs(hashObj, kept)
+	}
+	
+	return &object.Array{Elements: resultElements}
+}
+
+// pipelineElementFunction は map / filter 演算子の右辺から、要素1つに適用する関数を作成する
+// 右辺の関数呼び出しの引数はここで一度だけ評価され、各要素への適用で使い回される
+func pipelineElementFunction(right ast.Expression, env *object.Environment, opName string) (func(object.Object) object.Object, object.Object) {
 	var funcName string
 	var funcArgs []object.Object
 
-	switch right := node.Right.(type) {
+	switch right := right.(type) {
 	case *ast.Identifier:
 		// 識別子の場合、関数名として扱う
-		if logger.IsLevelEnabled(mapFilterDebugLevel) {
+		if logger.Enabled(logger.LevelDebug) {
 			logger.Debug("右辺が識別子: %s", right.Value)
 		}
 		funcName = right.Value
 	case *ast.CallExpression:
-		// 関数呼び出しの場合
-		if logger.IsLevelEnabled(mapFilterDebugLevel) {
-			logger.Debug("右辺が関数呼び出し式")
-		}
-		if ident, ok := right.Function.(*ast.Identifier); ok {
-			// 関数名を取得
-			funcName = ident.Value
-			if logger.IsLevelEnabled(mapFilterDebugLevel) {
-				logger.Debug("関数名: %s", funcName)
-			}
-
-			// 引数の評価
-			funcArgs = evalExpressions(right.Arguments, env)
-			if len(funcArgs) > 0 && funcArgs[0] != nil && funcArgs[0].Type() == object.ERROR_OBJ {
-				return funcArgs[0]
-			}
-		} else {
-			return createError("関数呼び出し式の関数部分が識別子ではありません: %T", right.Function)
-		}
+		logger.Debug("右辺が関数呼び出し式")
 		
-		// CallExpressionの場合、evalPipelineWithCallExpressionを使用して評価
-		resultElements := make([]object.Object, 0)
-		for _, element := range elements {
-			// 各要素に対して関数を適用
-			result := evalPipelineWithCallExpression(element, right, env)
-			
-			// 結果がtruthyな場合のみ結果に含める
-			if isTruthy(result) {
-				resultElements = append(resultElements, element)
-			}
+		// 関数名を取得
+		ident, ok := right.Function.(*ast.Identifier)
+		if !ok {
+			return nil, createError("関数呼び出し式の関数部分が識別子ではありません: %T", right.Function)
+		}
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("関数名: %s", ident.Value)
 		}
 		
-		// 単一値モードの場合、結果があれば元の値を、なければnullを返す
-		if isSingleValue {
-			if len(resultElements) > 0 {
-				return left // 元の単一値を返す
+		// 追加引数を評価（段を作るときに一度だけ評価し、後から変数が変わっても影響を受けない）
+		funcArgs = evalExpressions(right.Arguments, env)
+		for _, arg := range funcArgs {
+			if arg.Type() == object.ERROR_OBJ {
+				return nil, arg
 			}
-			return NULL
 		}
 		
-		return &object.Array{Elements: resultElements}
+		// CallExpressionの場合、各要素と評価済みの引数で関数を呼び出す
+		return func(elem object.Object) object.Object {
+			return applyPipelineCall(ident.Value, elem, funcArgs, env)
+		}, nil
 	default:
-		return createError("filter演算子の右辺が関数または識別子ではありません: %T", node.Right)
+		return nil, createError("%s演算子の右辺が関数または識別子ではありません: %T", opName, right)
 	}
 
-	// 直接配列の各要素に対して処理を行う
-	resultElements := make([]object.Object, 0)
-	
-	for _, elem := range elements {
-		// 一時環境を作成し、🍕に要素をセット
-		tempEnv := object.NewEnclosedEnvironment(env)
-		tempEnv.Set("🍕", elem)
-		
+	return func(elem object.Object) object.Object {
 		// 現在の要素に対して適切な関数を選択・実行
 		// 引数にはelemを含める
 		args := []object.Object{elem}
@@ -283,44 +298,214 @@ func evalFilterOperation(node *ast.InfixExpression, env *object.Environment) obj
 		if len(functions) == 0 {
 			// 組み込み関数を確認
 			if builtin, ok := Builtins[funcName]; ok {
-				logger.Debug("ビルトイン関数 '%s' をフィルター操作で呼び出します", funcName)
-				result := builtin.Fn(args...)
-				if result == nil || result.Type() == object.ERROR_OBJ {
-					return result
-				}
-				
-				// 結果がtruthyな場合のみ結果に含める
-				if isTruthy(result) {
-					resultElements = append(resultElements, elem)
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("ビルトイン関数 '%s' を%s操作で呼び出します", funcName, opName)
 				}
-				continue
+				return callBuiltin(builtin, args...)
 			}
 			return createError("関数 '%s' が見つかりません", funcName)
 		}
 		
 		// 関数を適用 (case文サポート)
-		logger.Debug("要素 %s に対して関数 %s を適用", elem.Inspect(), funcName)
-		logCaseDebug("filter演算子: case文対応で関数 %s を呼び出します", funcName)
-		result := applyCaseBare(functions[0], args)
-		
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("要素 %s に対して関数 %s を適用", elem.Inspect(), funcName)
+		}
+		if isCaseDebugEnabled() {
+			logCaseDebug("%s演算子: case文対応で関数 %s を呼び出します", opName, funcName)
+		}
+		// 同じ名前の条件付き関数は要素ごとに選び直す
+		fn, errObj := selectFunction(env, funcName, functions, args)
+		if errObj != nil {
+			return errObj
+		}
+		return applyCaseBare(fn, args)
+	}, nil
+}
+
+// evalFoldOperation はfold演算子(/>)を処理する
+// 🍕に各要素、💩に累積値を設定して関数を順に適用し、最終的な累積値を返す
+// 右辺の関数呼び出しに引数があれば、それを累積値の初期値として使用する
+func evalFoldOperation(node *ast.InfixExpression, env *object.Environment) (stageResult object.Object) {
+	if logger.IsLevelEnabled(mapFilterDebugLevel) {
+		logger.Debug("fold演算子(/>)の処理を開始")
+	}
+
+	// 左辺値の評価
+	left := evalPipelineSource(node.Left, env)
+	if left == nil {
+		return createError("foldオペレーション: 左辺の評価結果がnilです")
+	}
+	if left.Type() == object.ERROR_OBJ {
+		return left
+	}
+	if hook != nil {
+		hook.BeforeStage(node, left, env)
+		defer func() { hook.AfterStage(node, stageResult) }()
+	}
+	if profiler != nil {
+		profiler.Enter(stageFrame(node), left)
+		defer func() { profiler.Exit(stageResult) }()
+	}
+
+	// 配列・ストリーム・単一の値のいずれも要素を順に取り出して畳み込む
+	var stream *object.Stream
+	switch leftObj := left.(type) {
+	case *object.Stream:
+		if leftObj.Infinite {
+			return createError("無限ストリームをfoldすることはできません（take で要素数を制限してください）")
+		}
+		stream = leftObj
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("/> 左辺の評価結果: ストリーム %s", left.Inspect())
+		}
+	case *object.Array:
+		stream = newArrayStream(leftObj)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("/> 左辺の評価結果: 配列 %s (タイプ: %s)", left.Inspect(), left.Type())
+		}
+	case *object.Hash:
+		// ハッシュの場合は挿入順の値を畳み込む
+		stream = newArrayStream(&object.Array{Elements: hashValues(leftObj)})
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("/> 左辺の評価結果: ハッシュ %s (タイプ: %s)", left.Inspect(), left.Type())
+		}
+	default:
+		// 単一の値の場合は要素1つの配列として扱う
+		stream = newArrayStream(&object.Array{Elements: []object.Object{left}})
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("/> 左辺の評価結果: 単一値 %s (タイプ: %s) を要素1つの配列として扱います", left.Inspect(), left.Type())
+		}
+	}
+	next := stream.Iterate()
+	defer stream.Close()
+
+	// 右辺値の評価（関数名と初期値）
+	var funcName string
+	var initial object.Object
+
+	switch right := node.Right.(type) {
+	case *ast.Identifier:
+		// 識別子の場合、関数名として扱う（初期値なし）
+		funcName = right.Value
+	case *ast.CallExpression:
+		ident, ok := right.Function.(*ast.Identifier)
+		if !ok {
+			return createError("関数呼び出し式の関数部分が識別子ではありません: %T", right.Function)
+		}
+		funcName = ident.Value
+
+		// 引数は累積値の初期値として扱う
+		funcArgs := evalExpressions(right.Arguments, env)
+		if len(funcArgs) > 1 {
+			return createError("fold演算子の初期値は1つだけ指定できます: %d個与えられました", len(funcArgs))
+		}
+		if len(funcArgs) == 1 {
+			if funcArgs[0].Type() == object.ERROR_OBJ {
+				return funcArgs[0]
+			}
+			initial = funcArgs[0]
+		}
+	default:
+		return createError("fold演算子の右辺が関数または識別子ではありません: %T", node.Right)
+	}
+
+	// 初期値がない場合は最初の要素を初期値とする
+	if initial == nil {
+		first, ok := next()
+		if !ok {
+			return createError("空の配列を初期値なしでfoldすることはできません")
+		}
+		if first.Type() == object.ERROR_OBJ {
+			return first
+		}
+		initial = first
+	}
+
+	// 関数を取得（環境から検索し、なければ組み込み関数）
+	var builtin *object.Builtin
+	functions := env.GetAllFunctionsByName(funcName)
+	if len(functions) == 0 {
+		b, ok := Builtins[funcName]
+		if !ok {
+			return createError("関数 '%s' が見つかりません", funcName)
+		}
+		builtin = b
+	}
+
+	acc := initial
+	for {
+		elem, ok := next()
+		if !ok {
+			break
+		}
+		if elem.Type() == object.ERROR_OBJ {
+			return elem
+		}
+
+		var result object.Object
+		if builtin != nil {
+			// 組み込み関数は (累積値, 要素) の順で呼び出す
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("ビルトイン関数 '%s' をfold操作で呼び出します", funcName)
+			}
+			result = callBuiltin(builtin, acc, elem)
+		} else {
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("要素 %s と累積値 %s に対して関数 %s を適用", elem.Inspect(), acc.Inspect(), funcName)
+			}
+			if isCaseDebugEnabled() {
+				logCaseDebug("fold演算子: case文対応で関数 %s を呼び出します", funcName)
+			}
+			// 同じ名前の条件付き関数は (累積値, 要素) の組ごとに選び直す
+			fn, errObj := selectFunction(env, funcName, functions, []object.Object{elem, acc})
+			if errObj != nil {
+				return errObj
+			}
+			result = applyFoldFunction(fn, acc, elem)
+		}
+
 		if result == nil || result.Type() == object.ERROR_OBJ {
-			logger.Debug("関数 %s の適用中にエラーが発生: %s", funcName, result.Inspect())
 			return result
 		}
-		
-		// 結果がtruthyな場合のみ結果に含める
-		if isTruthy(result) {
-			resultElements = append(resultElements, elem)
-		}
+		acc = result
 	}
-	
-	// 単一値モードの場合、結果があれば元の値を、なければnullを返す
-	if isSingleValue {
-		if len(resultElements) > 0 {
-			return left // 元の単一値を返す
+
+	return acc
+}
+
+// applyFoldFunction はfold演算子の1ステップとして関数を実行する
+// 🍕に要素、💩と第1パラメータに累積値を設定する
+func applyFoldFunction(fn *object.Function, acc, elem object.Object) object.Object {
+	extendedEnv := object.NewEnclosedEnvironment(fn.Env)
+	extendedEnv.Set("🍕", elem)
+	extendedEnv.Set("💩", acc)
+	fn.SetPizzaValue(elem)
+
+	// パラメータがあれば累積値をバインド
+	if len(fn.Parameters) > 0 {
+		extendedEnv.Set(fn.Parameters[0].Value, acc)
+	}
+
+	// 現在実行中の関数を更新
+	oldCurrentFunction := currentFunction
+	currentFunction = fn
+	defer func() {
+		currentFunction = oldCurrentFunction
+	}()
+
+	astBody, ok := fn.ASTBody.(*ast.BlockStatement)
+	if !ok {
+		return createError("関数の本体がBlockStatementではありません")
+	}
+	result := evalFunctionBody(fn, astBody, extendedEnv)
+
+	// リターン値のアンラップ
+	if obj, ok := result.(*object.ReturnValue); ok {
+		if obj.Value == nil {
+			return NullObj
 		}
-		return NULL
+		return obj.Value
 	}
-	
-	return &object.Array{Elements: resultElements}
+
+	return result
 }
@@ -0,0 +1,72 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+)
+
+// TestFoldOperator は /> 演算子（fold）をテストする
+func TestFoldOperator(t *testing.T) {
+	// テスト時はデバッグログを無効化
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// 初期値なし: 最初の要素が初期値になる
+		{"[1..5] /> add;", int64(15)},
+		// 初期値あり
+		{"[1..5] /> add 100;", int64(115)},
+		// 左から順に畳み込まれる
+		{"[1, 2, 3] /> sub;", int64(-4)},
+		// 文字列の連結
+		{"[\"a\", \"b\", \"c\"] /> add \"\";", "abc"},
+		// ユーザー定義関数: 第1引数に累積値、🍕に要素
+		{"def sum_step(acc) { 🍕 + acc >> 💩 }; [1..4] /> sum_step 0;", int64(10)},
+		// ユーザー定義関数: 💩で累積値を参照
+		{"def mul_step() { 🍕 * 💩 >> 💩 }; [1..5] /> mul_step 1;", int64(120)},
+		// foldキーワード
+		{"[1, 2, 3] fold add;", int64(6)},
+		// 条件付き関数は (累積値, 要素) の組ごとに選ばれる
+		{"def step() if 🍕 % 2 == 0 { 💩 + 🍕 >> 💩; }; def step() if 🍕 % 2 != 0 { 💩 * 🍕 >> 💩; }; [1..4] /> step 1;", int64(13)},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int64:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+// TestFoldOperatorErrors は /> 演算子のエラーケースをテストする
+func TestFoldOperatorErrors(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"[] /> add;", "空の配列を初期値なしでfoldすることはできません"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
@@ -8,43 +8,48 @@ import (
 func TestMapOperator(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected []int64
+		expected interface{}
 	}{
 		// 引数なしの+>演算子（シンプルなmap操作）
 		{
-			"let double = fn() { 🍕 * 2 }; [1, 2, 3] +> double",
+			"def double() { 🍕 * 2 >> 💩 }; [1, 2, 3] +> double;",
 			[]int64{2, 4, 6},
 		},
 		// 引数を持つ関数を使った+>演算子
 		{
-			"let addNum = fn(n) { 🍕 + n }; [1, 2, 3] +> addNum(10)",
+			"def addNum(x, n) { 🍕 + n >> 💩 }; [1, 2, 3] +> addNum 10;",
 			[]int64{11, 12, 13},
 		},
 		// 複数の配列操作の組み合わせ
 		{
-			"let double = fn() { 🍕 * 2 }; [1..5] +> double",
+			"def double() { 🍕 * 2 >> 💩 }; [1..5] +> double;",
 			[]int64{2, 4, 6, 8, 10},
 		},
 		// 文字列配列に対する操作
 		{
-			"let addExclamation = fn() { 🍕 + \"!\" }; [\"hello\", \"world\"] +> addExclamation",
+			"def addExclamation() { 🍕 + \"!\" >> 💩 }; [\"hello\", \"world\"] +> addExclamation;",
 			[]string{"hello!", "world!"},
 		},
-		// パイプラインとの組み合わせ
+		// キーワード map / filter との組み合わせ
 		{
-			"let double = fn() { 🍕 * 2 }; [1, 2, 3] |> map double",
+			"def double() { 🍕 * 2 >> 💩 }; [1, 2, 3] map double;",
 			[]int64{2, 4, 6},
 		},
 		// +>演算子同士の連結
 		{
-			"let double = fn() { 🍕 * 2 }; let addOne = fn() { 🍕 + 1 }; [1, 2, 3] +> double +> addOne",
+			"def double() { 🍕 * 2 >> 💩 }; def addOne() { 🍕 + 1 >> 💩 }; [1, 2, 3] +> double +> addOne;",
 			[]int64{3, 5, 7},
 		},
+		// 条件付き関数は要素ごとに選ばれる
+		{
+			"def tens() if 🍕 % 2 == 0 { 🍕 * 10 >> 💩; }; def tens() if 🍕 % 2 != 0 { 🍕 >> 💩; }; [1, 2, 3, 4] +> tens;",
+			[]int64{1, 20, 3, 40},
+		},
 	}
 
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
-		
+
 		switch expected := tt.expected.(type) {
 		case []int64:
 			testIntegerArray(t, evaluated, expected)
@@ -58,38 +63,43 @@ func TestMapOperator(t *testing.T) {
 func TestFilterOperator(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected []int64
+		expected interface{}
 	}{
 		// 引数なしの?>演算子（シンプルなfilter操作）
 		{
-			"let isEven = fn() { 🍕 % 2 == 0 }; [1, 2, 3, 4, 5] ?> isEven",
+			"def isEven() { 🍕 % 2 == 0 >> 💩 }; [1, 2, 3, 4, 5] ?> isEven;",
 			[]int64{2, 4},
 		},
 		// 引数を持つ関数を使った?>演算子
 		{
-			"let greaterThan = fn(n) { 🍕 > n }; [1, 2, 3, 4, 5] ?> greaterThan(2)",
+			"def greaterThan(x, n) { 🍕 > n >> 💩 }; [1, 2, 3, 4, 5] ?> greaterThan 2;",
 			[]int64{3, 4, 5},
 		},
 		// 複数の配列操作の組み合わせ
 		{
-			"let isEven = fn() { 🍕 % 2 == 0 }; [1..10] ?> isEven",
+			"def isEven() { 🍕 % 2 == 0 >> 💩 }; [1..10] ?> isEven;",
 			[]int64{2, 4, 6, 8, 10},
 		},
 		// 文字列配列に対する操作
 		{
-			"let isLong = fn() { len(🍕) > 3 }; [\"a\", \"ab\", \"abc\", \"abcd\", \"abcde\"] ?> isLong",
+			"def isLong() { (🍕 |> length) > 3 >> 💩 }; [\"a\", \"ab\", \"abc\", \"abcd\", \"abcde\"] ?> isLong;",
 			[]string{"abcd", "abcde"},
 		},
 		// ?>演算子同士の連結
 		{
-			"let isEven = fn() { 🍕 % 2 == 0 }; let greaterThan3 = fn() { 🍕 > 3 }; [1, 2, 3, 4, 5, 6] ?> isEven ?> greaterThan3",
+			"def isEven() { 🍕 % 2 == 0 >> 💩 }; def greaterThan3() { 🍕 > 3 >> 💩 }; [1, 2, 3, 4, 5, 6] ?> isEven ?> greaterThan3;",
 			[]int64{4, 6},
 		},
+		// 条件付き関数は要素ごとに選ばれる
+		{
+			"def keep() if 🍕 > 2 { true >> 💩; }; def keep() if 🍕 <= 2 { false >> 💩; }; [1, 2, 3, 4] ?> keep;",
+			[]int64{3, 4},
+		},
 	}
 
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
-		
+
 		switch expected := tt.expected.(type) {
 		case []int64:
 			testIntegerArray(t, evaluated, expected)
@@ -107,28 +117,28 @@ func TestMapFilterOperatorsCombined(t *testing.T) {
 	}{
 		// +>と?>の連結
 		{
-			"let double = fn() { 🍕 * 2 }; let isEven = fn() { 🍕 % 2 == 0 }; [1, 2, 3, 4, 5] +> double ?> isEven",
+			"def double() { 🍕 * 2 >> 💩 }; def isEven() { 🍕 % 2 == 0 >> 💩 }; [1, 2, 3, 4, 5] +> double ?> isEven;",
 			[]int64{2, 4, 6, 8, 10},
 		},
 		// ?>と+>の連結
 		{
-			"let double = fn() { 🍕 * 2 }; let isEven = fn() { 🍕 % 2 == 0 }; [1, 2, 3, 4, 5] ?> isEven +> double",
+			"def double() { 🍕 * 2 >> 💩 }; def isEven() { 🍕 % 2 == 0 >> 💩 }; [1, 2, 3, 4, 5] ?> isEven +> double;",
 			[]int64{4, 8},
 		},
 		// 複雑な連結
 		{
-			"let double = fn() { 🍕 * 2 }; let addOne = fn() { 🍕 + 1 }; let isEven = fn() { 🍕 % 2 == 0 }; [1, 2, 3, 4, 5] +> double ?> isEven +> addOne",
-			[]int64{5, 9},
+			"def double() { 🍕 * 2 >> 💩 }; def addOne() { 🍕 + 1 >> 💩 }; def isEven() { 🍕 % 2 == 0 >> 💩 }; [1, 2, 3, 4, 5] +> double ?> isEven +> addOne;",
+			[]int64{3, 5, 7, 9, 11},
 		},
-		// パイプラインとの組み合わせ
+		// キーワード map / filter との組み合わせ
 		{
-			"let double = fn() { 🍕 * 2 }; let isGreaterThan5 = fn() { 🍕 > 5 }; [1, 2, 3, 4, 5] |> map double |> filter isGreaterThan5",
+			"def double() { 🍕 * 2 >> 💩 }; def isGreaterThan5() { 🍕 > 5 >> 💩 }; [1, 2, 3, 4, 5] map double filter isGreaterThan5;",
 			[]int64{6, 8, 10},
 		},
-		// +>, ?>, |>の混合
+		// +>, ?>, map の混合
 		{
-			"let double = fn() { 🍕 * 2 }; let isEven = fn() { 🍕 % 2 == 0 }; let addOne = fn() { 🍕 + 1 }; [1, 2, 3, 4, 5] +> double ?> isEven |> map addOne",
-			[]int64{5, 9},
+			"def double() { 🍕 * 2 >> 💩 }; def isEven() { 🍕 % 2 == 0 >> 💩 }; def addOne() { 🍕 + 1 >> 💩 }; [1, 2, 3, 4, 5] +> double ?> isEven map addOne;",
+			[]int64{3, 5, 7, 9, 11},
 		},
 	}
 
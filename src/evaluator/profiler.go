@@ -0,0 +1,74 @@
+package evaluator
+
+import (
+	"github.com/uncode/ast"
+	"github.com/uncode/object"
+)
+
+// ProfileFrame はプロファイルに記録する実行の単位（ユーザー定義関数・組み込み関数・パイプラインの段）
+type ProfileFrame struct {
+	Name    string
+	Builtin bool // 組み込み関数（ソース上の位置を持たない）
+	Stage   bool // パイプラインの段
+	Line    int  // ユーザー定義関数は本体の開始位置、段は演算子の位置
+	Column  int
+}
+
+// Profiler は関数やパイプラインの段の実行時間を計測するためのインターフェース
+// Enter と Exit は入れ子になった順に対で呼び出される
+type Profiler interface {
+	// Enter は実行の開始時に、🍕 として渡した値（組み込み関数は最初の引数、なければ nil）とともに呼び出される
+	Enter(frame ProfileFrame, input object.Object)
+	// Exit は実行の終了時に、💩 の結果とともに呼び出される。実行が打ち切られた場合は result が nil になる
+	Exit(result object.Object)
+}
+
+// profiler は登録されているプロファイラ（登録されていなければ nil）
+// 登録されていない場合のコストは nil の確認だけになる
+var profiler Profiler
+
+// SetProfiler はプロファイラを登録する。nil を指定すると解除する
+func SetProfiler(p Profiler) {
+	profiler = p
+}
+
+// callBuiltin は組み込み関数を呼び出す。プロファイル中は実行時間を記録する
+func callBuiltin(fn *object.Builtin, args ...object.Object) object.Object {
+	warnCoercedInteger(0, args...)
+	if profiler == nil {
+		return fn.Fn(args...)
+	}
+	var input object.Object
+	if len(args) > 0 {
+		input = args[0]
+	}
+	profiler.Enter(ProfileFrame{Name: fn.Name, Builtin: true}, input)
+	result := fn.Fn(args...)
+	profiler.Exit(result)
+	return result
+}
+
+// functionFrame はユーザー定義関数のプロファイル上の単位を返す
+func functionFrame(fn *object.Function, body *ast.BlockStatement) ProfileFrame {
+	return ProfileFrame{Name: functionName(fn), Line: body.Token.Line, Column: body.Token.Column}
+}
+
+// stageFrame はパイプラインの段のプロファイル上の単位を返す (例: "|> sq")
+func stageFrame(node *ast.InfixExpression) ProfileFrame {
+	return ProfileFrame{Name: node.Operator + " " + node.Right.String(), Stage: true, Line: node.Token.Line, Column: node.Token.Column}
+}
+
+// profileStreamStage はストリームに重ねる段の要素ごとの処理を、その段の単位で計測するようにする
+// ストリームの要素は後の段が取り出すときに計算されるため、段を作成したときの計測には含まれない
+func profileStreamStage(node *ast.InfixExpression, apply func(object.Object) object.Object) func(object.Object) object.Object {
+	if profiler == nil {
+		return apply
+	}
+	frame := stageFrame(node)
+	return func(elem object.Object) object.Object {
+		profiler.Enter(frame, elem)
+		result := apply(elem)
+		profiler.Exit(result)
+		return result
+	}
+}
@@ -24,11 +24,38 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 		logger.Debug("関数の事前登録はスキップされました（設定が無効です）")
 	}
 	
+	// トップレベルで💩に代入した値は終了コードとして記録し、残りの文も実行する
+	// 複数回代入した場合は最後の値を使う
+	var exitValue *object.ReturnValue
 	for _, statement := range program.Statements {
 		if statement == nil {
 			continue
 		}
 		result = Eval(statement, env)
+
+		// exit 関数やエラーでプログラムを終了する
+		if isError(result) {
+			annotateError(result, statement)
+			return result
+		}
+		if returnValue, ok := result.(*object.ReturnValue); ok {
+			if integer, ok := returnValue.Value.(*object.Integer); ok && (integer.Value < 0 || integer.Value > 255) {
+				err := createError("トップレベルの💩の終了コードは0から255の範囲である必要があります: %d", integer.Value)
+				annotateError(err, statement)
+				return err
+			}
+			exitValue = returnValue
+			result = returnValue.Value
+		}
+	}
+	if exitValue != nil {
+		return exitValue
+	}
+	
+	// プログラムの結果として観測されるストリームは配列に変換する
+	// 無限ストリームは変換できないためそのまま返す
+	if stream, ok := result.(*object.Stream); ok && !stream.Infinite {
+		return materialize(stream)
 	}
 	
 	return result
@@ -39,8 +66,12 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 	var result object.Object = NullObj
 	
 	// デバッグ出力
-	logger.Debug("ブロック文の評価を開始します。%d 個のステートメント", len(block.Statements))
-	logCaseDebug("ブロック文の評価開始: %d 個のステートメント", len(block.Statements))
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("ブロック文の評価を開始します。%d 個のステートメント", len(block.Statements))
+	}
+	if isCaseDebugEnabled() {
+		logCaseDebug("ブロック文の評価開始: %d 個のステートメント", len(block.Statements))
+	}
 	
 	// caseステートメントの処理用変数
 	var caseEvaluated bool = false      // いずれかのcase文が真となったかを追跡
@@ -53,8 +84,12 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 			continue
 		}
 		
-		logger.Debug("  ステートメント %d を評価: %T", i, statement)
-		logCaseDebug("ステートメント %d を評価: %T", i, statement)
+		if logger.Enabled(logger.LevelDebug) {
+			logger.Debug("  ステートメント %d を評価: %T", i, statement)
+		}
+		if isCaseDebugEnabled() {
+			logCaseDebug("ステートメント %d を評価: %T", i, statement)
+		}
 		
 		// case文の処理
 		switch stmt := statement.(type) {
@@ -62,20 +97,35 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 			// すでにcaseが評価済みなら続行
 			if caseEvaluated {
 				logger.Debug("  すでにマッチしたcaseがあるため、このcase文をスキップします")
-				logCaseDebug("マッチング済みのため case文をスキップ: %s", stmt.Condition.String())
+				if isCaseDebugEnabled() {
+					logCaseDebug("マッチング済みのため case文をスキップ: %s", stmt.Condition.String())
+				}
 				continue
 			}
 			
-			logger.Debug("  case文を評価します: %s", stmt.Condition.String())
-			logCaseDebug("case文の評価: %s", stmt.Condition.String())
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("  case文を評価します: %s", stmt.Condition.String())
+			}
+			if isCaseDebugEnabled() {
+				logCaseDebug("case文の評価: %s", stmt.Condition.String())
+			}
 			
 			// case文の条件を評価
 			caseResult := evalCaseStatement(stmt, env)
 			
 			// エラーチェック
 			if isError(caseResult) {
-				logger.Debug("  case文の評価でエラーが発生しました: %s", caseResult.Inspect())
-				logCaseDebug("case文の評価エラー: %s", caseResult.Inspect())
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("  case文の評価でエラーが発生しました: %s", caseResult.Inspect())
+				}
+				if isCaseDebugEnabled() {
+					logCaseDebug("case文の評価エラー: %s", caseResult.Inspect())
+				}
+
+				// exit による終了や上限超過は次のcase文へ進まずに呼び出し元へ伝える
+				if isUnrecoverable(caseResult) {
+					return caseResult
+				}
 				
 				// エラーの場合は次のcase文を評価する (変更点: エラーをすぐに返さない)
 				continue
@@ -83,26 +133,36 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 			
 			// NULLの場合は条件が一致しなかったので続行
 			if caseResult == NullObj {
-				logCaseDebug("case文の条件が一致しませんでした: %s", stmt.Condition.String())
+				if isCaseDebugEnabled() {
+					logCaseDebug("case文の条件が一致しませんでした: %s", stmt.Condition.String())
+				}
 				continue
 			}
 			
 			// 条件に一致したcase文を見つけた
-			logger.Debug("  マッチするcase文を見つけました: %s", stmt.Condition.String())
-			logCaseDebug("マッチするcase文を発見: %s - 結果: %s", 
-				stmt.Condition.String(), caseResult.Inspect())
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("  マッチするcase文を見つけました: %s", stmt.Condition.String())
+			}
+			if isCaseDebugEnabled() {
+				logCaseDebug("マッチするcase文を発見: %s - 結果: %s", 
+					stmt.Condition.String(), caseResult.Inspect())
+			}
 			
 			result = caseResult
 			caseEvaluated = true
 			
 			// Case文マッチ後のエラーもしくはリターン値の場合は即時リターン
 			if result.Type() == object.ERROR_OBJ {
-				logCaseDebug("case文の評価結果がエラーのため即時リターン: %s", result.Inspect())
+				if isCaseDebugEnabled() {
+					logCaseDebug("case文の評価結果がエラーのため即時リターン: %s", result.Inspect())
+				}
 				return result
 			}
 			
 			if returnObj, ok := result.(*object.ReturnValue); ok {
-				logCaseDebug("case文の評価結果がリターン値のため即時リターン: %s", returnObj.Inspect())
+				if isCaseDebugEnabled() {
+					logCaseDebug("case文の評価結果がリターン値のため即時リターン: %s", returnObj.Inspect())
+				}
 				return returnObj
 			}
 			
@@ -119,13 +179,18 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 			
 			// ReturnValue（関数からの戻り値）が検出された場合は評価を中止して戻る
 			if returnValue, ok := result.(*object.ReturnValue); ok {
-				logger.Debug("  ReturnValue が検出されました: %s", returnValue.Inspect())
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("  ReturnValue が検出されました: %s", returnValue.Inspect())
+				}
 				return returnValue
 			}
 			
 			// ErrorValue が検出された場合も評価を中止して戻る
 			if isError(result) {
-				logger.Debug("  Error が検出されました: %s", result.Inspect())
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("  Error が検出されました: %s", result.Inspect())
+				}
+				annotateError(result, statement)
 				return result
 			}
 			
@@ -136,7 +201,9 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 					// 左辺の値を取得
 					leftVal := Eval(assignStmt.Left, env)
 					if isError(leftVal) {
-						logger.Debug("  💩への代入で左辺の評価エラー: %s", leftVal.Inspect())
+						if logger.Enabled(logger.LevelDebug) {
+							logger.Debug("  💩への代入で左辺の評価エラー: %s", leftVal.Inspect())
+						}
 						return leftVal
 					}
 					return &object.ReturnValue{Value: leftVal}
@@ -154,20 +221,32 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 		
 		// default case評価後のエラーまたはリターン値チェック
 		if isError(result) {
-			logger.Debug("  default caseの評価でエラーが発生しました: %s", result.Inspect())
-			logCaseDebug("default caseの評価でエラー: %s", result.Inspect())
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("  default caseの評価でエラーが発生しました: %s", result.Inspect())
+			}
+			if isCaseDebugEnabled() {
+				logCaseDebug("default caseの評価でエラー: %s", result.Inspect())
+			}
 			return result
 		}
 		
 		if returnObj, ok := result.(*object.ReturnValue); ok {
-			logger.Debug("  default caseからreturn値を検出: %s", returnObj.Inspect())
-			logCaseDebug("default caseからreturn値を検出: %s", returnObj.Inspect())
+			if logger.Enabled(logger.LevelDebug) {
+				logger.Debug("  default caseからreturn値を検出: %s", returnObj.Inspect())
+			}
+			if isCaseDebugEnabled() {
+				logCaseDebug("default caseからreturn値を検出: %s", returnObj.Inspect())
+			}
 			return returnObj
 		}
 	}
 	
-	logger.Debug("ブロック文の評価を完了しました。最終結果: %s", result.Inspect())
-	logCaseDebug("ブロック文の評価完了。結果: %s", result.Inspect())
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("ブロック文の評価を完了しました。最終結果: %s", result.Inspect())
+	}
+	if isCaseDebugEnabled() {
+		logCaseDebug("ブロック文の評価完了。結果: %s", result.Inspect())
+	}
 	return result
 }
 
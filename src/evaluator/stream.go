@@ -0,0 +1,358 @@
+package evaluator
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/uncode/object"
+)
+
+// newIntRangeStream は start から end までの整数を順に返すストリームを作成する
+// infinite が true の場合は end を無視して終わりなく増加する
+func newIntRangeStream(start, end int64, infinite bool) *object.Stream {
+	step := int64(1)
+	if !infinite && start > end {
+		step = -1
+	}
+
+	return &object.Stream{
+		Iterate: func() object.Iterator {
+			current := start
+			done := false
+			return func() (object.Object, bool) {
+				if done {
+					return nil, false
+				}
+				value := current
+				if !infinite && value == end {
+					done = true
+				}
+				current += step
+				return &object.Integer{Value: value}, true
+			}
+		},
+		Infinite:   infinite,
+		Replayable: true,
+	}
+}
+
+// newRuneRangeStream は start から end までの文字を順に返すストリームを作成する
+func newRuneRangeStream(start, end rune) *object.Stream {
+	step := rune(1)
+	if start > end {
+		step = -1
+	}
+
+	return &object.Stream{
+		Iterate: func() object.Iterator {
+			current := start
+			done := false
+			return func() (object.Object, bool) {
+				if done {
+					return nil, false
+				}
+				value := current
+				if value == end {
+					done = true
+				}
+				current += step
+				return &object.String{Value: string(value)}, true
+			}
+		},
+		Replayable: true,
+	}
+}
+
+// newArrayStream は配列の要素を順に返すストリームを作成する
+func newArrayStream(array *object.Array) *object.Stream {
+	return &object.Stream{
+		Iterate: func() object.Iterator {
+			i := 0
+			return func() (object.Object, bool) {
+				if i >= len(array.Elements) {
+					return nil, false
+				}
+				i++
+				return array.Elements[i-1], true
+			}
+		},
+		Replayable: true,
+	}
+}
+
+// lineSource は行ストリームの読み込み元を開く関数
+// 読み込み元と、読み終えたときに呼ぶ後始末の関数を返す
+type lineSource func() (*bufio.Reader, func(), error)
+
+// newLineStream は読み込み元から1行ずつ文字列を返すストリームを作成する
+// 次の要素が要求されたときに1行だけ読み込むため、入力全体をメモリに載せることはなく、
+// 後段の処理が終わるまで次の行は読まれない
+// 最後まで読まずに走査をやめる場合は Close で読み込み元を閉じる
+func newLineStream(name string, open lineSource) *object.Stream {
+	// 開いている走査ごとの後始末（Close ですべて閉じる）
+	active := map[int]func(){}
+	nextID := 0
+	return &object.Stream{
+		Iterate: func() object.Iterator {
+			var reader *bufio.Reader
+			cleanup := func() {}
+			done := false
+			id := nextID
+			nextID++
+			finish := func() {
+				if done {
+					return
+				}
+				done = true
+				delete(active, id)
+				cleanup()
+			}
+			active[id] = finish
+			return func() (object.Object, bool) {
+				if done {
+					return nil, false
+				}
+				if reader == nil {
+					r, c, err := open()
+					if err != nil {
+						done = true
+						return createError("%s関数: 入力を開けませんでした: %s", name, err), true
+					}
+					reader, cleanup = r, c
+				}
+
+				line, err := reader.ReadString('\n')
+				if err != nil && err != io.EOF {
+					finish()
+					return createError("%s関数: 入力の読み込みに失敗しました: %s", name, err), true
+				}
+				if err == io.EOF {
+					finish()
+					// 末尾が改行で終わっていない最後の行も1行として返す
+					if line == "" {
+						return nil, false
+					}
+				}
+				return &object.String{Value: strings.TrimRight(line, "\r\n")}, true
+			}
+		},
+		Stop: func() {
+			for _, finish := range active {
+				finish()
+			}
+		},
+	}
+}
+
+// mapStream は各要素に fn を適用するストリームを作成する
+// パイプラインの段を重ねても中間の配列は作られず、要素ごとに全段が一度に適用される
+func mapStream(source *object.Stream, fn func(object.Object) object.Object) *object.Stream {
+	return &object.Stream{
+		Iterate: func() object.Iterator {
+			next := source.Iterate()
+			failed := false
+			return func() (object.Object, bool) {
+				if failed {
+					return nil, false
+				}
+				elem, ok := next()
+				if !ok {
+					return nil, false
+				}
+				if isError(elem) {
+					failed = true
+					return elem, true
+				}
+				result := fn(elem)
+				if result == nil {
+					result = NullObj
+				}
+				if isError(result) {
+					failed = true
+					source.Close()
+				}
+				return result, true
+			}
+		},
+		Infinite: source.Infinite,
+		Stop:     source.Close,
+	}
+}
+
+// filterStream は predicate が真を返す要素だけを返すストリームを作成する
+// predicate がエラーを返した場合は、そのエラーを要素として返して走査を終える
+func filterStream(source *object.Stream, predicate func(object.Object) (bool, object.Object)) *object.Stream {
+	return &object.Stream{
+		Iterate: func() object.Iterator {
+			next := source.Iterate()
+			failed := false
+			return func() (object.Object, bool) {
+				if failed {
+					return nil, false
+				}
+				for {
+					elem, ok := next()
+					if !ok {
+						return nil, false
+					}
+					if isError(elem) {
+						failed = true
+						return elem, true
+					}
+					keep, err := predicate(elem)
+					if err != nil {
+						failed = true
+						source.Close()
+						return err, true
+					}
+					if keep {
+						return elem, true
+					}
+				}
+			}
+		},
+		Infinite: source.Infinite,
+		Stop:     source.Close,
+	}
+}
+
+// takeStream は先頭から最大 n 個の要素を返すストリームを作成する
+// n 個取り出した時点で元のストリームの読み込み元を閉じる
+func takeStream(source *object.Stream, n int64) *object.Stream {
+	return &object.Stream{
+		Iterate: func() object.Iterator {
+			next := source.Iterate()
+			taken := int64(0)
+			return func() (object.Object, bool) {
+				if taken >= n {
+					source.Close()
+					return nil, false
+				}
+				taken++
+				elem, ok := next()
+				if taken == n {
+					source.Close()
+				}
+				return elem, ok
+			}
+		},
+		Replayable: source.Replayable,
+		Stop:       source.Close,
+	}
+}
+
+// cacheStream は一度計算した要素を覚えておくストリームを作成する
+// 変数に代入したストリームを何度走査しても、各段の関数は要素ごとに一度しか呼ばれない
+// 要素は最初に必要になったときに計算されるため、無限ストリームもそのまま扱える
+// 後の走査で続きを読めるように、途中で走査をやめても元のストリームは閉じない
+func cacheStream(source *object.Stream) *object.Stream {
+	if source.Replayable {
+		return source
+	}
+
+	var next object.Iterator
+	var cache []object.Object
+	done := false
+	return &object.Stream{
+		Iterate: func() object.Iterator {
+			i := 0
+			return func() (object.Object, bool) {
+				if i < len(cache) {
+					i++
+					return cache[i-1], true
+				}
+				if done {
+					return nil, false
+				}
+				if next == nil {
+					next = source.Iterate()
+				}
+				elem, ok := next()
+				if !ok {
+					done = true
+					return nil, false
+				}
+				cache = append(cache, elem)
+				i++
+				return elem, true
+			}
+		},
+		Infinite:   source.Infinite,
+		Replayable: true,
+	}
+}
+
+// storedValue は変数に代入する値を返す
+// ストリームは走査のたびに各段を計算し直すため、計算した要素を覚えておくストリームにして代入する
+func storedValue(obj object.Object) object.Object {
+	if stream, ok := obj.(*object.Stream); ok {
+		return cacheStream(stream)
+	}
+	return obj
+}
+
+// forEachInStream はストリームの各要素に fn を順に適用する
+// 要素がエラーだった場合や fn がエラーを返した場合はそのエラーを返す
+func forEachInStream(stream *object.Stream, fn func(object.Object) object.Object) object.Object {
+	if stream.Infinite {
+		return createError("無限ストリームを最後まで評価することはできません（take で要素数を制限してください）")
+	}
+
+	next := stream.Iterate()
+	defer stream.Close()
+	for {
+		elem, ok := next()
+		if !ok {
+			return nil
+		}
+		if isError(elem) {
+			return elem
+		}
+		if err := fn(elem); err != nil && isError(err) {
+			return err
+		}
+	}
+}
+
+// materialize はストリームを配列に変換する
+// ストリーム以外の値はそのまま返す
+func materialize(obj object.Object) object.Object {
+	stream, ok := obj.(*object.Stream)
+	if !ok {
+		return obj
+	}
+
+	elements := []object.Object{}
+	if err := forEachInStream(stream, func(elem object.Object) object.Object {
+		elements = append(elements, elem)
+		return nil
+	}); err != nil {
+		return err
+	}
+	return &object.Array{Elements: elements}
+}
+
+// streamIndex はストリームの index 番目の要素を返す
+// 負の添字は末尾からの位置になるため、有限のストリームを配列に変換してから処理する
+func streamIndex(stream *object.Stream, index int64) object.Object {
+	if index < 0 {
+		array := materialize(stream)
+		if isError(array) {
+			return array
+		}
+		return evalArraySingleIndex(array.(*object.Array), index)
+	}
+
+	next := stream.Iterate()
+	defer stream.Close()
+	for i := int64(0); ; i++ {
+		elem, ok := next()
+		if !ok {
+			return createError("Index out of bounds")
+		}
+		if isError(elem) || i == index {
+			return elem
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package evaluator
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uncode/config"
+	"github.com/uncode/lexer"
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+	"github.com/uncode/parser"
+)
+
+// TestLazyRanges は範囲式とパイプラインが遅延評価されることをテストする
+func TestLazyRanges(t *testing.T) {
+	// テスト時はデバッグログを無効化
+	logger.SetLevel(logger.LevelError)
+
+	// 範囲式やパイプラインの途中の値はストリームのまま
+	for _, input := range []string{"[1..5] >> r; r;", "[1..] >> r; r;", "[1..5] +> add 1 >> r; r;"} {
+		l := testEvalStatements(input)
+		if _, ok := l.(*object.Stream); !ok {
+			t.Errorf("input %q: expected Stream, got=%T (%+v)", input, l, l)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		expected []int64
+	}{
+		// プログラムの結果として観測されると配列になる
+		{"[1..5];", []int64{1, 2, 3, 4, 5}},
+		{"[3..1];", []int64{3, 2, 1}},
+		// 終わりのない範囲も take で取り出せる
+		{"[1..] |> take 3;", []int64{1, 2, 3}},
+		{"def sq() { 🍕 * 🍕 >> 💩 }; [1..] +> sq ?> gt 50 |> take 3;", []int64{64, 81, 100}},
+		{"[10..] ?> ge 12 +> add 1 |> take 2 |> to_array;", []int64{13, 14}},
+		// 同じストリームを何度でも走査できる
+		{"[1..3] +> add 1 >> r; r /> add >> s; r |> to_array;", []int64{2, 3, 4}},
+		{"[1, 2, 3, 4] |> take 2;", []int64{1, 2}},
+	}
+
+	for _, tt := range tests {
+		testIntegerArray(t, testEval(tt.input), tt.expected)
+	}
+
+	testIntegerObject(t, testEval("[1..100] /> add;"), 5050)
+	testIntegerObject(t, testEval("[1..10] ?> gt 7 |> length;"), 3)
+	testIntegerObject(t, testEval("[1..][1000];"), 1001)
+	testIntegerObject(t, testEval("[1..10][-1];"), 10)
+	testStringArray(t, testEval(`["a".."c"];`), []string{"a", "b", "c"})
+}
+
+// TestStreamFusion はパイプラインの各段が要素ごとにまとめて適用され、必要な分だけ計算されることをテストする
+func TestStreamFusion(t *testing.T) {
+	calls := 0
+	var order []int64
+	double := func(elem object.Object) object.Object {
+		calls++
+		value := elem.(*object.Integer).Value
+		order = append(order, value)
+		return &object.Integer{Value: value * 2}
+	}
+	isMultipleOf3 := func(elem object.Object) (bool, object.Object) {
+		order = append(order, -elem.(*object.Integer).Value)
+		return elem.(*object.Integer).Value%3 == 0, nil
+	}
+
+	stream := takeStream(filterStream(mapStream(newIntRangeStream(1, 0, true), double), isMultipleOf3), 2)
+	result := materialize(stream)
+
+	testIntegerArray(t, result, []int64{6, 12})
+	if calls != 6 {
+		t.Errorf("map function should be called only for needed elements. calls=%d", calls)
+	}
+	// map と filter が1要素ずつ交互に適用される
+	expectedOrder := []int64{1, -2, 2, -4, 3, -6, 4, -8, 5, -10, 6, -12}
+	if len(order) != len(expectedOrder) {
+		t.Fatalf("wrong evaluation order. got=%v", order)
+	}
+	for i := range expectedOrder {
+		if order[i] != expectedOrder[i] {
+			t.Fatalf("wrong evaluation order. expected=%v, got=%v", expectedOrder, order)
+		}
+	}
+}
+
+// TestStoredStreams は変数に代入したストリームの段が一度だけ評価されることをテストする
+func TestStoredStreams(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	// 段の引数は段を作ったときの値を使う
+	testIntegerArray(t, testEval("2 >> k; [1..3] +> mul k >> s; 10 >> k; s;"), []int64{2, 4, 6})
+	testIntegerArray(t, testEval("def addNum(x, n) { 🍕 + n >> 💩 }; 2 >> k; [1..3] +> addNum k >> s; 10 >> k; s;"), []int64{3, 4, 5})
+
+	// 代入したストリームを何度走査しても、各段の関数は要素ごとに一度しか呼ばれない
+	out := captureOutput(t)
+	testIntegerArray(t, testEval("def noisy() { 🍕 |> print; 🍕 >> 💩 }; [1..2] +> noisy >> s; s |> to_array; s |> to_array;"), []int64{1, 2})
+	if out.String() != "1\n2\n" {
+		t.Errorf("stage should run once per element. output=%q", out.String())
+	}
+
+	// 無限ストリームも必要な要素だけ計算して覚えておく
+	calls := 0
+	stream := cacheStream(mapStream(newIntRangeStream(1, 0, true), func(elem object.Object) object.Object {
+		calls++
+		return elem
+	}))
+	testIntegerArray(t, materialize(takeStream(stream, 3)), []int64{1, 2, 3})
+	testIntegerArray(t, materialize(takeStream(stream, 5)), []int64{1, 2, 3, 4, 5})
+	if calls != 5 {
+		t.Errorf("cached stream should compute each element once. calls=%d", calls)
+	}
+}
+
+// TestLineStreamClose は行ストリームを途中まで読んでやめたときに読み込み元を閉じることをテストする
+func TestLineStreamClose(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	opened, closed := 0, 0
+	lines := func() *object.Stream {
+		return newLineStream("test_lines", func() (*bufio.Reader, func(), error) {
+			opened++
+			return bufio.NewReader(strings.NewReader("1\n2\n3\n4\n")), func() { closed++ }, nil
+		})
+	}
+	failAt2 := func(elem object.Object) object.Object {
+		if elem.(*object.String).Value == "2" {
+			return createError("failed")
+		}
+		return elem
+	}
+
+	tests := []struct {
+		name string
+		run  func() object.Object
+	}{
+		{"take", func() object.Object { return materialize(takeStream(lines(), 2)) }},
+		{"take 0", func() object.Object { return materialize(takeStream(lines(), 0)) }},
+		{"error in map", func() object.Object { return materialize(mapStream(lines(), failAt2)) }},
+		{"index", func() object.Object { return streamIndex(lines(), 1) }},
+		{"to the end", func() object.Object { return materialize(lines()) }},
+	}
+	for _, tt := range tests {
+		opened, closed = 0, 0
+		tt.run()
+		if closed != opened {
+			t.Errorf("%s: every opened source should be closed once. opened=%d, closed=%d", tt.name, opened, closed)
+		}
+	}
+
+	// file_lines を take で途中までしか読まなくてもファイルを閉じる
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("/proc/self/fd is not available")
+	}
+	dir := t.TempDir()
+	allowed := config.PathPermission{Paths: []string{dir}}
+	withFilePermissions(t, allowed, allowed)
+	path := filepath.Join(dir, "big.txt")
+	if err := os.WriteFile(path, []byte(strings.Repeat("line\n", 100)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		testStringArray(t, testEval(fmt.Sprintf(`%q |> file_lines |> take 1;`, path)), []string{"line"})
+	}
+	after, _ := os.ReadDir("/proc/self/fd")
+	if len(after) > len(fds) {
+		t.Errorf("file_lines |> take should close the file. open fds: %d -> %d", len(fds), len(after))
+	}
+}
+
+// TestStreamErrors は無限ストリームの評価やストリーム中のエラーをテストする
+func TestStreamErrors(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"[1..] |> length;", "無限ストリームを最後まで評価することはできません（take で要素数を制限してください）"},
+		{"[1..] |> to_array;", "無限ストリームを最後まで評価することはできません（take で要素数を制限してください）"},
+		{"[1..] /> add;", "無限ストリームをfoldすることはできません（take で要素数を制限してください）"},
+		{`[1..] |> take "3";`, "take関数の要素数は整数である必要があります: STRING"},
+		{`["a"..1];`, "サポートされていない範囲式の型: STRING..INTEGER"},
+		// 途中の段のエラーは配列に変換するときに返される
+		{`[1..3] +> substring 1 |> to_array;`, "substring関数の第1引数は文字列である必要があります: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("input %q: no error object returned. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+// endlessLines は "line" を終わりなく返す Reader
+type endlessLines struct{}
+
+func (endlessLines) Read(p []byte) (int, error) {
+	return copy(p, strings.Repeat("line\n", len(p)/5+1)), nil
+}
+
+// withStdin はテスト中だけ標準入力の読み込み元を差し替える
+func withStdin(t *testing.T, r *bufio.Reader) {
+	t.Helper()
+	prev := stdinReader
+	stdinReader = r
+	t.Cleanup(func() { stdinReader = prev })
+}
+
+// TestLineStreams は標準入力とファイルを1行ずつパイプラインに流せることをテストする
+func TestLineStreams(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	withStdin(t, bufio.NewReader(strings.NewReader("apple\nbanana\r\ncherry")))
+	testStringArray(t, testEval(`stdin_lines ?> ne "banana" +> to_upper;`), []string{"APPLE", "CHERRY"})
+
+	// |> の直後に ?> / +> を続けて書いても同じ段になる
+	withStdin(t, bufio.NewReader(strings.NewReader("INFO a\nERROR b\nERROR c\n")))
+	testStringArray(t, testEval(`stdin_lines |> ?> contains "ERROR" +> to_lower;`), []string{"error b", "error c"})
+
+	// 終わりのない入力も必要な行だけ読み込む
+	withStdin(t, bufio.NewReader(endlessLines{}))
+	testStringArray(t, testEval(`stdin_lines |> take 2;`), []string{"line", "line"})
+
+	dir := t.TempDir()
+	allowed := config.PathPermission{Paths: []string{dir}}
+	withFilePermissions(t, allowed, allowed)
+	path := filepath.Join(dir, "data.txt")
+	if err := os.WriteFile(path, []byte("1\n2\n3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// ファイルのストリームは何度でも走査できる
+	testIntegerObject(t, testEval(fmt.Sprintf(`%q |> file_lines +> to_int >> r; r /> add >> s; r /> add;`, path)), 6)
+	testStringArray(t, testEval(fmt.Sprintf(`%q |> file_lines |> take 2;`, path)), []string{"1", "2"})
+
+	evaluated := testEval(fmt.Sprintf(`%q |> file_lines;`, filepath.Join(dir, "missing.txt")))
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+	if !strings.HasPrefix(errObj.Message, "file_lines関数: 入力を開けませんでした: ") {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+// TestPrintEach は print_each がストリームの要素を計算しながら出力することをテストする
+func TestPrintEach(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+	out := captureOutput(t)
+
+	testNullObject(t, testEval(`[1..] +> mul 2 |> take 3 |> print_each;`))
+	testNullObject(t, testEval(`["a", "b"] |> print_each;`))
+	if got := out.String(); got != "2\n4\n6\na\nb\n" {
+		t.Errorf("wrong output. got=%q", got)
+	}
+}
+
+// testEvalStatements はプログラムの最後の文の値を、プログラム全体の結果として変換せずに返す
+func testEvalStatements(input string) object.Object {
+	l := lexer.NewLexer(input)
+	tokens, _ := l.Tokenize()
+	p := parser.NewParser(tokens)
+	program, _ := p.ParseProgram()
+	env := object.NewEnvironment()
+
+	var result object.Object
+	for _, stmt := range program.Statements {
+		result = Eval(stmt, env)
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+)
+
+// strictMode が有効な場合、暗黙の型変換を一切行わず、型の合わない演算はエラーになる
+var strictMode = false
+
+// warnedCoercionSites は警告済みの暗黙の型変換箇所（行番号と種類）を記録する
+var warnedCoercionSites = map[string]bool{}
+
+// coercedPizza はパイプラインの段の評価中に、入力の文字列から変換した🍕の整数と元の文字列を記録する
+// 変換しただけでは警告せず、演算や組み込み関数がその整数を使ったときに warnCoercedInteger で警告する
+var coercedPizza = map[*object.Integer]coercion{}
+
+// coercion は暗黙の型変換の元の値と、変換したパイプラインの段の行番号
+type coercion struct {
+	source object.Object
+	line   int
+}
+
+// statementLine は評価中の文の行番号
+// 組み込み関数は構文木の位置を持たないため、組み込み関数の中の暗黙の型変換はこの行で警告する
+var statementLine int
+
+// enterStatement は評価中の文の行番号を設定し、元に戻す関数を返す
+func enterStatement(line int) func() {
+	prev := statementLine
+	statementLine = line
+	return func() { statementLine = prev }
+}
+
+// SetStrictMode は厳密モードの有効/無効を設定する
+func SetStrictMode(enabled bool) {
+	strictMode = enabled
+	warnedCoercionSites = map[string]bool{}
+}
+
+// IsStrictMode は厳密モードが有効かどうかを返す
+func IsStrictMode() bool {
+	return strictMode
+}
+
+// warnCoercion は暗黙の型変換が行われた箇所を警告する
+// ループ内で同じ箇所が何度も評価されても、警告は箇所ごとに一度だけ出力する
+func warnCoercion(line int, kind string, value object.Object) {
+	site := fmt.Sprintf("%d:%s", line, kind)
+	if warnedCoercionSites[site] {
+		return
+	}
+	warnedCoercionSites[site] = true
+
+	shown := value.Inspect()
+	if str, ok := value.(*object.String); ok {
+		shown = fmt.Sprintf("%q", str.Value)
+	}
+	logger.Warn("%d行目: 暗黙の型変換: %s (値: %s)。--strict で無効にできます", line, kind, shown)
+}
+
+// warnCoercedInteger は値がパイプラインの入力の文字列から変換した🍕の整数であれば、暗黙の型変換として警告する
+// line が0の場合は変換したパイプラインの段の行番号を使う
+func warnCoercedInteger(line int, values ...object.Object) {
+	if len(coercedPizza) == 0 {
+		return
+	}
+	for _, value := range values {
+		integer, ok := value.(*object.Integer)
+		if !ok {
+			continue
+		}
+		if c, ok := coercedPizza[integer]; ok {
+			site := line
+			if site == 0 {
+				site = c.line
+			}
+			warnCoercion(site, "パイプラインの入力の文字列を整数に変換しました", c.source)
+		}
+	}
+}
+
+// isStringIntegerPair は文字列と整数の組み合わせかどうかを判定する
+func isStringIntegerPair(left, right object.Object) bool {
+	return (left.Type() == object.STRING_OBJ && right.Type() == object.INTEGER_OBJ) ||
+		(left.Type() == object.INTEGER_OBJ && right.Type() == object.STRING_OBJ)
+}
@@ -0,0 +1,97 @@
+package evaluator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+)
+
+// TestImplicitCoercionDefaultMode は通常モードでは暗黙の型変換が行われることをテストする
+func TestImplicitCoercionDefaultMode(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+	SetStrictMode(false)
+
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`"42" + 1;`, 43},
+		{`def inc() { 🍕 + 1 >> 💩 }; "41" |> inc;`, 42},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+// TestStrictModeDisablesCoercion は厳密モードで暗黙の型変換がエラーになることをテストする
+func TestStrictModeDisablesCoercion(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+	SetStrictMode(true)
+	defer SetStrictMode(false)
+
+	tests := []string{
+		`"42" + 1;`,
+		`1 == "1";`,
+		`def inc() { 🍕 + 1 >> 💩 }; "41" |> inc;`,
+		`"007" |> add 1;`,
+		`add("a", true);`,
+	}
+
+	for _, input := range tests {
+		evaluated := testEval(input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error object returned. got=%T (%+v)", input, evaluated, evaluated)
+			continue
+		}
+		if !strings.HasPrefix(errObj.Message, "厳密モード: ") {
+			t.Errorf("%s: wrong error message. got=%q", input, errObj.Message)
+		}
+	}
+
+	// 文字列はパイプラインを通しても文字列のまま
+	testStringObject(t, testEval(`"007" |> to_string;`), "007")
+	// 明示的な変換は厳密モードでも使える
+	testIntegerObject(t, testEval(`"42" |> to_int |> add 1;`), 43)
+}
+
+// TestCoercionWarningOncePerSite は暗黙の型変換の警告が箇所ごとに一度だけ記録されることをテストする
+func TestCoercionWarningOncePerSite(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+	SetStrictMode(false)
+
+	testEval(`def inc() { 🍕 + 1 >> 💩 }; ["1", "2", "3"] +> inc;`)
+
+	if len(warnedCoercionSites) != 1 {
+		t.Errorf("expected 1 coercion site, got=%d (%v)", len(warnedCoercionSites), warnedCoercionSites)
+	}
+}
+
+// TestCoercionWarningOnlyWhenUsed は🍕の変換だけでは警告せず、変換した整数を使ったときだけ警告することをテストする
+func TestCoercionWarningOnlyWhenUsed(t *testing.T) {
+	logger.SetLevel(logger.LevelError)
+
+	tests := []struct {
+		input string
+		sites int
+	}{
+		{`"007" |> to_string;`, 0},
+		{`"007" |> length;`, 0},
+		// 🍕の整数への変換と、add による文字列への変換の2箇所
+		{`"41" |> add 🍕;`, 2},
+		{`"007" |> add 1;`, 1},
+		{`"a" |> add "b";`, 0},
+	}
+
+	for _, tt := range tests {
+		SetStrictMode(false)
+		testEval(tt.input)
+		if len(warnedCoercionSites) != tt.sites {
+			t.Errorf("%s: expected %d coercion sites, got=%d (%v)", tt.input, tt.sites, len(warnedCoercionSites), warnedCoercionSites)
+		}
+	}
+}
@@ -15,6 +15,7 @@ var typeMapping = map[string]object.ObjectType{
 	"null":   object.NULL_OBJ,
 	"array":  object.ARRAY_OBJ,
 	"hash":   object.HASH_OBJ,
+	"stream": object.STREAM_OBJ,
 	"class":  object.CLASS_OBJ,
 	"object": "", // 任意の型を許可
 }
@@ -33,7 +34,11 @@ func checkInputType(input object.Object, expectedType string) (bool, error) {
 	}
 
 	// 実際の型をチェック
+	// 遅延評価のストリームは配列として受け取れる
 	actualType := input.Type()
+	if actualType == object.STREAM_OBJ && expectedObjType == object.ARRAY_OBJ {
+		return true, nil
+	}
 	if actualType != expectedObjType {
 		return false, fmt.Errorf("🍕の型が不正です: 期待=%s, 実際=%s", expectedType, mapObjectTypeToName(actualType))
 	}
@@ -80,6 +85,8 @@ func mapObjectTypeToName(objType object.ObjectType) string {
 		return "array"
 	case object.HASH_OBJ:
 		return "hash"
+	case object.STREAM_OBJ:
+		return "stream"
 	case object.CLASS_OBJ:
 		return "class"
 	case object.INSTANCE_OBJ:
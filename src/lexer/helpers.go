@@ -9,6 +9,8 @@ import (
 func (l *Lexer) readChar() {
 	if l.readPosition >= len(l.input) {
 		l.ch = 0 // EOFを表す
+		// 入力末尾の数値や識別子を正しく切り出せるよう位置を終端に合わせる
+		l.position = len(l.input)
 	} else {
 		// UTF-8文字を正しく読み込む
 		r, size := utf8.DecodeRuneInString(l.input[l.readPosition:])
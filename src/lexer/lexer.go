@@ -21,6 +21,7 @@ func NewLexer(input string) *Lexer {
 		line:  1,
 	}
 	l.readChar() // 最初の文字を読み込む
+	l.skipShebang()
 	return l
 }
 
@@ -56,7 +57,14 @@ func (l *Lexer) NextToken() token.Token {
 			l.skipComment()
 			return l.NextToken() // コメントをスキップした後で次のトークンを取得
 		}
-		tok = l.newToken(token.SLASH, string(l.ch))
+		if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			// 実際の演算子をリテラルとして使用
+			tok = l.newToken(token.FOLD_PIPE, string(ch)+string(l.ch))
+		} else {
+			tok = l.newToken(token.SLASH, string(l.ch))
+		}
 	case '%':
 		tok = l.newToken(token.MODULO, string(l.ch))
 	case '=':
@@ -179,15 +187,26 @@ func (l *Lexer) NextToken() token.Token {
 		startColumn := l.column
 		
 		// 文字列を読み込む
-		literal := l.readString()
+		literal, parts, err := l.readString()
+		if err != nil {
+			// 補間式の中の不正なトークンは、文字列の先頭ではなくその位置で報告する
+			if interpErr, ok := err.(*interpolationError); ok {
+				return interpErr.tok
+			}
+			return token.Token{Type: token.ILLEGAL, Literal: err.Error(), Line: startLine, Column: startColumn}
+		}
 		
-		// トークンを生成
+		// トークンを生成（${...} を含む場合は補間文字列）
 		tok = token.Token{
 			Type:    token.STRING,
 			Literal: literal,
 			Line:    startLine,
 			Column:  startColumn,
 		}
+		if parts != nil {
+			tok.Type = token.INTERP_STRING
+			tok.Parts = parts
+		}
 		return tok
 	case '🍕':
 		tok = l.newToken(token.PIZZA, string(l.ch))
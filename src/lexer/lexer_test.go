@@ -155,3 +155,108 @@ if (5 < 10) {
 		}
 	}
 }
+
+// TestPipeOperators はパイプ演算子のトークン解析をテストする
+func TestPipeOperators(t *testing.T) {
+	input := `[1..3] |> print +> double ?> is_even /> add 0 / 2;`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.DOTDOT, ".."},
+		{token.INT, "3"},
+		{token.RBRACKET, "]"},
+		{token.PIPE, "|>"},
+		{token.IDENT, "print"},
+		{token.MAP_PIPE, "+>"},
+		{token.IDENT, "double"},
+		{token.FILTER_PIPE, "?>"},
+		{token.IDENT, "is_even"},
+		{token.FOLD_PIPE, "/>"},
+		{token.IDENT, "add"},
+		{token.INT, "0"},
+		{token.SLASH, "/"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	l := NewLexer(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+// TestShebang は先頭の #! の行が読み飛ばされ、行番号が保たれることをテストする
+func TestShebang(t *testing.T) {
+	l := NewLexer("#!/usr/bin/env uncode\n1 |> print;")
+
+	tok := l.NextToken()
+	if tok.Type != token.INT || tok.Literal != "1" {
+		t.Fatalf("shebang line should be skipped. got=%q (%q)", tok.Type, tok.Literal)
+	}
+	if tok.Line != 2 {
+		t.Errorf("line number should be kept. expected=2, got=%d", tok.Line)
+	}
+
+	// 先頭以外の #! は読み飛ばさない
+	l = NewLexer("1;\n#!/usr/bin/env uncode")
+	l.NextToken()
+	l.NextToken()
+	if tok = l.NextToken(); tok.Literal != "#" {
+		t.Errorf("#! in the middle of the input should not be skipped. got=%q (%q)", tok.Type, tok.Literal)
+	}
+}
+
+// TestPipeKeywords は map / filter / fold キーワードがパイプ演算子のトークンになることをテストする
+func TestPipeKeywords(t *testing.T) {
+	input := `[1, 2] map double filter is_even fold add; folded;`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RBRACKET, "]"},
+		{token.MAP_PIPE, "map"},
+		{token.IDENT, "double"},
+		{token.FILTER_PIPE, "filter"},
+		{token.IDENT, "is_even"},
+		{token.FOLD_PIPE, "fold"},
+		{token.IDENT, "add"},
+		{token.SEMICOLON, ";"},
+		// 予約語で始まる識別子は予約語にならない
+		{token.IDENT, "folded"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	l := NewLexer(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
@@ -40,6 +40,34 @@ func TestNumberLiterals(t *testing.T) {
 	}
 }
 
+// TestNumberAtEndOfInput は入力末尾の数値と識別子が正しく切り出されることをテストする
+func TestNumberAtEndOfInput(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{"42", token.INT, "42"},
+		{"x + 12", token.INT, "12"},
+		{"3.5", token.FLOAT, "3.5"},
+		{"1 + abc", token.IDENT, "abc"},
+	}
+
+	for _, tt := range tests {
+		tokens, _ := NewLexer(tt.input).Tokenize()
+		// 末尾のEOFの直前のトークンを確認する
+		tok := tokens[len(tokens)-2]
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("%q - tokentype wrong. expected=%q, got=%q", tt.input, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("%q - literal wrong. expected=%q, got=%q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
 // TestComments はコメント機能をテストする
 func TestComments(t *testing.T) {
 	input := `// これはコメントです
@@ -147,3 +147,88 @@ func TestEscapeSequencesInStrings(t *testing.T) {
 		}
 	}
 }
+
+// TestInterpolatedStringLiterals は ${...} を含む文字列リテラルの分割をテストする
+func TestInterpolatedStringLiterals(t *testing.T) {
+	input := `"合計: ${🍕 * 2} 円" "\${escaped}" "${ "}" }"`
+
+	l := NewLexer(input)
+
+	// 1つ目: 文字列部分と式部分に分割される
+	tok := l.NextToken()
+	if tok.Type != token.INTERP_STRING {
+		t.Fatalf("tokentype wrong. expected=%q, got=%q", token.INTERP_STRING, tok.Type)
+	}
+	if len(tok.Parts) != 3 {
+		t.Fatalf("wrong number of parts. expected=3, got=%d", len(tok.Parts))
+	}
+	if tok.Parts[0].IsExpr || tok.Parts[0].Value != "合計: " {
+		t.Errorf("parts[0] wrong. got=%+v", tok.Parts[0])
+	}
+	if !tok.Parts[1].IsExpr || tok.Parts[1].Value != "🍕 * 2" {
+		t.Errorf("parts[1] wrong. got=%+v", tok.Parts[1])
+	}
+	expectedTypes := []token.TokenType{token.PIZZA, token.ASTERISK, token.INT, token.EOF}
+	if len(tok.Parts[1].Tokens) != len(expectedTypes) {
+		t.Fatalf("wrong number of expression tokens. expected=%d, got=%d", len(expectedTypes), len(tok.Parts[1].Tokens))
+	}
+	for i, tt := range expectedTypes {
+		if tok.Parts[1].Tokens[i].Type != tt {
+			t.Errorf("expression tokens[%d] wrong. expected=%q, got=%q", i, tt, tok.Parts[1].Tokens[i].Type)
+		}
+	}
+	if tok.Parts[2].IsExpr || tok.Parts[2].Value != " 円" {
+		t.Errorf("parts[2] wrong. got=%+v", tok.Parts[2])
+	}
+
+	// 2つ目: エスケープされた $ は補間されない
+	tok = l.NextToken()
+	if tok.Type != token.STRING || tok.Literal != "${escaped}" {
+		t.Errorf("escaped string wrong. got=%q (%q)", tok.Literal, tok.Type)
+	}
+
+	// 3つ目: 式の中の文字列リテラルに含まれる } で補間が終わらない
+	tok = l.NextToken()
+	if tok.Type != token.INTERP_STRING || len(tok.Parts) != 1 || tok.Parts[0].Value != ` "}" ` {
+		t.Errorf("nested quote string wrong. got=%+v", tok)
+	}
+
+	if tok = l.NextToken(); tok.Type != token.EOF {
+		t.Errorf("expected EOF. got=%q", tok.Type)
+	}
+}
+
+// TestInterpolationLexError は補間式の中の不正な文字が、その位置の不正なトークンになることをテストする
+func TestInterpolationLexError(t *testing.T) {
+	input := "1;\n\"a ${1 & 2} b\";\n\"${\n  &}\";"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{token.INT, "1", 1, 1},
+		{token.SEMICOLON, ";", 1, 2},
+		// 文字列の先頭ではなく ${ の中の & の位置
+		{token.ILLEGAL, "&", 2, 8},
+		// 文字列の残りは読み飛ばされ、次のトークンから字句解析を続ける
+		{token.SEMICOLON, ";", 2, 15},
+		// 補間式の中の改行も行番号に反映される
+		{token.ILLEGAL, "&", 4, 3},
+		{token.SEMICOLON, ";", 4, 6},
+	}
+
+	l := NewLexer(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%q %q, got=%q %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d", i, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
@@ -1,15 +1,21 @@
 package lexer
 
 import (
+	"fmt"
+	"strings"
 	"unicode"
 	"github.com/uncode/token"
 )
 
 // readString は文字列リテラルを読み込む
-// エスケープシーケンスもサポート
-func (l *Lexer) readString() string {
+// ${...} が含まれる場合は文字列部分と式部分に分割したものを parts として返す
+// （補間を含まない場合 parts は nil）
+func (l *Lexer) readString() (string, []token.StringPart, error) {
 	var result []rune
+	var parts []token.StringPart
+	var interpErr error // 補間式の中で最初に見つかったエラー（文字列の終端までは読み進める）
 	l.readChar() // 最初の " をスキップ
+	contentStart := l.position
 
 	for {
 		// 文字列終端または入力終端に達した場合
@@ -34,6 +40,8 @@ func (l *Lexer) readString() string {
 				result = append(result, '"') // 二重引用符
 			case '\'':
 				result = append(result, '\'') // 一重引用符
+			case '$':
+				result = append(result, '$') // 補間を行わない $
 			case '0':
 				result = append(result, '\x00') // NULL文字
 			case '+', '|', '>', ' ', '\n':
@@ -45,6 +53,28 @@ func (l *Lexer) readString() string {
 				result = append(result, '\\')
 				result = append(result, l.ch)
 			}
+		} else if l.ch == '$' && l.peekChar() == '{' {
+			// 補間式の開始: ここまでの文字列部分を確定する
+			if len(result) > 0 {
+				parts = append(parts, token.StringPart{Value: string(result)})
+				result = nil
+			}
+			
+			exprLine, exprColumn := l.line, l.column+2
+			source, err := l.readInterpolation()
+			if err != nil {
+				return string(result), nil, err
+			}
+			
+			tokens, err := tokenizeInterpolation(source, exprLine, exprColumn)
+			if err != nil && interpErr == nil {
+				interpErr = err
+			}
+			parts = append(parts, token.StringPart{
+				IsExpr: true,
+				Value:  source,
+				Tokens: tokens,
+			})
 		} else {
 			// 通常の文字はそのまま追加
 			result = append(result, l.ch)
@@ -53,12 +83,88 @@ func (l *Lexer) readString() string {
 		l.readChar()
 	}
 	
+	// 補間文字列のリテラルには元のソースをそのまま使う
+	literal := string(result)
+	if parts != nil {
+		if len(result) > 0 {
+			parts = append(parts, token.StringPart{Value: string(result)})
+		}
+		literal = l.input[contentStart:l.position]
+	}
+	
 	// 文字列の終端（閉じ二重引用符）がまだ残っていればスキップ
 	if l.ch == '"' {
 		l.readChar()
 	}
 	
-	return string(result)
+	return literal, parts, interpErr
+}
+
+// readInterpolation は ${ から対応する } までを読み込み、式のソースを返す
+// 式の中の文字列リテラルや入れ子の {} は読み飛ばす。呼び出し後 l.ch は閉じ } を指す
+func (l *Lexer) readInterpolation() (string, error) {
+	startLine := l.line
+	l.readChar() // $ をスキップ
+	l.readChar() // { をスキップ
+	start := l.position
+	depth := 1
+
+	for l.ch != 0 {
+		switch l.ch {
+		case '"':
+			// 式の中の文字列リテラルを読み飛ばす
+			l.readChar()
+			for l.ch != '"' && l.ch != 0 {
+				if l.ch == '\\' {
+					l.readChar()
+				}
+				l.readChar()
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return l.input[start:l.position], nil
+			}
+		}
+		if l.ch != 0 {
+			l.readChar()
+		}
+	}
+
+	return "", fmt.Errorf("%d行目: 文字列補間 ${ が閉じられていません", startLine)
+}
+
+// interpolationError は補間式の中の不正なトークンを表す
+// トークンの位置は文字列リテラル内での位置に補正済み
+type interpolationError struct {
+	tok token.Token
+}
+
+func (e *interpolationError) Error() string {
+	return fmt.Sprintf("%d行目: 文字列補間の中に不正なトークンがあります: %s", e.tok.Line, e.tok.Literal)
+}
+
+// tokenizeInterpolation は補間式のソースをトークン列に変換する
+// 行番号・列番号は文字列リテラル内での位置に合わせて補正する
+// 式の中に不正なトークンがあれば、補正した位置とともにエラーとして返す
+func tokenizeInterpolation(source string, line, column int) ([]token.Token, error) {
+	tokens, err := NewLexer(source).Tokenize()
+	if err != nil {
+		return nil, err
+	}
+	var illegal error
+	for i := range tokens {
+		if tokens[i].Line == 1 {
+			tokens[i].Column += column - 1
+		}
+		tokens[i].Line += line - 1
+		if tokens[i].Type == token.ILLEGAL && illegal == nil {
+			illegal = &interpolationError{tok: tokens[i]}
+		}
+	}
+	return tokens, illegal
 }
 
 // readIdentifier は識別子を読み込む
@@ -120,6 +226,18 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
+// skipShebang は入力の先頭にある "#!" から始まる行をスキップする
+// 実行可能なスクリプト (#!/usr/bin/env uncode) として使えるようにするため
+// 改行文字は読み飛ばさないので、以降の行番号はそのまま保たれる
+func (l *Lexer) skipShebang() {
+	if l.position != 0 || !strings.HasPrefix(l.input, "#!") {
+		return
+	}
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+}
+
 // skipComment はコメントをスキップする
 // '//' から行末までをスキップする
 func (l *Lexer) skipComment() {
@@ -0,0 +1,135 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Format はログの出力形式を表す型
+type Format string
+
+const (
+	FormatText Format = "text" // 人が読むためのテキスト形式
+	FormatJSON Format = "json" // 1行に1つの JSON オブジェクトを出力する形式 (JSON Lines)
+)
+
+// Entry は1件のログ
+type Entry struct {
+	Time      time.Time
+	Level     LogLevel
+	Component ComponentType // コンポーネント。指定がなければ呼び出し元のパッケージから決める
+	Message   string
+	File      string // 呼び出し元のファイル（パッケージのディレクトリ名/ファイル名）
+	Line      int    // 呼び出し元の行番号
+}
+
+// jsonEntry は JSON 形式で出力するログのフィールド
+type jsonEntry struct {
+	Time      string        `json:"ts"`
+	Level     string        `json:"level"`
+	Component ComponentType `json:"component"`
+	Message   string        `json:"msg"`
+	File      string        `json:"file,omitempty"`
+	Line      int           `json:"line,omitempty"`
+}
+
+// jsonTimeFormat は JSON 形式のタイムスタンプの書式 (ミリ秒までの RFC 3339)
+const jsonTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// formatJSON はログを1行の JSON にする
+func formatJSON(e Entry) string {
+	component := e.Component
+	if component == "" {
+		component = ComponentGlobal
+	}
+	data, err := json.Marshal(jsonEntry{
+		Time:      e.Time.Format(jsonTimeFormat),
+		Level:     LevelNames[e.Level],
+		Component: component,
+		Message:   e.Message,
+		File:      e.File,
+		Line:      e.Line,
+	})
+	if err != nil {
+		// 文字列と数値だけなので失敗しないが、ログを失わないようにメッセージだけは残す
+		return fmt.Sprintf(`{"msg":%q}`, e.Message)
+	}
+	return string(data)
+}
+
+// formatText はログをテキスト形式にする
+// showComponent が true の場合はレベル名の後ろにコンポーネント名を付ける
+func (l *Logger) formatText(e Entry, showComponent bool) string {
+	levelName := LevelNames[e.Level]
+
+	var builder strings.Builder
+
+	// タイムスタンプを追加
+	if l.showTime {
+		timestamp := e.Time.Format("2006-01-02 15:04:05.000")
+		builder.WriteString(fmt.Sprintf("[%s] ", timestamp))
+	}
+
+	// レベル名とコンポーネント名を追加（カラーあり/なし）
+	colorCode, hasColor := levelColors[e.Level]
+	if l.useColor && hasColor {
+		builder.WriteString(fmt.Sprintf("%s[%s]%s ", colorCode, levelName, colorReset))
+		if showComponent {
+			builder.WriteString(fmt.Sprintf("%s[%s]%s ", colorCode, string(e.Component), colorReset))
+		}
+	} else {
+		builder.WriteString(fmt.Sprintf("[%s] ", levelName))
+		if showComponent {
+			builder.WriteString(fmt.Sprintf("[%s] ", string(e.Component)))
+		}
+	}
+
+	// メッセージを追加
+	builder.WriteString(e.Message)
+
+	return builder.String()
+}
+
+// loggerPackage はこのパッケージのインポートパス（呼び出し元を探すときに読み飛ばす）
+var loggerPackage = reflect.TypeOf(Logger{}).PkgPath()
+
+// packageComponents はパッケージ名と、コンポーネントの指定がないログのコンポーネントの対応
+var packageComponents = map[string]ComponentType{
+	"lexer":     ComponentLexer,
+	"parser":    ComponentParser,
+	"evaluator": ComponentEval,
+	"object":    ComponentEval,
+	"runtime":   ComponentRuntime,
+}
+
+// caller はログを出力した呼び出し元のファイルと行番号、パッケージから決めたコンポーネントを返す
+// このパッケージの関数は読み飛ばす（パッケージ内のテストは呼び出し元として扱う）
+func caller() (file string, line int, component ComponentType) {
+	var pcs [16]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, loggerPackage+".") || strings.HasSuffix(frame.File, "_test.go") {
+			dir, name := path.Split(frame.File)
+			pkg := path.Base(dir)
+			component = packageComponents[pkg]
+			// 組み込み関数は評価器のパッケージの builtins*.go で定義している
+			if component == ComponentEval && strings.HasPrefix(name, "builtins") {
+				component = ComponentBuiltin
+			}
+			if component == "" {
+				component = ComponentGlobal
+			}
+			return pkg + "/" + name, frame.Line, component
+		}
+		if !more {
+			return "", 0, ComponentGlobal
+		}
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -79,6 +79,14 @@ type Logger struct {
 	isEnabled       bool
 	useColor        bool
 	showTime        bool
+	format          Format                       // 出力形式（テキストまたは JSON）
+	outputs         map[ComponentType]io.Writer  // コンポーネントごとの出力先（指定のないコンポーネントは writer に出力する）
+	recorder        *flightRecorder              // 直近のログを残すフライトレコーダー（nil の場合は記録しない）
+
+	// 出力するレベルのビット集合。設定を変更するたびに refresh で計算し直し、ロックを取らずに読み出す
+	// 無効なログは引数を整形する前に、mu を取らずにこのビット集合だけで捨てる
+	enabledMask     uint32 // Error や Debug などで出力またはフライトレコーダーに記録するレベル
+	componentMask   uint32 // いずれかのコンポーネントの ComponentXXX で出力または記録するレベル
 }
 
 var (
@@ -117,6 +125,20 @@ func WithFileWriter(w io.Writer) LoggerOption {
 	}
 }
 
+// WithComponentWriter はコンポーネントの出力先を設定するオプション
+func WithComponentWriter(component ComponentType, w io.Writer) LoggerOption {
+	return func(l *Logger) {
+		l.outputs[component] = w
+	}
+}
+
+// WithFormat は出力形式を設定するオプション
+func WithFormat(format Format) LoggerOption {
+	return func(l *Logger) {
+		l.format = format
+	}
+}
+
 // WithColor はカラー出力を設定するオプション
 func WithColor(useColor bool) LoggerOption {
 	return func(l *Logger) {
@@ -137,11 +159,13 @@ func NewLogger(options ...LoggerOption) *Logger {
 		globalLevel:     LevelInfo,
 		componentLevels: make(map[ComponentType]LogLevel),
 		specialLevels:   make(map[LogLevel]bool),
+		outputs:         make(map[ComponentType]io.Writer),
 		writer:          os.Stdout,
 		fileWriter:      nil,
 		isEnabled:       true,
 		useColor:        true,
 		showTime:        true,
+		format:          FormatText,
 	}
 	
 	// デフォルトのコンポーネントレベルを設定
@@ -161,6 +185,7 @@ func NewLogger(options ...LoggerOption) *Logger {
 	for _, option := range options {
 		option(logger)
 	}
+	logger.refresh()
 	
 	return logger
 }
@@ -173,6 +198,85 @@ func GetLogger() *Logger {
 	return defaultLogger
 }
 
+// levelBit はレベルに対応するビット
+func levelBit(level LogLevel) uint32 {
+	if level <= LevelOff || level > LevelParserDebug {
+		return 0
+	}
+	return 1 << uint(level)
+}
+
+// refresh は設定から出力するレベルのビット集合を計算し直す。mu を取得した状態で呼び出す
+func (l *Logger) refresh() {
+	var enabled, component uint32
+	for level := LevelError; level <= LevelParserDebug; level++ {
+		bit := levelBit(level)
+		// 特殊ログレベルは専用の有効/無効設定だけで決まる
+		if level >= LevelTypeInfo {
+			if l.specialLevels[level] {
+				enabled |= bit
+				component |= bit
+			}
+			continue
+		}
+		if !l.isEnabled {
+			continue
+		}
+		if level <= l.globalLevel {
+			enabled |= bit
+		}
+		for _, componentLevel := range l.componentLevels {
+			if level <= componentLevel {
+				component |= bit
+				break
+			}
+		}
+	}
+	// フライトレコーダーは出力しないレベルのログも記録する
+	if l.recorder != nil {
+		for level := recordMinLevel; level <= recordMaxLevel; level++ {
+			enabled |= levelBit(level)
+			component |= levelBit(level)
+		}
+	}
+	atomic.StoreUint32(&l.enabledMask, enabled)
+	atomic.StoreUint32(&l.componentMask, component)
+}
+
+// Enabled は level のログが出力またはフライトレコーダーに記録されるかを返す
+// ロックを取らないため、評価器のように頻繁に通る場所で、ログの引数を組み立てる前の確認に使う
+//
+//	if logger.Enabled(logger.LevelDebug) {
+//		logger.Debug("環境: %v", env.GetVariables())
+//	}
+func (l *Logger) Enabled(level LogLevel) bool {
+	return atomic.LoadUint32(&l.enabledMask)&levelBit(level) != 0
+}
+
+// ComponentEnabled はコンポーネント指定付きの level のログが出力または記録されるかを返す
+// どのコンポーネントでも出力しないレベルはロックを取らずに判定する
+func (l *Logger) ComponentEnabled(component ComponentType, level LogLevel) bool {
+	if atomic.LoadUint32(&l.componentMask)&levelBit(level) == 0 {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.componentEnabled(component, level) || (l.recorder != nil && recordable(level))
+}
+
+// componentEnabled は mu を取得した状態でコンポーネントのログレベルを確認する
+func (l *Logger) componentEnabled(component ComponentType, level LogLevel) bool {
+	// 特殊ログレベルの場合は専用の有効/無効設定を使用
+	if level >= LevelTypeInfo {
+		return l.specialLevels[level]
+	}
+	componentLevel, exists := l.componentLevels[component]
+	if !exists {
+		componentLevel = l.componentLevels[ComponentGlobal]
+	}
+	return l.isEnabled && level <= componentLevel
+}
+
 // SetLevel はグローバルログレベルを設定する
 func (l *Logger) SetLevel(level LogLevel) {
 	l.mu.Lock()
@@ -180,6 +284,7 @@ func (l *Logger) SetLevel(level LogLevel) {
 	l.globalLevel = level
 	// グローバルレベルの変更を全コンポーネントに反映（ただし明示的に設定されたものは除く）
 	l.componentLevels[ComponentGlobal] = level
+	l.refresh()
 }
 
 // SetComponentLevel はコンポーネント別ログレベルを設定する
@@ -187,6 +292,7 @@ func (l *Logger) SetComponentLevel(component ComponentType, level LogLevel) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.componentLevels[component] = level
+	l.refresh()
 }
 
 // GetComponentLevel はコンポーネント別ログレベルを取得する
@@ -205,6 +311,7 @@ func (l *Logger) SetSpecialLevelEnabled(level LogLevel, enabled bool) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.specialLevels[level] = enabled
+	l.refresh()
 }
 
 // IsSpecialLevelEnabled は特殊ログレベルが有効かどうかを返す
@@ -232,6 +339,26 @@ func (l *Logger) SetFileOutput(w io.Writer) {
 	l.fileWriter = w
 }
 
+// SetComponentOutput はコンポーネントのログの出力先を設定する。w が nil の場合は通常の出力先に戻す
+// コンポーネントを指定しないログ (Debug など) は、呼び出し元のパッケージのコンポーネントとして振り分ける
+// ファイルへの出力 (SetFileOutput) にはすべてのコンポーネントのログを書き込む
+func (l *Logger) SetComponentOutput(component ComponentType, w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if w == nil {
+		delete(l.outputs, component)
+		return
+	}
+	l.outputs[component] = w
+}
+
+// SetFormat は出力形式を設定する
+func (l *Logger) SetFormat(format Format) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.format = format
+}
+
 // EnableColor はカラー出力を有効にする
 func (l *Logger) EnableColor() {
 	l.mu.Lock()
@@ -265,6 +392,7 @@ func (l *Logger) Enable() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.isEnabled = true
+	l.refresh()
 }
 
 // Disable はロガーを無効にする
@@ -272,135 +400,79 @@ func (l *Logger) Disable() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.isEnabled = false
-}
-
-// formatLogMessage はログメッセージをフォーマットする
-func (l *Logger) formatLogMessage(level LogLevel, format string, args ...interface{}) string {
-	levelName := LevelNames[level]
-	msg := fmt.Sprintf(format, args...)
-	
-	var builder strings.Builder
-	
-	// タイムスタンプを追加
-	if l.showTime {
-		timestamp := time.Now().Format("2006-01-02 15:04:05.000")
-		builder.WriteString(fmt.Sprintf("[%s] ", timestamp))
-	}
-	
-	// レベル名を追加（カラーあり/なし）
-	if l.useColor {
-		colorCode, hasColor := levelColors[level]
-		if hasColor {
-			builder.WriteString(fmt.Sprintf("%s[%s]%s ", colorCode, levelName, colorReset))
-		} else {
-			builder.WriteString(fmt.Sprintf("[%s] ", levelName))
-		}
-	} else {
-		builder.WriteString(fmt.Sprintf("[%s] ", levelName))
-	}
-	
-	// メッセージを追加
-	builder.WriteString(msg)
-	
-	return builder.String()
+	l.refresh()
 }
 
 // log はメッセージを指定されたレベルでログに記録する
 func (l *Logger) log(level LogLevel, format string, args ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	// 特殊ログレベルの判定（LevelTypeInfo, LevelEvalDebugなど）
-	isSpecialLevel := level >= LevelTypeInfo
-	
-	// 特殊ログレベルの場合は専用の有効/無効設定を使用
-	if isSpecialLevel {
-		enabled, exists := l.specialLevels[level]
-		if !exists || !enabled {
-			// 特殊レベルが無効なら出力しない
-			return
-		}
-	} else if !l.isEnabled || level > l.globalLevel {
-		// 通常レベルの場合はグローバルログレベルに基づく
+	// 出力も記録もしないレベルはロックを取らずに捨てる
+	if !l.Enabled(level) {
 		return
 	}
 
-	// フォーマット済みのメッセージを生成
-	formattedMsg := l.formatLogMessage(level, format, args...)
-	
-	// 標準出力に書き込み
-	fmt.Fprintln(l.writer, formattedMsg)
-	
-	// ファイルにも書き込み（設定されている場合）
-	if l.fileWriter != nil {
-		fmt.Fprintln(l.fileWriter, formattedMsg)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 特殊ログレベル（LevelTypeInfo, LevelEvalDebugなど）は専用の有効/無効設定、通常のレベルはグローバルログレベルに基づく
+	write := l.isEnabled && level <= l.globalLevel
+	if level >= LevelTypeInfo {
+		write = l.specialLevels[level]
 	}
+	l.emit("", level, write, format, args...)
 }
 
 // logWithComponent はコンポーネント指定付きでログを記録する
 func (l *Logger) logWithComponent(component ComponentType, level LogLevel, format string, args ...interface{}) {
+	// どのコンポーネントでも出力も記録もしないレベルはロックを取らずに捨てる
+	if atomic.LoadUint32(&l.componentMask)&levelBit(level) == 0 {
+		return
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	// 特殊ログレベルの判定（LevelTypeInfo, LevelEvalDebugなど）
-	isSpecialLevel := level >= LevelTypeInfo
-	
-	if isSpecialLevel {
-		// 特殊ログレベルの場合は専用の有効/無効設定を使用
-		enabled, exists := l.specialLevels[level]
-		if !exists || !enabled {
-			// 特殊レベルが無効なら出力しない
-			return
-		}
-	} else {
-		// 通常レベルの場合はコンポーネントレベルに基づく
-		// コンポーネントのログレベルを確認
-		componentLevel, exists := l.componentLevels[component]
-		if !exists {
-			componentLevel = l.componentLevels[ComponentGlobal]
-		}
+	// コンポーネントのログレベルに基づきフィルタリング
+	l.emit(component, level, l.componentEnabled(component, level), format, args...)
+}
 
-		// コンポーネントのログレベルに基づきフィルタリング
-		if !l.isEnabled || level > componentLevel {
-			return
-		}
+// emit はログを組み立て、write が true なら出力し、フライトレコーダーがあれば記録する。mu を取得した状態で呼び出す
+// component が空の場合はコンポーネントの指定がないログとして、必要な場合だけ呼び出し元のパッケージから決める
+func (l *Logger) emit(component ComponentType, level LogLevel, write bool, format string, args ...interface{}) {
+	record := l.recorder != nil && recordable(level)
+	if !write && !record {
+		return
 	}
 
-	// コンポーネント名を含むフォーマット済みのメッセージを生成
-	msg := fmt.Sprintf(format, args...)
-	levelName := LevelNames[level]
-	
-	var builder strings.Builder
-	
-	// タイムスタンプを追加
-	if l.showTime {
-		timestamp := time.Now().Format("2006-01-02 15:04:05.000")
-		builder.WriteString(fmt.Sprintf("[%s] ", timestamp))
-	}
-	
-	// レベル名とコンポーネント名を追加（カラーあり/なし）
-	if l.useColor {
-		colorCode, hasColor := levelColors[level]
-		if hasColor {
-			builder.WriteString(fmt.Sprintf("%s[%s]%s ", colorCode, levelName, colorReset))
-			builder.WriteString(fmt.Sprintf("%s[%s]%s ", colorCode, string(component), colorReset))
-		} else {
-			builder.WriteString(fmt.Sprintf("[%s] ", levelName))
-			builder.WriteString(fmt.Sprintf("[%s] ", string(component)))
+	e := Entry{Time: time.Now(), Level: level, Component: component, Message: fmt.Sprintf(format, args...)}
+	// 呼び出し元の取得は重いため、JSON 形式・コンポーネントごとの出力先・フライトレコーダーのいずれかを使う場合だけ行う
+	if l.format == FormatJSON || len(l.outputs) > 0 || record {
+		var inferred ComponentType
+		e.File, e.Line, inferred = caller()
+		if e.Component == "" {
+			e.Component = inferred
 		}
+	}
+	if record {
+		l.recorder.add(e)
+	}
+	if !write {
+		return
+	}
+
+	var formattedMsg string
+	if l.format == FormatJSON {
+		formattedMsg = formatJSON(e)
 	} else {
-		builder.WriteString(fmt.Sprintf("[%s] ", levelName))
-		builder.WriteString(fmt.Sprintf("[%s] ", string(component)))
+		formattedMsg = l.formatText(e, component != "")
 	}
-	
-	// メッセージを追加
-	builder.WriteString(msg)
-	
-	formattedMsg := builder.String()
-	
-	// 標準出力に書き込み
-	fmt.Fprintln(l.writer, formattedMsg)
-	
+
+	// 標準出力（コンポーネントの出力先が設定されていればそちら）に書き込み
+	w := l.writer
+	if out, ok := l.outputs[e.Component]; ok {
+		w = out
+	}
+	fmt.Fprintln(w, formattedMsg)
+
 	// ファイルにも書き込み（設定されている場合）
 	if l.fileWriter != nil {
 		fmt.Fprintln(l.fileWriter, formattedMsg)
@@ -494,27 +566,43 @@ func IsSpecialLevelEnabled(level LogLevel) bool {
 	return GetLogger().IsSpecialLevelEnabled(level)
 }
 
-// IsLevelEnabled は指定したログレベルが現在の設定で有効かどうかを返す
+// IsLevelEnabled は指定したログレベルが現在の設定で有効かどうかを返す（Enabled と同じ）
 func (l *Logger) IsLevelEnabled(level LogLevel) bool {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	return l.Enabled(level)
+}
 
-	// 特殊ログレベルの場合
-	if level >= LevelTypeInfo {
-		enabled, exists := l.specialLevels[level]
-		if !exists {
-			return false
-		}
-		return l.isEnabled && enabled
+// IsLevelEnabled はグローバルロガーを使用して、指定したログレベルが有効かを判定する
+func IsLevelEnabled(level LogLevel) bool {
+	return GetLogger().Enabled(level)
+}
+
+// Enabled はグローバルロガーで level のログが出力されるかを、ロックを取らずに返す
+func Enabled(level LogLevel) bool {
+	return GetLogger().Enabled(level)
+}
+
+// ComponentEnabled はグローバルロガーでコンポーネント指定付きの level のログが出力されるかを返す
+func ComponentEnabled(component ComponentType, level LogLevel) bool {
+	return GetLogger().ComponentEnabled(component, level)
+}
+
+// LogFunc は level のログが出力される場合だけ message を呼び出し、その結果を出力する
+// メッセージの組み立てに環境の走査などの重い処理が必要な場合に使う
+func (l *Logger) LogFunc(level LogLevel, message func() string) {
+	if !l.Enabled(level) {
+		return
 	}
+	l.log(level, "%s", message())
+}
 
-	// 通常のログレベルの場合
-	return l.isEnabled && level <= l.globalLevel
+// LogFunc はグローバルロガーを使用して、level のログが出力される場合だけ message の結果を出力する
+func LogFunc(level LogLevel, message func() string) {
+	GetLogger().LogFunc(level, message)
 }
 
-// IsLevelEnabled はグローバルロガーを使用して、指定したログレベルが有効かを判定する
-func IsLevelEnabled(level LogLevel) bool {
-	return GetLogger().IsLevelEnabled(level)
+// DebugFunc はデバッグレベルのログが出力される場合だけ message の結果を出力する
+func DebugFunc(message func() string) {
+	GetLogger().LogFunc(LevelDebug, message)
 }
 
 // Log は指定したレベルでログを出力する（条件チェックなし）
@@ -535,6 +623,14 @@ func SetFileOutput(w io.Writer) {
 	GetLogger().SetFileOutput(w)
 }
 
+func SetComponentOutput(component ComponentType, w io.Writer) {
+	GetLogger().SetComponentOutput(component, w)
+}
+
+func SetFormat(format Format) {
+	GetLogger().SetFormat(format)
+}
+
 func EnableColor() {
 	GetLogger().EnableColor()
 }
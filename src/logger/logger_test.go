@@ -2,6 +2,8 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -164,6 +166,71 @@ func TestLoggerEnable(t *testing.T) {
 	}
 }
 
+// TestEnabled は設定を変更するたびに Enabled と ComponentEnabled の結果が更新されることをテストする
+func TestEnabled(t *testing.T) {
+	var buf bytes.Buffer
+	
+	logger := NewLogger(
+		WithWriter(&buf),
+		WithColor(false),
+		WithTime(false),
+		WithLevel(LevelInfo),
+	)
+	
+	if !logger.Enabled(LevelInfo) || logger.Enabled(LevelDebug) {
+		t.Errorf("Infoレベルのロガーで Enabled が正しくありません")
+	}
+	
+	// レベルを上げると Debug も有効になる
+	logger.SetLevel(LevelDebug)
+	if !logger.Enabled(LevelDebug) || logger.Enabled(LevelTrace) {
+		t.Errorf("SetLevel が Enabled に反映されていません")
+	}
+	
+	// コンポーネントだけ Trace にした場合は、そのコンポーネントだけ有効になる
+	logger.SetComponentLevel(ComponentParser, LevelTrace)
+	if logger.Enabled(LevelTrace) {
+		t.Errorf("コンポーネントのレベルが Enabled に影響しています")
+	}
+	if !logger.ComponentEnabled(ComponentParser, LevelTrace) || logger.ComponentEnabled(ComponentLexer, LevelTrace) {
+		t.Errorf("SetComponentLevel が ComponentEnabled に反映されていません")
+	}
+	
+	// 特殊ログレベルは専用の設定で決まる
+	if logger.Enabled(LevelEvalDebug) {
+		t.Errorf("無効な特殊ログレベルが有効になっています")
+	}
+	logger.SetSpecialLevelEnabled(LevelEvalDebug, true)
+	if !logger.Enabled(LevelEvalDebug) {
+		t.Errorf("SetSpecialLevelEnabled が Enabled に反映されていません")
+	}
+	
+	// 無効化すると通常のレベルはすべて無効になる
+	logger.Disable()
+	if logger.Enabled(LevelError) || logger.ComponentEnabled(ComponentParser, LevelError) {
+		t.Errorf("Disable が Enabled に反映されていません")
+	}
+	logger.Enable()
+	if !logger.Enabled(LevelError) {
+		t.Errorf("Enable が Enabled に反映されていません")
+	}
+	
+	// 出力しないログは引数を整形しない
+	logger.SetLevel(LevelInfo)
+	called := false
+	logger.LogFunc(LevelDebug, func() string {
+		called = true
+		return "デバッグメッセージ"
+	})
+	if called {
+		t.Errorf("無効なレベルで LogFunc のメッセージが組み立てられました")
+	}
+	logger.LogFunc(LevelInfo, func() string { return "遅延メッセージ" })
+	if !strings.Contains(buf.String(), "遅延メッセージ") {
+		t.Errorf("LogFunc のメッセージが出力されていません: %s", buf.String())
+	}
+}
+
 // TestLoggerFormat はログフォーマットをテストする
 func TestLoggerFormat(t *testing.T) {
 	var buf bytes.Buffer
@@ -186,6 +253,134 @@ func TestLoggerFormat(t *testing.T) {
 	}
 }
 
+// TestJSONFormat は JSON 形式のログのフィールドをテストする
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(
+		WithWriter(&buf),
+		WithFormat(FormatJSON),
+	)
+
+	logger.Info("情報 %d", 1)
+	logger.ComponentWarn(ComponentParser, "警告")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("2行出力されるはずです: %q", buf.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("JSON として読み込めません: %v: %s", err, lines[0])
+	}
+	for _, key := range []string{"ts", "level", "component", "msg", "file", "line"} {
+		if _, ok := entry[key]; !ok {
+			t.Errorf("%s フィールドがありません: %s", key, lines[0])
+		}
+	}
+	if entry["level"] != "INFO" || entry["msg"] != "情報 1" || entry["component"] != "global" {
+		t.Errorf("フィールドの値が正しくありません: %s", lines[0])
+	}
+	if entry["file"] != "logger/logger_test.go" {
+		t.Errorf("呼び出し元のファイルが正しくありません: %v", entry["file"])
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("JSON として読み込めません: %v: %s", err, lines[1])
+	}
+	if entry["level"] != "WARN" || entry["component"] != "parser" {
+		t.Errorf("コンポーネント指定付きのログのフィールドが正しくありません: %s", lines[1])
+	}
+}
+
+// TestComponentOutput はコンポーネントごとの出力先をテストする
+func TestComponentOutput(t *testing.T) {
+	var stdout, parserOut, file bytes.Buffer
+	logger := NewLogger(
+		WithWriter(&stdout),
+		WithFileWriter(&file),
+		WithColor(false),
+		WithTime(false),
+		WithComponentWriter(ComponentParser, &parserOut),
+	)
+
+	logger.ComponentInfo(ComponentParser, "パーサーのログ")
+	logger.ComponentInfo(ComponentLexer, "レキサーのログ")
+
+	if !strings.Contains(parserOut.String(), "パーサーのログ") || strings.Contains(stdout.String(), "パーサーのログ") {
+		t.Errorf("パーサーのログはパーサーの出力先だけに書き込まれるはずです: stdout=%q parser=%q", stdout.String(), parserOut.String())
+	}
+	if !strings.Contains(stdout.String(), "レキサーのログ") || strings.Contains(parserOut.String(), "レキサーのログ") {
+		t.Errorf("レキサーのログは標準の出力先に書き込まれるはずです: stdout=%q parser=%q", stdout.String(), parserOut.String())
+	}
+	if !strings.Contains(file.String(), "パーサーのログ") || !strings.Contains(file.String(), "レキサーのログ") {
+		t.Errorf("ファイルにはすべてのログが書き込まれるはずです: %q", file.String())
+	}
+
+	// nil を指定すると標準の出力先に戻る
+	logger.SetComponentOutput(ComponentParser, nil)
+	logger.ComponentInfo(ComponentParser, "戻したログ")
+	if !strings.Contains(stdout.String(), "戻したログ") {
+		t.Errorf("出力先を戻したログが標準の出力先に書き込まれていません: %q", stdout.String())
+	}
+}
+
+// TestFlightRecorder はフライトレコーダーをテストする
+func TestFlightRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(
+		WithWriter(&buf),
+		WithColor(false),
+		WithTime(false),
+		WithLevel(LevelWarn),
+		WithFlightRecorder(3),
+	)
+
+	// 出力しないデバッグログも記録する
+	if !logger.Enabled(LevelDebug) {
+		t.Errorf("フライトレコーダーがある場合はデバッグログを組み立てる必要があります")
+	}
+	for i := 1; i <= 5; i++ {
+		logger.Debug("デバッグ %d", i)
+	}
+	logger.Trace("トレース") // 記録しないはず
+	if strings.Contains(buf.String(), "デバッグ") {
+		t.Errorf("デバッグログが出力されています: %s", buf.String())
+	}
+
+	// 最後の3件だけが古い順に残るはず
+	entries := logger.FlightRecorderEntries()
+	if len(entries) != 3 {
+		t.Fatalf("3件記録されるはずです: %d 件", len(entries))
+	}
+	for i, e := range entries {
+		expected := fmt.Sprintf("デバッグ %d", i+3)
+		if e.Message != expected || e.Level != LevelDebug {
+			t.Errorf("%d 件目が正しくありません: %+v", i, e)
+		}
+		if e.File != "logger/logger_test.go" || e.Line == 0 {
+			t.Errorf("呼び出し元が記録されていません: %+v", e)
+		}
+	}
+
+	var dump bytes.Buffer
+	if err := logger.DumpFlightRecorder(&dump); err != nil {
+		t.Fatalf("書き出しに失敗しました: %v", err)
+	}
+	if !strings.Contains(dump.String(), "3 件") || !strings.Contains(dump.String(), "[DEBUG] [global] デバッグ 5 (logger/logger_test.go:") {
+		t.Errorf("書き出した内容が正しくありません: %s", dump.String())
+	}
+
+	// 0 を指定すると記録しなくなる
+	logger.SetFlightRecorder(0)
+	if logger.Enabled(LevelDebug) {
+		t.Errorf("フライトレコーダーを外した後もデバッグログが有効になっています")
+	}
+	if entries := logger.FlightRecorderEntries(); entries != nil {
+		t.Errorf("フライトレコーダーを外した後もログが残っています: %v", entries)
+	}
+}
+
 // TestLoggerParseLevel はログレベル文字列解析をテストする
 func TestLoggerParseLevel(t *testing.T) {
 	tests := []struct {
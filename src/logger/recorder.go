@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+)
+
+// フライトレコーダーに記録するレベル（Error から Debug まで）
+// 出力するレベルに関係なく記録するため、デバッグログを無効にしていても異常終了の直前の様子がわかる
+const (
+	recordMinLevel = LevelError
+	recordMaxLevel = LevelDebug
+)
+
+// flightRecorder は直近のログを決まった件数だけ残すリングバッファ
+type flightRecorder struct {
+	entries []Entry
+	next    int // 次に書き込む位置
+	count   int // 記録している件数
+}
+
+// newFlightRecorder は size 件を残すフライトレコーダーを作成する
+func newFlightRecorder(size int) *flightRecorder {
+	return &flightRecorder{entries: make([]Entry, size)}
+}
+
+// add はログを記録する。いっぱいの場合は最も古いログを上書きする
+func (r *flightRecorder) add(e Entry) {
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	if r.count < len(r.entries) {
+		r.count++
+	}
+}
+
+// snapshot は記録しているログを古い順に返す
+func (r *flightRecorder) snapshot() []Entry {
+	entries := make([]Entry, 0, r.count)
+	start := r.next - r.count
+	if start < 0 {
+		start += len(r.entries)
+	}
+	for i := 0; i < r.count; i++ {
+		entries = append(entries, r.entries[(start+i)%len(r.entries)])
+	}
+	return entries
+}
+
+// recordable はフライトレコーダーに記録するレベルかどうかを返す
+func recordable(level LogLevel) bool {
+	return level >= recordMinLevel && level <= recordMaxLevel
+}
+
+// WithFlightRecorder は直近の size 件のログを残すフライトレコーダーを設定するオプション
+func WithFlightRecorder(size int) LoggerOption {
+	return func(l *Logger) {
+		l.recorder = nil
+		if size > 0 {
+			l.recorder = newFlightRecorder(size)
+		}
+	}
+}
+
+// SetFlightRecorder は直近の size 件のログを残すフライトレコーダーを設定する
+// 出力するレベルに関係なく Error から Debug までのログを記録する。size が0の場合は記録しない
+// 記録する間はデバッグログのメッセージを組み立てるため、評価器が遅くなる
+func (l *Logger) SetFlightRecorder(size int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	WithFlightRecorder(size)(l)
+	l.refresh()
+}
+
+// FlightRecorderEntries はフライトレコーダーに記録しているログを古い順に返す
+func (l *Logger) FlightRecorderEntries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.recorder == nil {
+		return nil
+	}
+	return l.recorder.snapshot()
+}
+
+// DumpFlightRecorder はフライトレコーダーに記録しているログを古い順に w に書き出す
+// 実行時エラーやパニックで終了する直前に呼び出す。JSON 形式の場合は見出しを付けずに1行ずつ書き出す
+func (l *Logger) DumpFlightRecorder(w io.Writer) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.recorder == nil || l.recorder.count == 0 {
+		return nil
+	}
+	entries := l.recorder.snapshot()
+	if l.format != FormatJSON {
+		if _, err := fmt.Fprintf(w, "--- 直近のログ (フライトレコーダー、%d 件) ---\n", len(entries)); err != nil {
+			return err
+		}
+	}
+	for _, e := range entries {
+		line := formatJSON(e)
+		if l.format != FormatJSON {
+			line = fmt.Sprintf("[%s] [%s] [%s] %s (%s:%d)",
+				e.Time.Format("2006-01-02 15:04:05.000"), LevelNames[e.Level], e.Component, e.Message, e.File, e.Line)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetFlightRecorder はグローバルロガーに直近の size 件のログを残すフライトレコーダーを設定する
+func SetFlightRecorder(size int) {
+	GetLogger().SetFlightRecorder(size)
+}
+
+// DumpFlightRecorder はグローバルロガーのフライトレコーダーに記録しているログを w に書き出す
+func DumpFlightRecorder(w io.Writer) error {
+	return GetLogger().DumpFlightRecorder(w)
+}
@@ -38,17 +38,137 @@ type HashPair struct {
 	Value Object
 }
 
-// Hash はハッシュマップを表す
+// Hash はキーの挿入順を保持するハッシュマップを表す
+// index で HashKey から pairs の位置を O(1) で引き、pairs を挿入順に走査する
+// HashKey が衝突したキーは同じ index のスロットに並べ、キーの値を比較して区別する
 type Hash struct {
-	Pairs map[HashKey]HashPair
-	Poo   Object // 💩メンバ
+	index   map[HashKey][]int // HashKey から pairs の位置への索引
+	pairs   []HashPair        // 挿入順のペア（削除済みのペアは Key が nil）
+	deleted int               // 削除済みのペアの数
+	Poo     Object            // 💩メンバ
+}
+
+// NewHash は空のハッシュマップを作成する
+func NewHash() *Hash {
+	return &Hash{index: make(map[HashKey][]int)}
+}
+
+// lookup はキーに対応する pairs の位置を返す
+func (h *Hash) lookup(key Hashable) (HashKey, int) {
+	hashKey := key.HashKey()
+	for _, i := range h.index[hashKey] {
+		if keysEqual(h.pairs[i].Key, key.(Object)) {
+			return hashKey, i
+		}
+	}
+	return hashKey, -1
+}
+
+// Set はキーと値を設定する
+// 既存のキーを上書きした場合、挿入順の位置は変わらない
+func (h *Hash) Set(key Hashable, value Object) {
+	if h.index == nil {
+		h.index = make(map[HashKey][]int)
+	}
+	hashKey, i := h.lookup(key)
+	if i >= 0 {
+		h.pairs[i].Value = value
+		return
+	}
+	h.index[hashKey] = append(h.index[hashKey], len(h.pairs))
+	h.pairs = append(h.pairs, HashPair{Key: key.(Object), Value: value})
+}
+
+// Get はキーに対応する値を取得する
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	_, i := h.lookup(key)
+	if i < 0 {
+		return nil, false
+	}
+	return h.pairs[i].Value, true
+}
+
+// Delete はキーを削除し、削除できたかどうかを返す
+func (h *Hash) Delete(key Hashable) bool {
+	hashKey, i := h.lookup(key)
+	if i < 0 {
+		return false
+	}
+
+	slot := h.index[hashKey]
+	for j, pos := range slot {
+		if pos == i {
+			slot = append(slot[:j], slot[j+1:]...)
+			break
+		}
+	}
+	if len(slot) == 0 {
+		delete(h.index, hashKey)
+	} else {
+		h.index[hashKey] = slot
+	}
+
+	h.pairs[i] = HashPair{}
+	h.deleted++
+	// 削除済みのペアが半分を超えたら詰め直す
+	if h.deleted*2 > len(h.pairs) {
+		h.compact()
+	}
+	return true
+}
+
+// compact は削除済みのペアを取り除いて索引を作り直す
+func (h *Hash) compact() {
+	pairs := h.Pairs()
+	h.pairs = pairs
+	h.deleted = 0
+	h.index = make(map[HashKey][]int, len(pairs))
+	for i, pair := range pairs {
+		hashKey := pair.Key.(Hashable).HashKey()
+		h.index[hashKey] = append(h.index[hashKey], i)
+	}
+}
+
+// Len はペアの数を返す
+func (h *Hash) Len() int {
+	return len(h.pairs) - h.deleted
+}
+
+// Pairs は挿入順に並んだキーと値のペアを返す
+func (h *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, 0, h.Len())
+	for _, pair := range h.pairs {
+		if pair.Key != nil {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// keysEqual は HashKey が同じ2つのキーが実際に等しいかを判定する
+func keysEqual(a, b Object) bool {
+	switch a := a.(type) {
+	case *Integer:
+		b, ok := b.(*Integer)
+		return ok && a.Value == b.Value
+	case *Float:
+		b, ok := b.(*Float)
+		return ok && a.Value == b.Value
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	}
+	return a == b
 }
 
 func (h *Hash) Type() ObjectType { return HASH_OBJ }
 func (h *Hash) Inspect() string {
 	var out bytes.Buffer
 	pairs := []string{}
-	for _, pair := range h.Pairs {
+	for _, pair := range h.Pairs() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s",
 			pair.Key.Inspect(), pair.Value.Inspect()))
 	}
@@ -0,0 +1,115 @@
+package object
+
+import (
+	"fmt"
+	"testing"
+)
+
+// collidingKey はすべての値が同じ HashKey になるテスト用のキー
+type collidingKey struct {
+	Value string
+}
+
+func (k collidingKey) Type() ObjectType  { return "COLLIDING" }
+func (k collidingKey) Inspect() string   { return k.Value }
+func (k collidingKey) HashKey() HashKey { return HashKey{Type: k.Type(), Value: 42} }
+
+// TestHashInsertionOrder はハッシュが挿入順を保持することをテストする
+func TestHashInsertionOrder(t *testing.T) {
+	h := NewHash()
+	for _, key := range []string{"z", "a", "m", "b"} {
+		h.Set(&String{Value: key}, &Integer{Value: int64(len(key))})
+	}
+	// 既存のキーの上書きでは位置が変わらない
+	h.Set(&String{Value: "a"}, &Integer{Value: 100})
+
+	expected := "{z: 1, a: 100, m: 1, b: 1}"
+	for i := 0; i < 10; i++ {
+		if got := h.Inspect(); got != expected {
+			t.Fatalf("Inspect should be deterministic. expected=%q, got=%q", expected, got)
+		}
+	}
+
+	value, ok := h.Get(&String{Value: "a"})
+	if !ok || value.(*Integer).Value != 100 {
+		t.Errorf("Get(a) wrong. got=%v, %t", value, ok)
+	}
+	if _, ok := h.Get(&String{Value: "x"}); ok {
+		t.Errorf("Get(x) should not be found")
+	}
+}
+
+// TestHashKeyCollisions は HashKey が衝突したキーを区別できることをテストする
+func TestHashKeyCollisions(t *testing.T) {
+	h := NewHash()
+	h.Set(collidingKey{"a"}, &Integer{Value: 1})
+	h.Set(collidingKey{"b"}, &Integer{Value: 2})
+	h.Set(collidingKey{"a"}, &Integer{Value: 3})
+
+	if h.Len() != 2 {
+		t.Fatalf("colliding keys should be distinct. len=%d", h.Len())
+	}
+	if got := h.Inspect(); got != "{a: 3, b: 2}" {
+		t.Errorf("wrong hash. got=%q", got)
+	}
+
+	if !h.Delete(collidingKey{"a"}) {
+		t.Fatalf("Delete(a) should succeed")
+	}
+	value, ok := h.Get(collidingKey{"b"})
+	if !ok || value.(*Integer).Value != 2 {
+		t.Errorf("Get(b) after deleting a wrong. got=%v, %t", value, ok)
+	}
+	if _, ok := h.Get(collidingKey{"a"}); ok {
+		t.Errorf("Get(a) should not be found after delete")
+	}
+
+	// 型が異なるキーは HashKey の値が同じでも別のキーになる
+	h2 := NewHash()
+	h2.Set(&Integer{Value: 1}, &String{Value: "int"})
+	h2.Set(&Boolean{Value: true}, &String{Value: "bool"})
+	if h2.Len() != 2 {
+		t.Errorf("integer 1 and true should be distinct keys. len=%d", h2.Len())
+	}
+
+	// -0 と 0 は同じキーとして扱う
+	h3 := NewHash()
+	h3.Set(&Float{Value: 0}, &String{Value: "zero"})
+	negZero := &Float{Value: 0}
+	negZero.Value = -negZero.Value
+	h3.Set(negZero, &String{Value: "negative zero"})
+	if h3.Len() != 1 {
+		t.Errorf("0.0 and -0.0 should be the same key. len=%d", h3.Len())
+	}
+}
+
+// TestHashDelete は削除と詰め直しの後も順序と検索が正しいことをテストする
+func TestHashDelete(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Set(&Integer{Value: int64(i)}, &String{Value: fmt.Sprint(i)})
+	}
+	for i := 0; i < 10; i += 2 {
+		if !h.Delete(&Integer{Value: int64(i)}) {
+			t.Fatalf("Delete(%d) should succeed", i)
+		}
+	}
+	// 偶数を消し終えた後にさらに消すと詰め直しが発生する
+	h.Delete(&Integer{Value: 1})
+	if h.Delete(&Integer{Value: 1}) {
+		t.Errorf("deleting a missing key should return false")
+	}
+	h.Set(&Integer{Value: 0}, &String{Value: "new"})
+
+	if got := h.Inspect(); got != "{3: 3, 5: 5, 7: 7, 9: 9, 0: new}" {
+		t.Errorf("wrong hash after delete. got=%q", got)
+	}
+	if h.Len() != 5 {
+		t.Errorf("wrong length. got=%d", h.Len())
+	}
+	for _, i := range []int64{3, 5, 7, 9, 0} {
+		if _, ok := h.Get(&Integer{Value: i}); !ok {
+			t.Errorf("Get(%d) should be found", i)
+		}
+	}
+}
@@ -89,9 +89,12 @@ func (e *Environment) GetAllFunctionsByName(name string) []*Function {
 		// そのようなキーも検索し、関数オブジェクトを取得
 		for key, obj := range env.store {
 			if (key == name) {
-				logger.Debug("%#v", obj)
+				if logger.Enabled(logger.LevelDebug) {
+					logger.Debug("%#v", obj)
+				}
 			}
-			if (key == name) || (len(key) > len(name) && key[:len(name)+1] == name+"#") {
+			// name+"#" の連結はキーごとに文字列を割り当てるため、区切りの # と名前を別々に比べる
+			if (key == name) || (len(key) > len(name) && key[len(name)] == '#' && key[:len(name)] == name) {
 				if fn, ok := obj.(*Function); ok {
 						functions = append(functions, fn)
 					// // まだ処理済みでなければ追加
@@ -112,14 +115,18 @@ func (e *Environment) GetAllFunctionsByName(name string) []*Function {
 	collectFunctions(e)
 
 	// デバッグ情報
-	logger.Debug("関数 '%s' の候補を %d 個見つけました", name, len(functions))
-	for i, fn := range functions {
-		// 条件の有無を表示
-		hasCondition := "なし"
-		if fn.Condition != nil {
-			hasCondition = "あり"
+	if logger.Enabled(logger.LevelDebug) {
+		logger.Debug("関数 '%s' の候補を %d 個見つけました", name, len(functions))
+	}
+	if logger.Enabled(logger.LevelTrace) {
+		for i, fn := range functions {
+			// 条件の有無を表示
+			hasCondition := "なし"
+			if fn.Condition != nil {
+				hasCondition = "あり"
+			}
+			logger.Trace("  関数候補 %d: 条件=%s", i+1, hasCondition)
 		}
-		logger.Trace("  関数候補 %d: 条件=%s", i+1, hasCondition)
 	}
 
 	return functions
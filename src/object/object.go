@@ -17,6 +17,7 @@ const (
 	HASH_OBJ         = "HASH"
 	CLASS_OBJ        = "CLASS"
 	INSTANCE_OBJ     = "INSTANCE"
+	STREAM_OBJ       = "STREAM"
 	
 	// 特殊な型
 	ANY_OBJ          = "ANY"     // どの型でも受け付ける
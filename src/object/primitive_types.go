@@ -40,8 +40,12 @@ func (f *Float) GetPooValue() Object {
 }
 func (f *Float) SetPooValue(val Object) { f.Poo = val }
 func (f *Float) HashKey() HashKey {
+	value := f.Value
+	if value == 0 {
+		value = 0 // -0 と 0 を同じキーとして扱う
+	}
 	h := fnv.New64a()
-	h.Write([]byte(fmt.Sprintf("%g", f.Value)))
+	h.Write([]byte(fmt.Sprintf("%g", value)))
 	return HashKey{Type: f.Type(), Value: h.Sum64()}
 }
 
@@ -127,10 +131,32 @@ func (rv *ReturnValue) GetPooValue() Object {
 }
 func (rv *ReturnValue) SetPooValue(val Object) { rv.Poo = val }
 
+// ErrorKind はエラーの種類を表す
+type ErrorKind int
+
+const (
+	RuntimeError ErrorKind = iota // 実行時エラー
+	TypeError                     // 型注釈や演算子の型が合わないエラー
+	LimitError                    // 関数呼び出しの深さなどの上限を超えたエラー
+)
+
+// StackFrame はエラーが伝わった関数呼び出しを表す
+type StackFrame struct {
+	Function string // 呼び出された関数の名前
+	Line     int    // 呼び出し元の行番号（不明な場合は0）
+	Column   int    // 呼び出し元の列番号
+}
+
 // Error はエラー値を表す
 type Error struct {
-	Message string
-	Poo     Object // 💩メンバ
+	Message  string
+	Kind     ErrorKind    // エラーの種類
+	Line     int          // エラーが発生した文の行番号（不明な場合は0）
+	Column   int          // エラーが発生した文の列番号
+	Stack    []StackFrame // エラーが伝わった関数呼び出し（内側から順）
+	Exit     bool         // exit 関数によるプログラムの終了を表す（エラーではない）
+	ExitCode int          // Exit が true の場合の終了コード
+	Poo      Object       // 💩メンバ
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
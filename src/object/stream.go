@@ -0,0 +1,45 @@
+package object
+
+// Iterator は遅延評価される値の列から値を1つずつ取り出す関数
+// 値がなくなると false を返す。値の計算中に起きたエラーは *Error として返す
+type Iterator func() (Object, bool)
+
+// Stream は遅延評価される値の列を表す
+// 範囲式やパイプラインの各段は配列を作らずに Stream をつなぎ、値は必要になったときに1つずつ計算される
+// Iterate は走査のたびに先頭から始まる新しいイテレータを作るため、同じ Stream を何度でも走査できる
+type Stream struct {
+	Iterate    func() Iterator
+	Infinite   bool   // 終わりのない列かどうか（[1..] など）
+	Replayable bool   // 走査し直しても値を計算し直さず、副作用もないかどうか（範囲式や配列など）
+	Poo        Object // 💩メンバ
+
+	// Stop は開いている読み込み元（ファイルなど）を閉じる関数（閉じるものがなければ nil）
+	// 最後まで走査した場合は読み込み元が自分で閉じるため、途中で走査をやめる場合だけ必要になる
+	Stop func()
+}
+
+// Close は走査を途中でやめたときに呼び、開いている読み込み元を閉じる
+// 何度呼んでもよく、最後まで走査した後に呼んでも何もしない
+func (s *Stream) Close() {
+	if s.Stop != nil {
+		s.Stop()
+	}
+}
+
+func (s *Stream) Type() ObjectType { return STREAM_OBJ }
+
+// Inspect は値を計算せずにストリームであることだけを表す
+// 要素を表示するには配列に変換する必要がある
+func (s *Stream) Inspect() string {
+	if s.Infinite {
+		return "<stream ...>"
+	}
+	return "<stream>"
+}
+func (s *Stream) GetPooValue() Object {
+	if s.Poo == nil {
+		s.Poo = s // デフォルトでは自分自身
+	}
+	return s.Poo
+}
+func (s *Stream) SetPooValue(val Object) { s.Poo = val }
@@ -127,9 +127,7 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 			}
 		} else {
 			// array[..] の形式（両方なし）
-			if !p.expectPeek(token.RBRACKET) {
-				return nil
-			}
+			// 現在のトークンが既に ] なので読み進めない
 		}
 		
 		// 範囲式を直接返す
@@ -157,9 +155,7 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 			}
 		} else {
 			// array[start..] の形式（終了値なし）
-			if !p.expectPeek(token.RBRACKET) {
-				return nil
-			}
+			// 現在のトークンが既に ] なので読み進めない
 		}
 		
 		// 範囲式を直接返す
@@ -206,6 +202,19 @@ func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
 	// 次のトークンに進む
 	p.nextToken()
 	
+	// |> の直後の +> / ?> / /> （stdin_lines |> ?> f など）は、|> を省いた形と同じ段として扱う
+	if pipeToken.Type == token.PIPE && (p.curTokenIs(token.MAP_PIPE) ||
+		p.curTokenIs(token.FILTER_PIPE) || p.curTokenIs(token.FOLD_PIPE)) {
+		pipeToken = p.curToken
+		p.nextToken()
+	}
+	
+	// eq や not はキーワードだが、パイプラインの段では組み込み関数名として扱う
+	if (p.curTokenIs(token.EQ) && p.curToken.Literal == "eq") ||
+		(p.curTokenIs(token.NOT) && p.curToken.Literal == "not") {
+		p.curToken.Type = token.IDENT
+	}
+	
 	// 現在のトークンと次のトークンを記録（デバッグ用）
 	logger.ParserDebug("パイプライン右辺の解析中：現在のトークン=%s, 次のトークン=%s", 
 		p.curToken.Literal, p.peekToken.Literal)
@@ -251,9 +260,11 @@ func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
 			// それを関数の引数として処理する
 			if !p.peekTokenIs(token.PIPE) && !p.peekTokenIs(token.PIPE_PAR) && 
 			   !p.peekTokenIs(token.MAP_PIPE) && !p.peekTokenIs(token.FILTER_PIPE) &&
+			   !p.peekTokenIs(token.FOLD_PIPE) &&
 			   !p.peekTokenIs(token.ASSIGN) && !p.peekTokenIs(token.SEMICOLON) &&
 			   !p.peekTokenIs(token.RPAREN) && !p.peekTokenIs(token.RBRACE) &&
-			   !p.peekTokenIs(token.RBRACKET) && !p.peekTokenIs(token.COMMA) {
+			   !p.peekTokenIs(token.RBRACKET) && !p.peekTokenIs(token.COMMA) &&
+			   !p.peekTokenIs(token.EOF) {
 				
 				logger.ParserDebug("引数として処理可能なトークンが続きます: %s (%s)", p.peekToken.Literal, p.peekToken.Type)
 				
@@ -333,6 +344,21 @@ func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
 			}
 		}
 		
+		// 「func arg1 arg2 ...」のように括弧なしで複数の引数が続く場合
+		// parseIdentifierは最初の引数までしか読まないため、残りの引数をここで収集する
+		if callExpr, ok := rightExp.(*ast.CallExpression); ok {
+			if _, isIdent := callExpr.Function.(*ast.Identifier); isIdent && callExpr.Token.Type != token.LPAREN {
+				for p.peekTokenIs(token.INT) || p.peekTokenIs(token.FLOAT) || p.peekTokenIs(token.STRING) ||
+					p.peekTokenIs(token.INTERP_STRING) || p.peekTokenIs(token.BOOLEAN) || p.peekTokenIs(token.IDENT) || p.peekTokenIs(token.PIZZA) {
+					p.nextToken()
+					if arg := p.parseBareArgument(); arg != nil {
+						callExpr.Arguments = append(callExpr.Arguments, arg)
+						logger.ParserDebug("解析された追加引数: %s (タイプ: %T)", arg.String(), arg)
+					}
+				}
+			}
+		}
+
 		// 通常のパイプライン式として処理
 		return &ast.InfixExpression{
 			Token:    pipeToken,
@@ -343,6 +369,28 @@ func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
 	}
 }
 
+// parseBareArgument は括弧なしの関数呼び出しの引数を1つだけ解析する
+// 識別子は後続のトークンを引数として取り込まないよう、単なる識別子として扱う
+func (p *Parser) parseBareArgument() ast.Expression {
+	switch p.curToken.Type {
+	case token.INT:
+		return p.parseIntegerLiteral()
+	case token.FLOAT:
+		return p.parseFloatLiteral()
+	case token.STRING:
+		return p.parseStringLiteral()
+	case token.INTERP_STRING:
+		return p.parseInterpolatedString()
+	case token.BOOLEAN:
+		return p.parseBooleanLiteral()
+	case token.PIZZA:
+		return p.parsePizzaLiteral()
+	case token.IDENT:
+		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+	return nil
+}
+
 // parseAssignExpression は代入式を解析する
 func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
 	expression := &ast.InfixExpression{
@@ -1,8 +1,6 @@
 package parser
 
 import (
-	"fmt"
-
 	"github.com/uncode/ast"
 	"github.com/uncode/logger"
 	"github.com/uncode/token"
@@ -120,15 +118,9 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	
 	logger.Debug("関数呼び出し解析中: 関数=%s, 引数数=%d", function.String(), len(args))
 	
-	// スタンドアロンな関数呼び出しの場合、引数は最大1つまで
-	if len(args) > 1 {
-		p.errors = append(p.errors, fmt.Sprintf("%d行目: 関数 %s は最大1つの引数しか取れません（パイプラインを除く）", 
-			p.curToken.Line, function.String()))
-		// エラーの場合でも、最初の引数だけを使用して解析を続行
-		exp.Arguments = []ast.Expression{args[0]}
-	} else {
-		exp.Arguments = args
-	}
+	// 組み込み関数は複数の引数を取るため、引数の数はここでは制限しない
+	// （ユーザー定義関数に渡された引数の扱いは評価器に任せる）
+	exp.Arguments = args
 	
 	return exp
 }
@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"fmt"
 	"strconv"
 
 	"github.com/uncode/ast"
@@ -16,7 +15,8 @@ func (p *Parser) parseIdentifier() ast.Expression {
 	// 識別子の後に引数になりうるトークンが続いていて、かつ括弧ではない場合
 	// 例: func arg (括弧なしの関数呼び出し)
 	if p.peekTokenIs(token.INT) || p.peekTokenIs(token.STRING) || 
-	   p.peekTokenIs(token.IDENT) || p.peekTokenIs(token.BOOLEAN) {
+	   p.peekTokenIs(token.IDENT) || p.peekTokenIs(token.BOOLEAN) ||
+	   p.peekTokenIs(token.INTERP_STRING) {
 		
 		// 次のトークンに進む
 		p.nextToken()
@@ -30,6 +30,8 @@ func (p *Parser) parseIdentifier() ast.Expression {
 			arg = p.parseIntegerLiteral()
 		case token.STRING:
 			arg = p.parseStringLiteral()
+		case token.INTERP_STRING:
+			arg = p.parseInterpolatedString()
 		case token.BOOLEAN:
 			arg = p.parseBooleanLiteral()
 		case token.IDENT:
@@ -50,14 +52,23 @@ func (p *Parser) parseIdentifier() ast.Expression {
 	return ident
 }
 
+// parseKeywordFunctionName はキーワードとして字句解析される組み込み関数名（eq）を識別子として解析する
+func (p *Parser) parseKeywordFunctionName() ast.Expression {
+	if p.curToken.Literal != "eq" {
+		p.noPrefixParseFnError(p.curToken.Type)
+		return nil
+	}
+	p.curToken.Type = token.IDENT
+	return p.parseIdentifier()
+}
+
 // parseIntegerLiteral は整数リテラルを解析する
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("整数 '%s' を解析できませんでした", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken, "整数 '%s' を解析できませんでした", p.curToken.Literal)
 		return nil
 	}
 
@@ -71,8 +82,7 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		msg := fmt.Sprintf("浮動小数点数 '%s' を解析できませんでした", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken, "浮動小数点数 '%s' を解析できませんでした", p.curToken.Literal)
 		return nil
 	}
 
@@ -85,12 +95,47 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+// parseInterpolatedString は ${...} を含む補間文字列リテラルを解析する
+// 式部分はレキサーが分割したトークン列を別のパーサーで解析する
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	interp := &ast.InterpolatedString{Token: p.curToken}
+
+	for _, part := range p.curToken.Parts {
+		if !part.IsExpr {
+			interp.Parts = append(interp.Parts, &ast.StringLiteral{Token: p.curToken, Value: part.Value})
+			continue
+		}
+
+		sub := NewParser(part.Tokens)
+		program, _ := sub.ParseProgram()
+		if len(sub.parseErrors) > 0 {
+			for _, e := range sub.parseErrors {
+				p.errorAt(p.curToken, "文字列補間 ${%s} の解析エラー: %s", part.Value, e.Message)
+			}
+			return nil
+		}
+
+		// 補間式はちょうど1つの式である必要がある
+		if len(program.Statements) != 1 {
+			p.errorAt(p.curToken, "文字列補間 ${%s} には1つの式を指定してください", part.Value)
+			return nil
+		}
+		exprStmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok || exprStmt.Expression == nil {
+			p.errorAt(p.curToken, "文字列補間 ${%s} には式を指定してください", part.Value)
+			return nil
+		}
+		interp.Parts = append(interp.Parts, exprStmt.Expression)
+	}
+
+	return interp
+}
+
 // parseBooleanLiteral は真偽値リテラルを解析する
 func (p *Parser) parseBooleanLiteral() ast.Expression {
 	value, err := strconv.ParseBool(p.curToken.Literal)
 	if err != nil {
-		msg := fmt.Sprintf("真偽値 '%s' を解析できませんでした", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken, "真偽値 '%s' を解析できませんでした", p.curToken.Literal)
 		return nil
 	}
 	return &ast.BooleanLiteral{Token: p.curToken, Value: value}
@@ -123,9 +168,7 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 			}
 		} else {
 			// [start..] の形式（終了値なし）
-			if !p.expectPeek(token.RBRACKET) {
-				return nil
-			}
+			// 現在のトークンが既に ] なので読み進めない
 		}
 		
 		return rangeExp
@@ -147,16 +190,29 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 			}
 		} else {
 			// [..] の形式（両方なし）
-			if !p.expectPeek(token.RBRACKET) {
-				return nil
-			}
+			// 現在のトークンが既に ] なので読み進めない
 		}
 		
 		return rangeExp
 	}
 	
 	// 通常の配列の場合 [1, 2, 3]
-	array.Elements = p.parseExpressionList(token.RBRACKET)
+	// 既に [ の次のトークンに進んでいるため、現在のトークンから要素を解析する
+	array.Elements = []ast.Expression{}
+	if p.curTokenIs(token.RBRACKET) {
+		return array
+	}
+
+	array.Elements = append(array.Elements, p.parseExpression(LOWEST))
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		array.Elements = append(array.Elements, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
 	return array
 }
 
@@ -187,9 +243,7 @@ func (p *Parser) parseRangeExpression() ast.Expression {
 		}
 	} else {
 		// [..] の形式（両方なし）
-		if !p.expectPeek(token.RBRACKET) {
-			return nil
-		}
+		// 現在のトークンが既に ] なので読み進めない
 	}
 	
 	return rangeExp
@@ -249,7 +303,7 @@ func (p *Parser) parseClassLiteral() ast.Expression {
 			method := p.parseFunctionLiteral().(*ast.FunctionLiteral)
 			lit.Methods = append(lit.Methods, method)
 		} else {
-			p.errors = append(p.errors, fmt.Sprintf("クラス定義内で予期しないトークンです: %s", p.curToken.Literal))
+			p.errorAt(p.curToken, "クラス定義内で予期しないトークンです: %s", p.curToken.Literal)
 		}
 		p.nextToken()
 	}
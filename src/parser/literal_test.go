@@ -278,6 +278,108 @@ func TestSpecialLiterals(t *testing.T) {
 	}
 }
 
+// TestInterpolatedString は補間文字列リテラルの解析をテストする
+func TestInterpolatedString(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  string
+		partCount int
+	}{
+		{`"total: ${🍕 * 2}!";`, `"total: ${(🍕 * 2)}!"`, 3},
+		{`"${a}${b}";`, `"${a}${b}"`, 2},
+		{`"len ${"寿司" |> length}";`, `"len ${("寿司" |> length)}"`, 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		tokens, _ := l.Tokenize()
+		p := NewParser(tokens)
+		program, err := p.ParseProgram()
+
+		if err != nil {
+			t.Fatalf("Parser error: %v", err)
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		interp, ok := stmt.Expression.(*ast.InterpolatedString)
+		if !ok {
+			t.Fatalf("Expression is not *ast.InterpolatedString. got=%T", stmt.Expression)
+		}
+
+		if len(interp.Parts) != tt.partCount {
+			t.Errorf("wrong number of parts. expected=%d, got=%d", tt.partCount, len(interp.Parts))
+		}
+
+		if interp.String() != tt.expected {
+			t.Errorf("interp.String() not %q. got=%q", tt.expected, interp.String())
+		}
+	}
+}
+
+// TestInterpolatedStringErrors は不正な補間式がエラーになることをテストする
+func TestInterpolatedStringErrors(t *testing.T) {
+	inputs := []string{
+		`"${}";`,
+		`"${1; 2}";`,
+		`"${a"`,
+	}
+
+	for _, input := range inputs {
+		l := lexer.NewLexer(input)
+		tokens, _ := l.Tokenize()
+		p := NewParser(tokens)
+		if _, err := p.ParseProgram(); err == nil {
+			t.Errorf("expected parser error for %s", input)
+		}
+	}
+}
+
+// TestOpenRangeExpression は終了値のない範囲式の解析をテストする
+func TestOpenRangeExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		hasStart bool
+		hasEnd   bool
+	}{
+		{"[1..];", true, false},
+		{"[..];", false, false},
+		{"[..5];", false, true},
+		{"[1..] |> take 3;", true, false},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		tokens, _ := l.Tokenize()
+		p := NewParser(tokens)
+		program, err := p.ParseProgram()
+
+		if err != nil {
+			t.Fatalf("Parser error for %q: %v", tt.input, err)
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		exp := stmt.Expression
+		if pipe, ok := exp.(*ast.InfixExpression); ok {
+			exp = pipe.Left
+		}
+		rangeExp, ok := exp.(*ast.RangeExpression)
+		if !ok {
+			t.Fatalf("exp not *ast.RangeExpression for %q. got=%T", tt.input, exp)
+		}
+		if (rangeExp.Start != nil) != tt.hasStart || (rangeExp.End != nil) != tt.hasEnd {
+			t.Errorf("wrong range for %q. got=%s", tt.input, rangeExp.String())
+		}
+	}
+}
+
 // TestIndexExpression は配列の添字アクセス式の解析をテストする
 func TestIndexExpression(t *testing.T) {
 	input := "myArray[1 + 1];"
@@ -51,6 +51,7 @@ var precedences = map[token.TokenType]int{
 	token.PIPE_PAR:     PIPE,
 	token.MAP_PIPE:     PIPE,
 	token.FILTER_PIPE:  PIPE,
+	token.FOLD_PIPE:    PIPE,
 	token.DOTDOT:       SUM, // 範囲演算子の優先順位
 }
 
@@ -67,6 +68,7 @@ type Parser struct {
 	curToken  token.Token
 	peekToken token.Token
 	errors    []string
+	parseErrors []ParseError // errors と同じエラーを位置つきで保持する
 
 	prefixParseFns    map[token.TokenType]prefixParseFn
 	infixParseFns     map[token.TokenType]infixParseFn
@@ -88,6 +90,7 @@ func NewParser(tokens []token.Token) *Parser {
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
 	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.INTERP_STRING, p.parseInterpolatedString)
 	p.registerPrefix(token.BOOLEAN, p.parseBooleanLiteral)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.NOT, p.parsePrefixExpression)
@@ -100,6 +103,7 @@ func NewParser(tokens []token.Token) *Parser {
 	p.registerPrefix(token.PIZZA, p.parsePizzaLiteral)
 	p.registerPrefix(token.POO, p.parsePooLiteral)
 	p.registerPrefix(token.DOTDOT, p.parseRangeExpression)
+	p.registerPrefix(token.EQ, p.parseKeywordFunctionName) // eq(a, b) のような呼び出し
 	// EOFトークンに対するダミー解析関数を登録
 	p.registerPrefix(token.EOF, p.parseEOF)
 
@@ -127,6 +131,7 @@ func NewParser(tokens []token.Token) *Parser {
 	p.registerInfix(token.PIPE_PAR, p.parsePipeExpression)
 	p.registerInfix(token.MAP_PIPE, p.parsePipeExpression)
 	p.registerInfix(token.FILTER_PIPE, p.parsePipeExpression)
+	p.registerInfix(token.FOLD_PIPE, p.parsePipeExpression)
 
 	// 最初の2つのトークンを読み込む
 	if len(tokens) > 0 {
@@ -139,11 +144,36 @@ func NewParser(tokens []token.Token) *Parser {
 	return p
 }
 
+// ParseError は位置つきのパースエラー
+type ParseError struct {
+	Line    int    // 行番号
+	Column  int    // 列番号
+	Message string // 行番号を含まないエラーメッセージ
+}
+
 // Errors はパース中に発生したエラーを返す
 func (p *Parser) Errors() []string {
 	return p.errors
 }
 
+// ParseErrors はパース中に発生したエラーを位置つきで返す
+func (p *Parser) ParseErrors() []ParseError {
+	return p.parseErrors
+}
+
+// errorAt はトークンの位置にエラーを追加する
+// 回復のために同じトークンを解析し直すことがあるため、同じ位置の同じエラーは一度だけ記録する
+func (p *Parser) errorAt(tok token.Token, format string, args ...interface{}) {
+	e := ParseError{Line: tok.Line, Column: tok.Column, Message: fmt.Sprintf(format, args...)}
+	for _, existing := range p.parseErrors {
+		if existing == e {
+			return
+		}
+	}
+	p.parseErrors = append(p.parseErrors, e)
+	p.errors = append(p.errors, fmt.Sprintf("%d行目: %s", e.Line, e.Message))
+}
+
 // isNestedBlock はパーサーが現在ネストされたブロック内にいるかどうかを判定する
 func (p *Parser) isNestedBlock() bool {
 	// ブロック式のネスト状態を確認するためのヘルパー
@@ -270,16 +300,12 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 
 // peekError は次のトークンが期待と異なる場合にエラーを追加する
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("%d行目: 次のトークンは %s であることが期待されていますが、実際は %s です",
-		p.peekToken.Line, t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.errorAt(p.peekToken, "次のトークンは %s であることが期待されていますが、実際は %s です", t, p.peekToken.Type)
 }
 
 // noPrefixParseFnError は前置解析関数がない場合にエラーを追加する
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("%d行目: トークン %s に対する前置解析関数がありません",
-		p.curToken.Line, t)
-	p.errors = append(p.errors, msg)
+	p.errorAt(p.curToken, "トークン %s に対する前置解析関数がありません", t)
 }
 
 // registerPrefix は前置演算子の解析関数を登録する
@@ -203,3 +203,27 @@ func TestPrecedence(t *testing.T) {
 		}
 	}
 }
+
+// TestParseErrorPositions はパースエラーが位置つきで記録され、同じ位置の同じエラーは一度だけになることをテストする
+func TestParseErrorPositions(t *testing.T) {
+	tokens, _ := lexer.NewLexer("1 |> print;\n1 + ;").Tokenize()
+	p := NewParser(tokens)
+	if _, err := p.ParseProgram(); err == nil {
+		t.Fatalf("ParseProgram should return error for invalid syntax, got nil")
+	}
+
+	expected := []ParseError{{Line: 2, Column: 5, Message: "トークン ; に対する前置解析関数がありません"}}
+	if len(p.ParseErrors()) != len(expected) || p.ParseErrors()[0] != expected[0] {
+		t.Fatalf("wrong parse errors. expected=%+v, got=%+v", expected, p.ParseErrors())
+	}
+	if len(p.Errors()) != 1 || p.Errors()[0] != "2行目: トークン ; に対する前置解析関数がありません" {
+		t.Errorf("wrong error messages. got=%v", p.Errors())
+	}
+
+	// 回復のために同じトークンを解析し直しても、エラーは重複しない
+	p.errorAt(p.curToken, "予期しないトークンです")
+	p.errorAt(p.curToken, "予期しないトークンです")
+	if len(p.ParseErrors()) != 2 || len(p.Errors()) != 2 {
+		t.Errorf("same error at the same position should be recorded once. got=%+v", p.ParseErrors())
+	}
+}
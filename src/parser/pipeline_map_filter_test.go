@@ -5,6 +5,7 @@ import (
 
 	"github.com/uncode/ast"
 	"github.com/uncode/lexer"
+	"github.com/uncode/token"
 )
 
 // TestMapFilterOperators は+>と?>演算子の解析をテストします
@@ -215,6 +216,79 @@ func TestChainedMapFilterOperators(t *testing.T) {
 	}
 }
 
+// TestFoldOperator はfold演算子(/>)の解析をテストします
+func TestFoldOperator(t *testing.T) {
+	tests := []struct {
+		input     string
+		function  string
+		argValues []interface{}
+	}{
+		// 初期値なしのfold演算子
+		{"data /> add;", "add", nil},
+		// 初期値付きのfold演算子
+		{"data /> add 0;", "add", []interface{}{0}},
+		{"data /> join \"\";", "join", []interface{}{""}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		tokens, _ := l.Tokenize()
+		p := NewParser(tokens)
+		program, err := p.ParseProgram()
+
+		if err != nil {
+			t.Fatalf("Parser error: %v", err)
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		pipeExp, ok := stmt.Expression.(*ast.InfixExpression)
+		if !ok {
+			t.Fatalf("exp is not ast.InfixExpression. got=%T", stmt.Expression)
+		}
+
+		if pipeExp.Operator != "/>" {
+			t.Fatalf("exp.Operator is not '/>'. got=%s", pipeExp.Operator)
+		}
+
+		if !testIdentifier(t, pipeExp.Left, "data") {
+			return
+		}
+
+		// 初期値がない場合、右辺は識別子
+		if tt.argValues == nil {
+			testIdentifier(t, pipeExp.Right, tt.function)
+			continue
+		}
+
+		// 初期値がある場合、右辺は関数呼び出し
+		rightCall, ok := pipeExp.Right.(*ast.CallExpression)
+		if !ok {
+			t.Fatalf("pipeExp.Right is not ast.CallExpression. got=%T", pipeExp.Right)
+		}
+
+		if !testIdentifier(t, rightCall.Function, tt.function) {
+			return
+		}
+
+		if len(rightCall.Arguments) != len(tt.argValues) {
+			t.Fatalf("関数の引数の数が正しくありません。期待値=%d, 実際=%d", len(tt.argValues), len(rightCall.Arguments))
+		}
+
+		for i, expectedValue := range tt.argValues {
+			switch expected := expectedValue.(type) {
+			case int:
+				testIntegerLiteral(t, rightCall.Arguments[i], int64(expected))
+			case string:
+				testStringLiteral(t, rightCall.Arguments[i], expected)
+			}
+		}
+	}
+}
+
 // testStringLiteral は式が期待する文字列リテラルかをテストする
 func testStringLiteral(t *testing.T, exp ast.Expression, expected string) bool {
 	strLit, ok := exp.(*ast.StringLiteral)
@@ -233,3 +307,42 @@ func testStringLiteral(t *testing.T, exp ast.Expression, expected string) bool {
 
 // テスト用ヘルパーのインポート
 // testBooleanLiteralはexpression_test.goにすでに定義されているので削除
+
+// TestPipeBeforeMapFilterOperators は |> の直後の +> / ?> / /> が |> を省いた形と同じ段になることをテストします
+func TestPipeBeforeMapFilterOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`stdin_lines |> ?> contains "ERROR";`, `stdin_lines ?> contains "ERROR";`},
+		{`data |> +> double |> print;`, `data +> double |> print;`},
+		{`data |> /> add 0;`, `data /> add 0;`},
+		{`data |> map double;`, `data map double;`},
+		{`stdin_lines |> ?> contains "ERROR" +> parse_line |> print_each;`, `stdin_lines ?> contains "ERROR" +> parse_line |> print_each;`},
+	}
+
+	for _, tt := range tests {
+		program, err := NewParser(tokenize(t, tt.input)).ParseProgram()
+		if err != nil {
+			t.Fatalf("%s: Parser error: %v", tt.input, err)
+		}
+		expected, err := NewParser(tokenize(t, tt.expected)).ParseProgram()
+		if err != nil {
+			t.Fatalf("%s: Parser error: %v", tt.expected, err)
+		}
+
+		if program.String() != expected.String() {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, expected.String(), program.String())
+		}
+	}
+}
+
+// tokenize はテスト用に入力をトークン列に変換する
+func tokenize(t *testing.T, input string) []token.Token {
+	t.Helper()
+	tokens, err := lexer.NewLexer(input).Tokenize()
+	if err != nil {
+		t.Fatalf("%s: Lexer error: %v", input, err)
+	}
+	return tokens
+}
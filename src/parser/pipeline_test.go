@@ -124,6 +124,55 @@ func TestPipelineWithArgument(t *testing.T) {
 	testIntegerLiteral(t, rightCall.Arguments[0], 3)
 }
 
+// TestPipelineWithMultipleArguments は括弧なしで複数の引数を渡すパイプラインの解析をテストする
+func TestPipelineWithMultipleArguments(t *testing.T) {
+	input := `"a-b" |> replace "-" "+" 1 |> print;`
+
+	l := lexer.NewLexer(input)
+	tokens, _ := l.Tokenize()
+	p := NewParser(tokens)
+	program, err := p.ParseProgram()
+
+	if err != nil {
+		t.Fatalf("Parser error: %v", err)
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	// 外側は |> print
+	outer, ok := stmt.Expression.(*ast.InfixExpression)
+	if !ok {
+		t.Fatalf("exp is not ast.InfixExpression. got=%T", stmt.Expression)
+	}
+	testIdentifier(t, outer.Right, "print")
+
+	inner, ok := outer.Left.(*ast.InfixExpression)
+	if !ok {
+		t.Fatalf("outer.Left is not ast.InfixExpression. got=%T", outer.Left)
+	}
+
+	// 右辺は関数呼び出し replace("-", "+", 1)
+	rightCall, ok := inner.Right.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("inner.Right is not ast.CallExpression. got=%T", inner.Right)
+	}
+
+	if !testIdentifier(t, rightCall.Function, "replace") {
+		return
+	}
+
+	if len(rightCall.Arguments) != 3 {
+		t.Fatalf("replace関数の引数の数が正しくありません。期待値=3, 実際=%d", len(rightCall.Arguments))
+	}
+
+	testStringLiteral(t, rightCall.Arguments[0], "-")
+	testStringLiteral(t, rightCall.Arguments[1], "+")
+	testIntegerLiteral(t, rightCall.Arguments[2], 1)
+}
+
 // TestParallelPipeExpression は並列パイプ演算子の解析をテストする
 func TestParallelPipeExpression(t *testing.T) {
 	input := "data | process;"
@@ -264,3 +313,57 @@ func TestMixedPipeExpressions(t *testing.T) {
 		return
 	}
 }
+
+// TestKeywordFunctionsInPipeline はキーワードのeq/notをパイプラインの関数名として解析できることをテストする
+func TestKeywordFunctionsInPipeline(t *testing.T) {
+	tests := []struct {
+		input    string
+		operator string
+		function string
+		argCount int
+	}{
+		{"x |> eq 0;", "|>", "eq", 1},
+		{"x ?> gt 3;", "?>", "gt", 1},
+		{"x |> not;", "|>", "not", 0},
+		{"eq(1, 2) |> print;", "|>", "print", 0},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		tokens, _ := l.Tokenize()
+		p := NewParser(tokens)
+		program, err := p.ParseProgram()
+
+		if err != nil {
+			t.Fatalf("Parser error for %q: %v", tt.input, err)
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		pipeExp, ok := stmt.Expression.(*ast.InfixExpression)
+		if !ok {
+			t.Fatalf("exp is not ast.InfixExpression. got=%T", stmt.Expression)
+		}
+
+		if pipeExp.Operator != tt.operator {
+			t.Fatalf("exp.Operator is not %q. got=%s", tt.operator, pipeExp.Operator)
+		}
+
+		if tt.argCount == 0 {
+			testIdentifier(t, pipeExp.Right, tt.function)
+			continue
+		}
+
+		rightCall, ok := pipeExp.Right.(*ast.CallExpression)
+		if !ok {
+			t.Fatalf("pipeExp.Right is not ast.CallExpression. got=%T", pipeExp.Right)
+		}
+		testIdentifier(t, rightCall.Function, tt.function)
+		if len(rightCall.Arguments) != tt.argCount {
+			t.Errorf("wrong length of arguments. expected=%d, got=%d", tt.argCount, len(rightCall.Arguments))
+		}
+	}
+}
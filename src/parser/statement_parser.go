@@ -1,8 +1,6 @@
 package parser
 
 import (
-	"fmt"
-	
 	"github.com/uncode/ast"
 	"github.com/uncode/logger"
 	"github.com/uncode/token"
@@ -16,7 +14,7 @@ func (p *Parser) parseStatement() ast.Statement {
 	case token.CASE:
 		// 関数内でのみcase文を許可するチェック
 		if !p.insideFunctionBody {
-			p.errors = append(p.errors, fmt.Sprintf("%d行目: case文は関数ブロック内でのみ使用できます。関数定義内で使用してください", p.curToken.Line))
+			p.errorAt(p.curToken, "case文は関数ブロック内でのみ使用できます。関数定義内で使用してください")
 			logger.ParserDebug("関数外でのcase文使用を検出: エラー報告 (insideFunctionBody=%v)", p.insideFunctionBody)
 			return nil
 		}
@@ -24,7 +22,7 @@ func (p *Parser) parseStatement() ast.Statement {
 		// ネストしたブロック内のcase文を禁止する追加チェック
 		// 直接関数の本体内でないcase文は禁止
 		if p.isNestedBlock() {
-			p.errors = append(p.errors, fmt.Sprintf("%d行目: case文は関数のルート階層でのみ使用できます。ネストされたブロック内では使用できません", p.curToken.Line))
+			p.errorAt(p.curToken, "case文は関数のルート階層でのみ使用できます。ネストされたブロック内では使用できません")
 			logger.ParserDebug("ネストされたブロック内でのcase文使用を検出: エラー報告")
 			return nil
 		}
@@ -34,14 +32,14 @@ func (p *Parser) parseStatement() ast.Statement {
 	case token.DEFAULT:
 		// 関数内でのみdefault文を許可するチェック
 		if !p.insideFunctionBody {
-			p.errors = append(p.errors, fmt.Sprintf("%d行目: default文は関数ブロック内でのみ使用できます。関数定義内で使用してください", p.curToken.Line))
+			p.errorAt(p.curToken, "default文は関数ブロック内でのみ使用できます。関数定義内で使用してください")
 			logger.ParserDebug("関数外でのdefault文使用を検出: エラー報告 (insideFunctionBody=%v)", p.insideFunctionBody)
 			return nil
 		}
 		
 		// ネストしたブロック内のdefault文を禁止する追加チェック
 		if p.isNestedBlock() {
-			p.errors = append(p.errors, fmt.Sprintf("%d行目: default文は関数のルート階層でのみ使用できます。ネストされたブロック内では使用できません", p.curToken.Line))
+			p.errorAt(p.curToken, "default文は関数のルート階層でのみ使用できます。ネストされたブロック内では使用できません")
 			logger.ParserDebug("ネストされたブロック内でのdefault文使用を検出: エラー報告")
 			return nil
 		}
@@ -128,7 +126,7 @@ func (p *Parser) parseCaseStatement() *ast.CaseStatement {
 	// コロンを期待
 	if !p.expectPeek(token.COLON) {
 		logger.ParserDebug("case文の解析エラー: コロンが見つかりませんでした")
-		p.errors = append(p.errors, fmt.Sprintf("%d行目: case文の後にコロンが必要です", p.curToken.Line))
+		p.errorAt(p.curToken, "case文の後にコロンが必要です")
 		return nil
 	}
 
@@ -156,7 +154,7 @@ func (p *Parser) parseDefaultCaseStatement() *ast.DefaultCaseStatement {
 	// コロンを期待
 	if !p.expectPeek(token.COLON) {
 		logger.ParserDebug("default文の解析エラー: コロンが見つかりませんでした")
-		p.errors = append(p.errors, fmt.Sprintf("%d行目: default文の後にコロンが必要です", p.curToken.Line))
+		p.errorAt(p.curToken, "default文の後にコロンが必要です")
 		return nil
 	}
 	
@@ -0,0 +1,203 @@
+package profile
+
+import (
+	"compress/gzip"
+	"io"
+
+	"github.com/uncode/evaluator"
+)
+
+// pprof の profile.proto のフィールド番号
+// https://github.com/google/pprof/blob/main/proto/profile.proto
+const (
+	profileSampleType        = 1
+	profileSample            = 2
+	profileMapping           = 3
+	profileLocation          = 4
+	profileFunction          = 5
+	profileStringTable       = 6
+	profileTimeNanos         = 9
+	profileDurationNanos     = 10
+	profilePeriodType        = 11
+	profilePeriod            = 12
+	profileDefaultSampleType = 14
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	mappingID             = 1
+	mappingFilename       = 5
+	mappingHasFunctions   = 7
+	mappingHasFilenames   = 8
+	mappingHasLineNumbers = 9
+
+	locationID        = 1
+	locationMappingID = 2
+	locationLine      = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+	functionStartLine  = 5
+)
+
+// builtinFilename は組み込み関数のファイル名として表示する名前
+const builtinFilename = "<組み込み関数>"
+
+// protoBuffer は protobuf のメッセージを組み立てる
+type protoBuffer struct {
+	data []byte
+}
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *protoBuffer) key(field int, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+// uint64Field は varint のフィールドを書き込む（0 は既定値なので省略する）
+func (b *protoBuffer) uint64Field(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.key(field, 0)
+	b.varint(x)
+}
+
+func (b *protoBuffer) int64Field(field int, x int64) {
+	b.uint64Field(field, uint64(x))
+}
+
+func (b *protoBuffer) bytesField(field int, data []byte) {
+	b.key(field, 2)
+	b.varint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+func (b *protoBuffer) messageField(field int, msg *protoBuffer) {
+	b.bytesField(field, msg.data)
+}
+
+// packedField は repeated の数値フィールドを packed 形式で書き込む
+func (b *protoBuffer) packedField(field int, xs []uint64) {
+	var packed protoBuffer
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	b.bytesField(field, packed.data)
+}
+
+// pprofBuilder は Profile を profile.proto のメッセージに変換する
+type pprofBuilder struct {
+	p         *Profile
+	out       protoBuffer
+	strings   map[string]int64
+	functions map[evaluator.ProfileFrame]uint64 // フレームから関数と位置の ID（1つの関数に1つの位置を対応させる）
+}
+
+// str は文字列テーブルでの番号を返す。まだなければ追加する
+func (b *pprofBuilder) str(s string) int64 {
+	if i, ok := b.strings[s]; ok {
+		return i
+	}
+	i := int64(len(b.strings))
+	b.strings[s] = i
+	b.out.bytesField(profileStringTable, []byte(s))
+	return i
+}
+
+// valueType は ValueType メッセージを作成する
+func (b *pprofBuilder) valueType(typ string, unit string) *protoBuffer {
+	var msg protoBuffer
+	msg.int64Field(valueTypeType, b.str(typ))
+	msg.int64Field(valueTypeUnit, b.str(unit))
+	return &msg
+}
+
+// location はフレームの Function と Location を書き込み、その ID を返す
+func (b *pprofBuilder) location(frame evaluator.ProfileFrame) uint64 {
+	if id, ok := b.functions[frame]; ok {
+		return id
+	}
+	id := uint64(len(b.functions) + 1)
+	b.functions[frame] = id
+
+	filename := b.p.file
+	if frame.Builtin {
+		filename = builtinFilename
+	}
+	var fn protoBuffer
+	fn.uint64Field(functionID, id)
+	fn.int64Field(functionName, b.str(frame.Name))
+	fn.int64Field(functionSystemName, b.str(frame.Name))
+	fn.int64Field(functionFilename, b.str(filename))
+	fn.int64Field(functionStartLine, int64(frame.Line))
+	b.out.messageField(profileFunction, &fn)
+
+	var line protoBuffer
+	line.uint64Field(lineFunctionID, id)
+	line.int64Field(lineLine, int64(frame.Line))
+	var loc protoBuffer
+	loc.uint64Field(locationID, id)
+	loc.uint64Field(locationMappingID, 1)
+	loc.messageField(locationLine, &line)
+	b.out.messageField(profileLocation, &loc)
+	return id
+}
+
+// WritePprof は go tool pprof で読める gzip 圧縮した protobuf 形式で書き出す
+// サンプルの値は呼び出し回数 (calls) と、子を除いた実行時間 (wall, ナノ秒) の2つ
+func (p *Profile) WritePprof(w io.Writer) error {
+	b := &pprofBuilder{p: p, strings: make(map[string]int64), functions: make(map[evaluator.ProfileFrame]uint64)}
+	// 文字列テーブルの先頭は空文字列でなければならない
+	b.str("")
+
+	b.out.messageField(profileSampleType, b.valueType("calls", "count"))
+	b.out.messageField(profileSampleType, b.valueType("wall", "nanoseconds"))
+
+	// 位置はすべてシンボル解決済みであることを示し、pprof がバイナリを探さないようにする
+	var mapping protoBuffer
+	mapping.uint64Field(mappingID, 1)
+	mapping.int64Field(mappingFilename, b.str(p.file))
+	mapping.uint64Field(mappingHasFunctions, 1)
+	mapping.uint64Field(mappingHasFilenames, 1)
+	mapping.uint64Field(mappingHasLineNumbers, 1)
+	b.out.messageField(profileMapping, &mapping)
+
+	p.walk(func(n *node) {
+		// 位置は最も内側のフレームから順に並べる
+		var locations []uint64
+		for m := n; m != nil; m = m.parent {
+			locations = append(locations, b.location(m.frame))
+		}
+		var sample protoBuffer
+		sample.packedField(sampleLocationID, locations)
+		sample.packedField(sampleValue, []uint64{uint64(n.calls), uint64(n.self.Nanoseconds())})
+		b.out.messageField(profileSample, &sample)
+	})
+
+	b.out.int64Field(profileTimeNanos, p.start.UnixNano())
+	b.out.int64Field(profileDurationNanos, p.duration.Nanoseconds())
+	b.out.messageField(profilePeriodType, b.valueType("wall", "nanoseconds"))
+	b.out.int64Field(profilePeriod, 1)
+	b.out.int64Field(profileDefaultSampleType, b.str("wall"))
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b.out.data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,162 @@
+// Package profile はユーザー定義関数・組み込み関数・パイプラインの段ごとの実行時間と呼び出し回数を集計し、
+// pprof の protobuf 形式やフレームグラフ用の折りたたみ形式で出力する
+package profile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/uncode/evaluator"
+	"github.com/uncode/object"
+)
+
+// maxStackDepth は呼び出し履歴を区別する深さの上限
+// 深い再帰で呼び出し履歴の数が膨れ上がらないように、これより深い呼び出しは上限の深さのフレームにまとめる
+const maxStackDepth = 256
+
+// rootFrame はプログラム全体を表す一番外側のフレーム
+// pprof は関数名の <...> をテンプレート引数として取り除くため、デバッガの "<メイン>" とは違う名前にする
+var rootFrame = evaluator.ProfileFrame{Name: "メイン"}
+
+// node は呼び出し履歴の木の節。同じ呼び出し履歴の実行は同じ節に集計する
+type node struct {
+	frame    evaluator.ProfileFrame
+	parent   *node
+	depth    int
+	children map[evaluator.ProfileFrame]*node
+	order    []*node // 子を最初に呼び出された順に並べたもの（出力の順序を決めるため）
+	calls    int64
+	self     time.Duration // 子の実行時間を除いた実行時間
+}
+
+// child は子の節を返す。まだなければ作成する
+func (n *node) child(frame evaluator.ProfileFrame) *node {
+	if c, ok := n.children[frame]; ok {
+		return c
+	}
+	c := &node{frame: frame, parent: n, depth: n.depth + 1, children: make(map[evaluator.ProfileFrame]*node)}
+	n.children[frame] = c
+	n.order = append(n.order, c)
+	return c
+}
+
+// active は実行中のフレーム
+type active struct {
+	node     *node
+	start    time.Time
+	children time.Duration // このフレームから呼び出したフレームの実行時間の合計
+}
+
+// Profile は evaluator.Profiler を実装し、呼び出し履歴ごとに実行時間と呼び出し回数を集計する
+type Profile struct {
+	file     string // ソースファイル名（ユーザー定義関数と段の位置に使う）
+	now      func() time.Time
+	start    time.Time
+	duration time.Duration
+	root     *node
+	stack    []active
+}
+
+// New はプロファイルを作成し、計測を開始する
+func New(file string) *Profile {
+	return newProfile(file, time.Now)
+}
+
+// newProfile は時刻の取得方法を指定してプロファイルを作成する
+func newProfile(file string, now func() time.Time) *Profile {
+	p := &Profile{
+		file: file,
+		now:  now,
+		root: &node{frame: rootFrame, children: make(map[evaluator.ProfileFrame]*node), calls: 1},
+	}
+	p.start = now()
+	p.stack = []active{{node: p.root, start: p.start}}
+	return p
+}
+
+// Enter は関数や段の実行の開始を記録する（🍕 と 💩 の値は集計に使わない）
+func (p *Profile) Enter(frame evaluator.ProfileFrame, input object.Object) {
+	parent := p.stack[len(p.stack)-1].node
+	n := parent
+	if parent.depth < maxStackDepth {
+		n = parent.child(frame)
+	}
+	n.calls++
+	p.stack = append(p.stack, active{node: n, start: p.now()})
+}
+
+// Exit は最後に開始した関数や段の実行の終了を記録する
+func (p *Profile) Exit(result object.Object) {
+	if len(p.stack) <= 1 {
+		return
+	}
+	p.pop(p.now())
+}
+
+// Stop は計測を終了する。実行中のフレームはすべてこの時点で終了したものとして扱う
+func (p *Profile) Stop() {
+	if len(p.stack) == 0 {
+		return
+	}
+	end := p.now()
+	for len(p.stack) > 0 {
+		p.pop(end)
+	}
+	p.duration = end.Sub(p.start)
+}
+
+// pop は実行中のフレームを1つ終了し、子を除いた実行時間を節に加える
+func (p *Profile) pop(end time.Time) {
+	a := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	elapsed := end.Sub(a.start)
+	a.node.self += elapsed - a.children
+	if len(p.stack) > 0 {
+		p.stack[len(p.stack)-1].children += elapsed
+	}
+}
+
+// walk は呼び出し履歴の木を深さ優先で、最初に呼び出された順にたどる
+func (p *Profile) walk(visit func(n *node)) {
+	var rec func(n *node)
+	rec = func(n *node) {
+		visit(n)
+		for _, c := range n.order {
+			rec(c)
+		}
+	}
+	rec(p.root)
+}
+
+// label は折りたたみ形式で表示するフレームの名前を返す (例: "sq (main.poo:1)")
+func (p *Profile) label(frame evaluator.ProfileFrame) string {
+	name := frame.Name
+	if !frame.Builtin && frame.Line > 0 {
+		name = fmt.Sprintf("%s (%s:%d)", name, p.file, frame.Line)
+	}
+	// フレームの区切りに使う ; は名前に含められない
+	return strings.ReplaceAll(name, ";", ",")
+}
+
+// WriteFolded はフレームグラフ用の折りたたみ形式で書き出す
+// 1行が1つの呼び出し履歴で、外側から順に ; で区切ったフレームの後に、子を除いた実行時間（ナノ秒）が続く
+func (p *Profile) WriteFolded(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	p.walk(func(n *node) {
+		var labels []string
+		for m := n; m != nil; m = m.parent {
+			labels = append(labels, p.label(m.frame))
+		}
+		for i := len(labels) - 1; i >= 0; i-- {
+			bw.WriteString(labels[i])
+			if i > 0 {
+				bw.WriteByte(';')
+			}
+		}
+		fmt.Fprintf(bw, " %d\n", n.self.Nanoseconds())
+	})
+	return bw.Flush()
+}
@@ -0,0 +1,212 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/uncode/evaluator"
+	"github.com/uncode/lexer"
+	"github.com/uncode/object"
+	"github.com/uncode/parser"
+)
+
+// fakeClock は呼び出されるたびに1ミリ秒ずつ進む時計
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.t = c.t.Add(time.Millisecond)
+	return c.t
+}
+
+// newTestProfile は "3 |> sq |> print" を2回実行したときのプロファイルを作成する
+func newTestProfile() *Profile {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	p := newProfile("main.poo", clock.now)
+
+	stage := evaluator.ProfileFrame{Name: "|> sq", Line: 4, Column: 3}
+	sq := evaluator.ProfileFrame{Name: "sq", Line: 1, Column: 22}
+	print := evaluator.ProfileFrame{Name: "print", Builtin: true}
+	// 時計は now を呼び出すたびに進むので、sq と print は 1ms、|> sq は子を除いて 2ms になる
+	for i := 0; i < 2; i++ {
+		p.Enter(stage, nil)
+		p.Enter(sq, nil)
+		p.Exit(nil)
+		p.Exit(nil)
+		p.Enter(print, nil)
+		p.Exit(nil)
+	}
+	// 全体は 13ms で、子を除くと 5ms になる
+	p.Stop()
+	return p
+}
+
+// TestWriteFolded は呼び出し履歴ごとに子を除いた実行時間を集計することをテストする
+func TestWriteFolded(t *testing.T) {
+	var out bytes.Buffer
+	if err := newTestProfile().WriteFolded(&out); err != nil {
+		t.Fatal(err)
+	}
+	expected := "メイン 5000000\n" +
+		"メイン;|> sq (main.poo:4) 4000000\n" +
+		"メイン;|> sq (main.poo:4);sq (main.poo:1) 2000000\n" +
+		"メイン;print 2000000\n"
+	if out.String() != expected {
+		t.Errorf("wrong folded output.\nexpected=%q\ngot=%q", expected, out.String())
+	}
+}
+
+// TestWritePprof は pprof の protobuf として読み出せることをテストする
+func TestWritePprof(t *testing.T) {
+	var out bytes.Buffer
+	if err := newTestProfile().WritePprof(&out); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("profile should be gzip compressed: %s", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// トップレベルのフィールドだけを読み、文字列テーブルとサンプルの値を確認する
+	var strs []string
+	var values [][]uint64
+	for len(data) > 0 {
+		key, n := readVarint(data)
+		data = data[n:]
+		field, wireType := key>>3, key&7
+		if wireType == 0 {
+			_, n = readVarint(data)
+			data = data[n:]
+			continue
+		}
+		length, n := readVarint(data)
+		body := data[n : n+int(length)]
+		data = data[n+int(length):]
+		switch field {
+		case profileStringTable:
+			strs = append(strs, string(body))
+		case profileSample:
+			values = append(values, sampleValues(body))
+		}
+	}
+
+	if len(strs) == 0 || strs[0] != "" {
+		t.Fatalf("string table should start with an empty string. got=%q", strs)
+	}
+	for _, name := range []string{"calls", "wall", "nanoseconds", "|> sq", "sq", "print", "main.poo", builtinFilename} {
+		if !contains(strs, name) {
+			t.Errorf("string table should contain %q. got=%q", name, strs)
+		}
+	}
+	// メイン、|> sq、sq、print の順に (呼び出し回数, 子を除いた実行時間)
+	expected := [][]uint64{{1, 5000000}, {2, 4000000}, {2, 2000000}, {2, 2000000}}
+	if len(values) != len(expected) {
+		t.Fatalf("wrong number of samples. got=%v", values)
+	}
+	for i := range expected {
+		if len(values[i]) != 2 || values[i][0] != expected[i][0] || values[i][1] != expected[i][1] {
+			t.Errorf("sample %d wrong. expected=%v, got=%v", i, expected[i], values[i])
+		}
+	}
+}
+
+// TestLazyStageElements は範囲式に重ねた +> と ?> の要素ごとの処理が、要素を取り出した段の下でその段に計上されることをテストする
+func TestLazyStageElements(t *testing.T) {
+	source := "def double() { 🍕 * 2 >> 💩 }; def big() { 🍕 > 2 >> 💩 }; [1..3] +> double ?> big |> sum;"
+	tokens, err := lexer.NewLexer(source).Tokenize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, err := parser.NewParser(tokens).ParseProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := New("main.poo")
+	evaluator.SetProfiler(p)
+	result := evaluator.Eval(program, object.NewEnvironment())
+	evaluator.SetProfiler(nil)
+	p.Stop()
+	if i, ok := result.(*object.Integer); !ok || i.Value != 10 {
+		t.Fatalf("wrong result. got=%v", result)
+	}
+
+	tests := []struct {
+		path  []string
+		calls int64
+	}{
+		{[]string{"|> sum", "sum", "+> double", "double"}, 3},
+		{[]string{"|> sum", "sum", "?> big", "big"}, 3},
+	}
+	for _, tt := range tests {
+		n := p.root
+		for _, name := range tt.path {
+			n = findChild(n, name)
+			if n == nil {
+				t.Fatalf("profile should contain %v", tt.path)
+			}
+		}
+		if n.calls != tt.calls {
+			t.Errorf("%v should be called %d times. got=%d", tt.path, tt.calls, n.calls)
+		}
+	}
+}
+
+// findChild は名前で子の節を探す
+func findChild(n *node, name string) *node {
+	for _, c := range n.order {
+		if c.frame.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// readVarint は protobuf の varint を読み、値と読んだバイト数を返す
+func readVarint(data []byte) (uint64, int) {
+	var x uint64
+	for i, b := range data {
+		x |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return x, i + 1
+		}
+	}
+	return x, len(data)
+}
+
+// sampleValues は Sample メッセージから packed の value フィールドを読む
+func sampleValues(body []byte) []uint64 {
+	var values []uint64
+	for len(body) > 0 {
+		key, n := readVarint(body)
+		length, m := readVarint(body[n:])
+		packed := body[n+m : n+m+int(length)]
+		body = body[n+m+int(length):]
+		if key>>3 != sampleValue {
+			continue
+		}
+		for len(packed) > 0 {
+			v, k := readVarint(packed)
+			values = append(values, v)
+			packed = packed[k:]
+		}
+	}
+	return values
+}
+
+func contains(strs []string, s string) bool {
+	for _, v := range strs {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
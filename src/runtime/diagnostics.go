@@ -0,0 +1,207 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/uncode/object"
+	"github.com/uncode/parser"
+	"github.com/uncode/token"
+)
+
+// 終了コード
+// exit 関数で指定された終了コードはそのまま使われるため、これらと重なる場合がある
+const (
+	ExitOK            = 0 // 正常終了
+	ExitRuntimeError  = 1 // 実行時エラー
+	ExitUsageError    = 2 // コマンドラインや設定の誤り、ソースファイルが読み込めない
+	ExitLexError      = 3 // 字句解析エラー
+	ExitParseError    = 4 // 構文解析エラー
+	ExitTypeError     = 5 // 型エラー
+	ExitLimitExceeded = 6 // 関数呼び出しの深さなどの上限超過
+)
+
+// 診断コード（--diagnostics=json の code）
+const (
+	CodeUsageError    = "usage-error"
+	CodeLexError      = "lex-error"
+	CodeParseError    = "parse-error"
+	CodeTypeError     = "type-error"
+	CodeRuntimeError  = "runtime-error"
+	CodeLimitExceeded = "limit-exceeded"
+)
+
+// diagnosticClass は診断コードごとの終了コードとテキスト形式での見出し
+var diagnosticClass = map[string]struct {
+	exitCode int
+	label    string
+}{
+	CodeUsageError:    {ExitUsageError, "使用方法の誤り"},
+	CodeLexError:      {ExitLexError, "レキサーエラー"},
+	CodeParseError:    {ExitParseError, "パーサーエラー"},
+	CodeTypeError:     {ExitTypeError, "型エラー"},
+	CodeRuntimeError:  {ExitRuntimeError, "実行時エラー"},
+	CodeLimitExceeded: {ExitLimitExceeded, "上限超過"},
+}
+
+// SeverityError は診断の重大度
+const SeverityError = "error"
+
+// Diagnostic はエディタや CI が読み取るための1件のエラー情報
+type Diagnostic struct {
+	Code     string   `json:"code"`
+	Severity string   `json:"severity"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`   // 行番号（不明な場合は0）
+	Column   int      `json:"column"` // 列番号（不明な場合は0）
+	Message  string   `json:"message"`
+	Stack    []string `json:"stack"` // エラーが伝わった関数呼び出し（内側から順）
+}
+
+// NewDiagnostic は位置のない診断を作成する
+func NewDiagnostic(code string, file string, message string) Diagnostic {
+	return Diagnostic{
+		Code:     code,
+		Severity: SeverityError,
+		File:     file,
+		Message:  message,
+		Stack:    []string{},
+	}
+}
+
+// ExitCode は診断の種類に対応する終了コードを返す
+func (d Diagnostic) ExitCode() int {
+	if class, ok := diagnosticClass[d.Code]; ok {
+		return class.exitCode
+	}
+	return ExitRuntimeError
+}
+
+// String はログに出力するテキスト形式を返す
+// 例: main.poo:3:5: 型エラー: 🍕の型が不正です
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	b.WriteString(formatPosition(d.File, d.Line, d.Column))
+	b.WriteString(": ")
+	if class, ok := diagnosticClass[d.Code]; ok {
+		b.WriteString(class.label)
+		b.WriteString(": ")
+	}
+	b.WriteString(d.Message)
+	for _, frame := range d.Stack {
+		b.WriteString("\n    ")
+		b.WriteString(frame)
+	}
+	return b.String()
+}
+
+// formatPosition は "ファイル名:行:列" を返す。不明な部分は省略する
+func formatPosition(file string, line int, column int) string {
+	if line == 0 {
+		return file
+	}
+	if column == 0 {
+		return fmt.Sprintf("%s:%d", file, line)
+	}
+	return fmt.Sprintf("%s:%d:%d", file, line, column)
+}
+
+// WriteDiagnosticsJSON は診断を JSON の配列として書き出す
+// エラーがない場合も空の配列を出力する
+func WriteDiagnosticsJSON(w io.Writer, diagnostics []Diagnostic) error {
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(diagnostics)
+}
+
+// exitCodeFor は診断の一覧から終了コードを決める（最初の診断の種類を使う）
+func exitCodeFor(diagnostics []Diagnostic) int {
+	if len(diagnostics) == 0 {
+		return ExitOK
+	}
+	return diagnostics[0].ExitCode()
+}
+
+// linePrefix はパーサーやレキサーのメッセージ先頭の "N行目: "
+var linePrefix = regexp.MustCompile(`^(\d+)行目: `)
+
+// splitLinePrefix はメッセージ先頭の "N行目: " を行番号として取り出す
+func splitLinePrefix(message string) (int, string) {
+	m := linePrefix.FindStringSubmatch(message)
+	if m == nil {
+		return 0, message
+	}
+	line, _ := strconv.Atoi(m[1])
+	return line, message[len(m[0]):]
+}
+
+// lexDiagnostics は字句解析で不正なトークンになった箇所を診断にする
+func lexDiagnostics(file string, tokens []token.Token) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, tok := range tokens {
+		if tok.Type != token.ILLEGAL {
+			continue
+		}
+		d := NewDiagnostic(CodeLexError, file, "")
+		d.Line, d.Column = tok.Line, tok.Column
+		// 1文字の場合は読み取れなかった文字、それ以外はレキサーのエラーメッセージ
+		if len([]rune(tok.Literal)) == 1 {
+			d.Message = fmt.Sprintf("不正な文字です: %q", tok.Literal)
+		} else {
+			_, d.Message = splitLinePrefix(tok.Literal)
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// parseDiagnostics はパーサーのエラーを診断にする
+func parseDiagnostics(file string, errors []parser.ParseError) []Diagnostic {
+	diagnostics := make([]Diagnostic, len(errors))
+	for i, e := range errors {
+		d := NewDiagnostic(CodeParseError, file, e.Message)
+		d.Line, d.Column = e.Line, e.Column
+		diagnostics[i] = d
+	}
+	return diagnostics
+}
+
+// errorDiagnostic は評価器のエラーを診断にする
+func errorDiagnostic(file string, err *object.Error) Diagnostic {
+	code := CodeRuntimeError
+	switch err.Kind {
+	case object.TypeError:
+		code = CodeTypeError
+	case object.LimitError:
+		code = CodeLimitExceeded
+	}
+
+	d := NewDiagnostic(code, file, err.Message)
+	d.Line, d.Column = err.Line, err.Column
+
+	// 再帰で同じ呼び出しが続く部分は1件にまとめる
+	repeated := 0
+	for i, frame := range err.Stack {
+		entry := fmt.Sprintf("%s (%s)", frame.Function, formatPosition(file, frame.Line, frame.Column))
+		if i > 0 && frame == err.Stack[i-1] {
+			repeated++
+			continue
+		}
+		if repeated > 0 {
+			d.Stack = append(d.Stack, fmt.Sprintf("(同じ呼び出しが %d 回続いています)", repeated))
+			repeated = 0
+		}
+		d.Stack = append(d.Stack, entry)
+	}
+	if repeated > 0 {
+		d.Stack = append(d.Stack, fmt.Sprintf("(同じ呼び出しが %d 回続いています)", repeated))
+	}
+	return d
+}
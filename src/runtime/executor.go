@@ -3,6 +3,7 @@ package runtime
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/uncode/ast"
 	"github.com/uncode/config"
@@ -16,25 +17,23 @@ import (
 
 // SourceCodeResult は処理結果を表す構造体
 type SourceCodeResult struct {
-	Tokens   []token.Token
-	Program  *ast.Program
-	Result   object.Object
-	ExitCode int
+	Source      string // 実行したソースコード
+	Tokens      []token.Token
+	Program     *ast.Program
+	Result      object.Object
+	ExitCode    int
+	Diagnostics []Diagnostic // 発生したエラー（エラーがなければ空）
 }
 
 // SetupBuiltins は組み込み関数を環境に設定する
 func SetupBuiltins(env *object.Environment) {
-	// プリント関数を追加
-	env.Set("print", &object.Builtin{
-		Name: "print",
-		Fn: func(args ...object.Object) object.Object {
-			for _, arg := range args {
-				fmt.Println(arg.Inspect())
-			}
-			return evaluator.NullObj
-		},
-	})
-	
+	// スクリプトへの引数を args として参照できるようにする
+	scriptArgs := make([]object.Object, len(config.GlobalConfig.ScriptArgs))
+	for i, arg := range config.GlobalConfig.ScriptArgs {
+		scriptArgs[i] = &object.String{Value: arg}
+	}
+	env.Set("args", &object.Array{Elements: scriptArgs})
+
 	// 評価器から組み込み関数をすべてインポート
 	// evaluator.Builtinsに登録されている関数をすべて環境に追加
 	for name, builtin := range evaluator.Builtins {
@@ -43,208 +42,154 @@ func SetupBuiltins(env *object.Environment) {
 	}
 }
 
-// convertToObjectIdentifiers は ast.Identifier スライスを object.Identifier スライスに変換する
-func convertToObjectIdentifiers(params []*ast.Identifier) []*object.Identifier {
-	if params == nil {
-		return nil
-	}
-	
-	result := make([]*object.Identifier, len(params))
-	for i, param := range params {
-		result[i] = &object.Identifier{Value: param.Value}
-	}
-	return result
-}
+// strictPragma はファイル単位で厳密モードを有効にするプラグマ
+const strictPragma = "@strict"
 
-// findAndRegisterFunctionsInExpression は式の中から関数定義を再帰的に探索する
-func findAndRegisterFunctionsInExpression(expr ast.Expression, env *object.Environment, count *int) {
-	if expr == nil {
-		return
-	}
-
-	switch e := expr.(type) {
-	case *ast.FunctionLiteral:
-		// 関数リテラルを見つけた場合
-		if e.Name != nil {
-			// 関数名があれば登録
-			function := &object.Function{
-				Parameters: convertToObjectIdentifiers(e.Parameters),
-				ASTBody:    e.Body,
-				Env:        env,
-				InputType:  e.InputType,
-				ReturnType: e.ReturnType,
-				Condition:  e.Condition,
-			}
-
-			funcName := e.Name.Value
-			logger.Debug("埋め込み関数定義: 関数 '%s' の定義を見つけました", funcName)
-
-			// 条件付き関数の場合の特別な処理
-			if e.Condition != nil {
-				existingFuncs := env.GetAllFunctionsByName(funcName)
-				uniqueName := fmt.Sprintf("%s#%d", funcName, len(existingFuncs))
-				logger.Debug("埋め込み条件付き関数 '%s' を '%s' として事前登録します", funcName, uniqueName)
-				env.Set(uniqueName, function)
-			}
-
-			// 通常の名前でも登録
-			env.Set(funcName, function)
-			*count++
-			logger.Debug("埋め込み関数 '%s' を事前登録しました", funcName)
+// hasStrictPragma はソース先頭のコメント行に厳密モードのプラグマ（// @strict）があるかを判定する
+// 最初のコメント以外の行が現れた時点で探索を終える
+func hasStrictPragma(source string) bool {
+	for i, line := range strings.Split(source, "\n") {
+		// 先頭の #! の行は読み飛ばす
+		if i == 0 && strings.HasPrefix(line, "#!") {
+			continue
 		}
-
-		// 関数本体内のステートメントも探索
-		if e.Body != nil {
-			for _, stmt := range e.Body.Statements {
-				if exprStmt, ok := stmt.(*ast.ExpressionStatement); ok {
-					findAndRegisterFunctionsInExpression(exprStmt.Expression, env, count)
-				}
-
-				if assignStmt, ok := stmt.(*ast.AssignStatement); ok {
-					findAndRegisterFunctionsInExpression(assignStmt.Value, env, count)
-				}
-			}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
 		}
-
-	case *ast.InfixExpression:
-		// 中置式の場合、左右の式を探索
-		findAndRegisterFunctionsInExpression(e.Left, env, count)
-		findAndRegisterFunctionsInExpression(e.Right, env, count)
-
-	case *ast.PrefixExpression:
-		// 前置式の場合、右の式を探索
-		findAndRegisterFunctionsInExpression(e.Right, env, count)
-
-	case *ast.CallExpression:
-		// 関数呼び出しの場合、関数と引数を探索
-		findAndRegisterFunctionsInExpression(e.Function, env, count)
-		for _, arg := range e.Arguments {
-			findAndRegisterFunctionsInExpression(arg, env, count)
+		if !strings.HasPrefix(trimmed, "//") {
+			return false
+		}
+		if strings.TrimSpace(strings.TrimPrefix(trimmed, "//")) == strictPragma {
+			return true
 		}
-
-	case *ast.IndexExpression:
-		// 添字式の場合、配列と添字を探索
-		findAndRegisterFunctionsInExpression(e.Left, env, count)
-		findAndRegisterFunctionsInExpression(e.Index, env, count)
 	}
+	return false
 }
 
-// preRegisterFunctions は ASTをトラバースして関数定義を事前に環境に登録する
-func preRegisterFunctions(program *ast.Program, env *object.Environment) {
-	if program == nil || len(program.Statements) == 0 {
-		return
+// exitCodeOf はプログラムの評価結果から終了コードを取り出す
+// exit 関数で終了した場合はその終了コード、トップレベルで💩に整数を代入した場合はその値を返す
+// 終了コードは0から255の範囲だけを受け付ける（範囲外の値は exit 関数や評価時にエラーにしている）
+func exitCodeOf(result object.Object) (int, bool) {
+	switch obj := result.(type) {
+	case *object.Error:
+		if obj.Exit {
+			return obj.ExitCode, true
+		}
+	case *object.ReturnValue:
+		if integer, ok := obj.Value.(*object.Integer); ok && integer.Value >= 0 && integer.Value <= 255 {
+			return int(integer.Value), true
+		}
 	}
+	return 0, false
+}
 
-	logger.Debug("関数の事前登録を開始します...")
-	registeredCount := 0
-
-	// プログラム内の全てのステートメントを走査
-	for _, stmt := range program.Statements {
-		// ExpressionStatement 内の FunctionLiteral を検出
-		// FUNCTION (def) で始まる式文を見つける
-		if exprStmt, ok := stmt.(*ast.ExpressionStatement); ok {
-			// 関数リテラルの場合
-			if funcLit, ok := exprStmt.Expression.(*ast.FunctionLiteral); ok {
-				if funcLit.Name != nil {
-					// 関数を環境に登録
-					function := &object.Function{
-						Parameters: convertToObjectIdentifiers(funcLit.Parameters),
-						ASTBody:    funcLit.Body,
-						Env:        env,
-						InputType:  funcLit.InputType,
-						ReturnType: funcLit.ReturnType,
-						Condition:  funcLit.Condition,
-					}
+// ExecuteSourceFile はソースファイルを読み込んで実行する
+func ExecuteSourceFile(filePath string) (*SourceCodeResult, error) {
+	// ファイル読み込み
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		result := &SourceCodeResult{}
+		reportDiagnostics(result, []Diagnostic{
+			NewDiagnostic(CodeUsageError, filePath, fmt.Sprintf("ファイルを読み込めませんでした: %s", err)),
+		})
+		return result, fmt.Errorf("ファイルを読み込めませんでした: %w", err)
+	}
 
-					// 関数名を取得
-					funcName := funcLit.Name.Value
-					logger.Debug("FunctionLiteral: 関数 '%s' の定義を見つけました", funcName)
+	return ExecuteSource(filePath, string(content))
+}
 
-					// 条件付き関数の場合の特別な処理
-					if funcLit.Condition != nil {
-						// 既存の同名関数の数をカウント
-						existingFuncs := env.GetAllFunctionsByName(funcName)
-						uniqueName := fmt.Sprintf("%s#%d", funcName, len(existingFuncs))
+// ExecuteSource はソースコードを実行する
+// name は診断メッセージに表示する名前で、-e や標準入力の場合は "<-e>" や "<stdin>" になる
+func ExecuteSource(name string, source string) (*SourceCodeResult, error) {
+	env := object.NewEnvironment()
+	SetupBuiltins(env)
+	return ExecuteSourceInEnvironment(name, source, env)
+}
 
-						logger.Debug("条件付き関数 '%s' を '%s' として事前登録します", funcName, uniqueName)
+// CheckSource はソースコードを実行せずに字句解析と構文解析だけを行い、見つかったエラーを返す
+func CheckSource(name string, source string) []Diagnostic {
+	_, diagnostics := ParseSource(name, source)
+	return diagnostics
+}
 
-						// 特別な名前で登録
-						env.Set(uniqueName, function)
-					}
+// TokenizeSource はソースコードを字句解析し、トークン列を返す
+func TokenizeSource(name string, source string) ([]token.Token, []Diagnostic) {
+	return tokenize(name, source)
+}
 
-					// 通常の名前でも登録
-					env.Set(funcName, function)
-					registeredCount++
-					logger.Debug("関数 '%s' を事前登録しました", funcName)
-				}
-			}
-		}
+// ParseSource はソースコードを実行せずに字句解析と構文解析を行い、AST を返す
+func ParseSource(name string, source string) (*ast.Program, []Diagnostic) {
+	tokens, diagnostics := tokenize(name, source)
+	if len(diagnostics) > 0 {
+		return nil, diagnostics
+	}
+	return parse(name, tokens)
+}
 
-		// AssignStatement 内の FunctionLiteral を検出（関数を変数に代入するケース）
-		if assignStmt, ok := stmt.(*ast.AssignStatement); ok {
-			if funcLit, ok := assignStmt.Value.(*ast.FunctionLiteral); ok {
-				if ident, ok := assignStmt.Left.(*ast.Identifier); ok {
-					logger.Debug("AssignStatement: 関数を変数 '%s' に代入する定義を見つけました", ident.Value)
-					
-					// 関数を環境に登録
-					function := &object.Function{
-						Parameters: convertToObjectIdentifiers(funcLit.Parameters),
-						ASTBody:    funcLit.Body,
-						Env:        env,
-						InputType:  funcLit.InputType,
-						ReturnType: funcLit.ReturnType,
-						Condition:  funcLit.Condition,
-					}
+// tokenize はソースコードをトークン列にする
+// 不正な文字や閉じられていない文字列補間は字句解析エラーとして返す
+func tokenize(name string, source string) ([]token.Token, []Diagnostic) {
+	tokens, err := lexer.NewLexer(source).Tokenize()
+	if err != nil {
+		return nil, []Diagnostic{NewDiagnostic(CodeLexError, name, err.Error())}
+	}
+	return tokens, lexDiagnostics(name, tokens)
+}
 
-					// 代入先の変数名を関数名として使用
-					funcName := ident.Value
-					env.Set(funcName, function)
-					registeredCount++
-					logger.Debug("代入式の関数 '%s' を事前登録しました", funcName)
-				}
-			}
+// parse はトークン列を構文解析する
+func parse(name string, tokens []token.Token) (*ast.Program, []Diagnostic) {
+	p := parser.NewParser(tokens)
+	program, err := p.ParseProgram()
+	if err != nil {
+		if len(p.ParseErrors()) == 0 {
+			return nil, []Diagnostic{NewDiagnostic(CodeParseError, name, err.Error())}
 		}
-		logger.Debug("")
+		return nil, parseDiagnostics(name, p.ParseErrors())
 	}
+	return program, nil
+}
 
-	// 第二パス: すべてのステートメントを再度走査して、埋もれた関数定義を見つける
-	// 特に、コメントの後に出現する可能性がある関数定義を見つけるため
-	for _, stmt := range program.Statements {
-		// トップレベルの式を探索
-		if exprStmt, ok := stmt.(*ast.ExpressionStatement); ok {
-			// より複雑な式の中にある関数定義を掘り下げる
-			findAndRegisterFunctionsInExpression(exprStmt.Expression, env, &registeredCount)
+// reportDiagnostics はエラーを結果に記録し、終了コードを設定する
+// --diagnostics=text の場合はログにも出力する（json の場合は呼び出し元がまとめて出力する）
+func reportDiagnostics(result *SourceCodeResult, diagnostics []Diagnostic) error {
+	result.Diagnostics = append(result.Diagnostics, diagnostics...)
+	result.ExitCode = exitCodeFor(result.Diagnostics)
+	if config.GlobalConfig.Diagnostics != config.DiagnosticsJSON {
+		for _, d := range diagnostics {
+			logger.Error("%s", d)
 		}
 	}
-
-	logger.Debug("関数の事前登録が完了しました。%d 個の関数を登録しました", registeredCount)
+	return fmt.Errorf("%s", diagnostics[0])
 }
 
-func ExecuteSourceFile(filePath string) (*SourceCodeResult, error) {
+// ExecuteSourceInEnvironment は既存の環境でソースコードを実行する
+// REPL のように、それまでに定義した変数や関数を引き継いで評価する場合に使用する
+func ExecuteSourceInEnvironment(name string, source string, env *object.Environment) (*SourceCodeResult, error) {
 	result := &SourceCodeResult{
+		Source:   source,
 		ExitCode: 0,
 	}
 
-	// ファイル読み込み
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("ファイルを読み込めませんでした: %w", err)
+	// 厳密モードの設定（フラグまたはファイル先頭のプラグマ）
+	strict := config.GlobalConfig.StrictMode || hasStrictPragma(source)
+	evaluator.SetStrictMode(strict)
+	if strict {
+		logger.Debug("厳密モードが有効です: 暗黙の型変換を行いません")
 	}
+	evaluator.SetMaxCallDepth(config.GlobalConfig.MaxCallDepth)
 
 	// ファイル内容をデバッグ出力
 	if config.GlobalConfig.ShowLexerDebug {
-		logger.Debug("ファイル内容:\n%s\n", string(content))
+		logger.Debug("ファイル内容:\n%s\n", source)
 	}
 
 	// レキサーでトークン化
-	l := lexer.NewLexer(string(content))
-	tokens, err := l.Tokenize()
-	if err != nil {
-		logger.Error("レキサーエラー: %s\n", err)
-		result.ExitCode = 1
-		return result, err
-	}
+	tokens, diagnostics := tokenize(name, source)
 	result.Tokens = tokens
+	if len(diagnostics) > 0 {
+		return result, reportDiagnostics(result, diagnostics)
+	}
 
 	// トークン列をデバッグ出力
 	if config.GlobalConfig.ShowLexerDebug {
@@ -255,12 +200,9 @@ func ExecuteSourceFile(filePath string) (*SourceCodeResult, error) {
 	}
 
 	// パーサーで構文解析
-	p := parser.NewParser(tokens)
-	program, err := p.ParseProgram()
-	if err != nil {
-		logger.Error("パーサーエラー: %s\n", err)
-		result.ExitCode = 1
-		return result, err
+	program, diagnostics := parse(name, tokens)
+	if len(diagnostics) > 0 {
+		return result, reportDiagnostics(result, diagnostics)
 	}
 	result.Program = program
 
@@ -271,13 +213,9 @@ func ExecuteSourceFile(filePath string) (*SourceCodeResult, error) {
 	}
 
 	// インタプリタで実行
-	env := object.NewEnvironment()
-	SetupBuiltins(env)
-	
 	// 関数の事前登録を実行（設定が有効な場合のみ）
 	if config.GlobalConfig.PreregisterFunctions {
 		logger.Debug("関数の事前登録機能が有効です")
-		// preRegisterFunctions(program, env)
 		evaluator.PreregisterFunctions(program, env)
 	}
 
@@ -293,11 +231,15 @@ func ExecuteSourceFile(filePath string) (*SourceCodeResult, error) {
 
 	evalResult := evaluator.Eval(program, env)
 	result.Result = evalResult
+
+	// exit 関数やトップレベルの💩の値を終了コードにする
+	if code, ok := exitCodeOf(evalResult); ok {
+		result.ExitCode = code
+		return result, nil
+	}
 	
-	if evalResult != nil && evalResult.Type() == object.ERROR_OBJ {
-		logger.Error("実行時エラー: %s\n", evalResult.Inspect())
-		result.ExitCode = 1
-		return result, fmt.Errorf("実行時エラー: %s", evalResult.Inspect())
+	if errObj, ok := evalResult.(*object.Error); ok {
+		return result, reportDiagnostics(result, []Diagnostic{errorDiagnostic(name, errObj)})
 	}
 
 	// 実行結果を表示
@@ -0,0 +1,239 @@
+package runtime
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/uncode/config"
+	"github.com/uncode/evaluator"
+	"github.com/uncode/logger"
+	"github.com/uncode/object"
+)
+
+// TestHasStrictPragma はファイル先頭の厳密モードのプラグマの検出をテストする
+func TestHasStrictPragma(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected bool
+	}{
+		{"// @strict\n1 |> print;", true},
+		{"\n// 説明コメント\n//   @strict  \n1 |> print;", true},
+		{"1 |> print;\n// @strict", false},
+		{"// @strictly\n1 |> print;", false},
+		{"", false},
+		{"#!/usr/bin/env uncode\n// @strict\n1 |> print;", true},
+		{"#!/usr/bin/env uncode\n1 |> print;\n// @strict", false},
+	}
+
+	for _, tt := range tests {
+		if got := hasStrictPragma(tt.source); got != tt.expected {
+			t.Errorf("hasStrictPragma(%q) = %v, want %v", tt.source, got, tt.expected)
+		}
+	}
+}
+
+// TestExitCodeOf はプログラムの評価結果から終了コードを取り出せることをテストする
+func TestExitCodeOf(t *testing.T) {
+	tests := []struct {
+		result   object.Object
+		expected int
+		ok       bool
+	}{
+		{&object.Error{Message: "exit", Exit: true, ExitCode: 3}, 3, true},
+		{&object.ReturnValue{Value: &object.Integer{Value: 7}}, 7, true},
+		{&object.ReturnValue{Value: &object.String{Value: "7"}}, 0, false},
+		{&object.ReturnValue{Value: &object.Integer{Value: 300}}, 0, false},
+		{&object.ReturnValue{Value: &object.Integer{Value: -1}}, 0, false},
+		{&object.Error{Message: "実行時エラー"}, 0, false},
+		{&object.Integer{Value: 7}, 0, false},
+		{nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		code, ok := exitCodeOf(tt.result)
+		if code != tt.expected || ok != tt.ok {
+			t.Errorf("exitCodeOf(%v) = %d, %v, want %d, %v", tt.result, code, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+// TestExecuteSource はファイル以外から渡されたソースコードを実行できることをテストする
+func TestExecuteSource(t *testing.T) {
+	logger.SetLevel(logger.LevelOff)
+	var out bytes.Buffer
+	evaluator.SetOutput(&out)
+	t.Cleanup(func() { evaluator.SetOutput(os.Stdout) })
+
+	result, err := ExecuteSource("<-e>", "#!/usr/bin/env uncode\n[1..3] +> mul 2 |> print;\n4 >> 💩;")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := out.String(); got != "[2, 4, 6]\n" {
+		t.Errorf("wrong output. got=%q", got)
+	}
+	if result.ExitCode != 4 {
+		t.Errorf("wrong exit code. expected=4, got=%d", result.ExitCode)
+	}
+
+	result, err = ExecuteSource("<stdin>", `1 / 0;`)
+	if err == nil || result.ExitCode != 1 {
+		t.Errorf("runtime error should fail with exit code 1. err=%v, code=%d", err, result.ExitCode)
+	}
+}
+
+// TestDiagnostics はエラーの種類ごとの診断コード・終了コード・位置・呼び出し履歴をテストする
+func TestDiagnostics(t *testing.T) {
+	logger.SetLevel(logger.LevelOff)
+	evaluator.SetOutput(io.Discard)
+	prevDepth := config.GlobalConfig.MaxCallDepth
+	t.Cleanup(func() {
+		evaluator.SetOutput(os.Stdout)
+		config.GlobalConfig.MaxCallDepth = prevDepth
+	})
+	config.GlobalConfig.MaxCallDepth = 50
+
+	tests := []struct {
+		source   string
+		code     string
+		exitCode int
+		line     int
+		stack    []string
+	}{
+		{"1 |> print;\n\"${1 + 2\" |> print;", CodeLexError, ExitLexError, 2, nil},
+		{"1 |> print;\n1 + ;", CodeParseError, ExitParseError, 2, nil},
+		{
+			"def half(): int -> int {\n  🍕 / 0 >> 💩\n}\ndef twice(): int -> int {\n  🍕 |> half >> 💩\n}\n3 |> twice |> print;\n\"never\" |> print;",
+			CodeRuntimeError, ExitRuntimeError, 2,
+			[]string{"half (main.poo:5:3)", "twice (main.poo:7:1)"},
+		},
+		{"def f(): int -> int {\n  🍕 * 2 >> 💩\n}\n\"x\" |> f;", CodeTypeError, ExitTypeError, 2, []string{"f (main.poo:4:1)"}},
+		{
+			"def down(): int -> int {\n  🍕 - 1 |> down >> 💩\n}\n1 |> down;",
+			CodeLimitExceeded, ExitLimitExceeded, 2,
+			[]string{"down (main.poo:2:3)", "(同じ呼び出しが 48 回続いています)", "down (main.poo:4:1)"},
+		},
+	}
+
+	for _, tt := range tests {
+		result, err := ExecuteSource("main.poo", tt.source)
+		if err == nil {
+			t.Errorf("%q: expected an error", tt.source)
+			continue
+		}
+		if result.ExitCode != tt.exitCode || len(result.Diagnostics) != 1 {
+			t.Errorf("%q: wrong exit code or diagnostics. code=%d, diagnostics=%+v", tt.source, result.ExitCode, result.Diagnostics)
+			continue
+		}
+		d := result.Diagnostics[0]
+		if d.Code != tt.code || d.File != "main.poo" || d.Line != tt.line || d.Severity != SeverityError {
+			t.Errorf("%q: wrong diagnostic. got=%+v", tt.source, d)
+		}
+		if strings.Join(d.Stack, "|") != strings.Join(tt.stack, "|") {
+			t.Errorf("%q: wrong stack. expected=%q, got=%q", tt.source, tt.stack, d.Stack)
+		}
+	}
+
+	// JSON はエラーがなくても空の配列になり、stack は常に配列になる
+	var buf bytes.Buffer
+	WriteDiagnosticsJSON(&buf, nil)
+	WriteDiagnosticsJSON(&buf, []Diagnostic{NewDiagnostic(CodeUsageError, "<stdin>", "x")})
+	expected := "[]\n" + `[{"code":"usage-error","severity":"error","file":"<stdin>","line":0,"column":0,"message":"x","stack":[]}]` + "\n"
+	if buf.String() != expected {
+		t.Errorf("wrong JSON. got=%q", buf.String())
+	}
+}
+
+// TestParseDiagnosticPositions はパースエラーの診断に列番号がつき、同じエラーが重複しないことをテストする
+func TestParseDiagnosticPositions(t *testing.T) {
+	logger.SetLevel(logger.LevelOff)
+
+	tests := []struct {
+		source  string
+		columns []int
+	}{
+		{"1 |> print;\n1 + ;", []int{5}},
+		{"+ + ;", []int{1, 3}},
+	}
+
+	for _, tt := range tests {
+		result, _ := ExecuteSource("main.poo", tt.source)
+		if len(result.Diagnostics) != len(tt.columns) {
+			t.Errorf("%q: wrong number of diagnostics. got=%+v", tt.source, result.Diagnostics)
+			continue
+		}
+		for i, d := range result.Diagnostics {
+			if d.Code != CodeParseError || d.Column != tt.columns[i] {
+				t.Errorf("%q: wrong diagnostic %d. expected column=%d, got=%+v", tt.source, i, tt.columns[i], d)
+			}
+		}
+	}
+}
+
+// TestJSONDiagnosticsLogs は --diagnostics=json で実行時エラーをログに書かないことをテストする
+func TestJSONDiagnosticsLogs(t *testing.T) {
+	var logs bytes.Buffer
+	logger.SetLevel(logger.LevelError)
+	logger.SetOutput(&logs)
+	evaluator.SetOutput(io.Discard)
+	prevDiagnostics := config.GlobalConfig.Diagnostics
+	t.Cleanup(func() {
+		logger.SetOutput(os.Stdout)
+		evaluator.SetOutput(os.Stdout)
+		config.GlobalConfig.Diagnostics = prevDiagnostics
+	})
+	config.GlobalConfig.Diagnostics = config.DiagnosticsJSON
+
+	result, err := ExecuteSource("main.poo", "1 / 0;")
+	if err == nil || len(result.Diagnostics) != 1 || result.Diagnostics[0].Code != CodeRuntimeError {
+		t.Fatalf("expected a runtime error diagnostic. got=%+v", result.Diagnostics)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("runtime errors should not be logged with --diagnostics=json. logs=%q", logs.String())
+	}
+}
+
+// benchmarkSource は関数呼び出し・条件付き関数・case 文・map と fold を多く含むプログラム
+var benchmarkSource = strings.Join([]string{
+	"[1..300] >> xs;",
+	"def sq(): int -> int {",
+	"  🍕 * 🍕 >> 💩;",
+	"}",
+	"def sign() if 🍕 % 3 == 0 {",
+	"  1 >> 💩;",
+	"}",
+	"def sign() if 🍕 % 3 == 1 {",
+	"  2 >> 💩;",
+	"}",
+	"def sign() if 🍕 % 3 == 2 {",
+	"  3 >> 💩;",
+	"}",
+	"def kind(): int -> int {",
+	"  case 🍕 % 2 == 0: {",
+	"    🍕 |> sq >> 💩;",
+	"  }",
+	"  default: {",
+	"    🍕 |> sign >> 💩;",
+	"  }",
+	"}",
+	"xs +> kind /> add >> total;",
+}, "\n")
+
+// BenchmarkFunctionCalls はログを出力しない設定で、関数呼び出しの多いプログラムの実行時間を計測する
+// 無効なログのコスト（引数の組み立てやロックの取得）が評価器の実行時間に含まれないことを確認するために使う
+func BenchmarkFunctionCalls(b *testing.B) {
+	logger.SetLevel(logger.LevelInfo)
+	evaluator.SetOutput(io.Discard)
+	b.Cleanup(func() {
+		logger.SetLevel(logger.LevelOff)
+		evaluator.SetOutput(os.Stdout)
+	})
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ExecuteSource("bench.poo", benchmarkSource); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
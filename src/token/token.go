@@ -18,6 +18,7 @@ const (
 	INT     = "INT"     // 整数リテラル
 	FLOAT   = "FLOAT"   // 浮動小数点リテラル
 	STRING  = "STRING"  // 文字列リテラル
+	INTERP_STRING = "INTERP_STRING" // ${...} を含む補間文字列リテラル
 	BOOLEAN = "BOOLEAN" // 真偽値リテラル
 
 	// 演算子
@@ -63,6 +64,7 @@ const (
 	// 特殊パイプ演算子
 	MAP_PIPE = "+>" // map関数をパイプとして使用
 	FILTER_PIPE = "?>" // filter関数をパイプとして使用
+	FOLD_PIPE = "/>" // fold(reduce)関数をパイプとして使用
 
 	// キーワード
 	FUNCTION = "def"     // 関数定義
@@ -91,6 +93,14 @@ type Token struct {
 	Literal string    // トークンのリテラル値
 	Line    int       // トークンの行番号
 	Column  int       // トークンの列番号
+	Parts   []StringPart // 補間文字列（INTERP_STRING）の構成要素
+}
+
+// StringPart は補間文字列を構成する文字列部分または ${...} 内の式を表す
+type StringPart struct {
+	IsExpr bool    // ${...} 内の式の場合はtrue
+	Value  string  // 文字列部分（エスケープ処理済み）または式のソース
+	Tokens []Token // 式の場合のトークン列（EOFで終わる）
 }
 
 // キーワードマップ
@@ -115,6 +125,7 @@ var keywords = map[string]TokenType{
 	"show":    IDENT, // 代替としてshowも追加
 	"map":     MAP_PIPE, // mapをパイプとして追加
 	"filter":  FILTER_PIPE, // filterをパイプとして追加
+	"fold":    FOLD_PIPE, // foldをパイプとして追加
 }
 
 // LookupIdent は識別子がキーワードかどうかを判定する
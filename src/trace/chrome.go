@@ -0,0 +1,138 @@
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/uncode/evaluator"
+	"github.com/uncode/object"
+)
+
+const (
+	// tracePID はイベントに記録するプロセス ID
+	tracePID = 1
+	// mainThreadID はメインのゴルーチンのスレッド ID
+	// 並列パイプ (|) は未実装のため、現在はすべてのイベントをこのスレッドに記録する
+	mainThreadID = 1
+)
+
+// chromeEvent は Chrome Trace Event 形式のイベント
+// https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+type chromeEvent struct {
+	Name string            `json:"name"`
+	Cat  string            `json:"cat,omitempty"`
+	Ph   string            `json:"ph"`
+	Ts   float64           `json:"ts"` // マイクロ秒
+	Pid  int               `json:"pid"`
+	Tid  int               `json:"tid"`
+	Args map[string]string `json:"args,omitempty"`
+}
+
+// Chrome は evaluator.Profiler を実装し、関数・組み込み関数・パイプラインの段の開始と終了を
+// Chrome Trace Event 形式の JSON として書き出す。Perfetto や chrome://tracing で読み込める
+// イベントは実行しながら書き出すため、長い実行でもメモリに溜め込まない
+type Chrome struct {
+	w      *bufio.Writer
+	file   string // ソースファイル名（ユーザー定義関数と段の位置に使う）
+	now    func() time.Time
+	start  time.Time
+	stack  []evaluator.ProfileFrame
+	events int
+	err    error // 最初に発生した書き込みエラー
+}
+
+// NewChrome はトレースの書き出しを開始する
+func NewChrome(w io.Writer, file string) *Chrome {
+	return newChrome(w, file, time.Now)
+}
+
+// newChrome は時刻の取得方法を指定してトレースの書き出しを開始する
+func newChrome(w io.Writer, file string, now func() time.Time) *Chrome {
+	c := &Chrome{w: bufio.NewWriter(w), file: file, now: now}
+	c.start = now()
+	_, c.err = c.w.WriteString(`{"displayTimeUnit":"ms","traceEvents":[` + "\n")
+	c.write(chromeEvent{Name: "process_name", Ph: "M", Pid: tracePID, Tid: mainThreadID, Args: map[string]string{"name": "uncode " + file}})
+	c.write(chromeEvent{Name: "thread_name", Ph: "M", Pid: tracePID, Tid: mainThreadID, Args: map[string]string{"name": mainParticipant}})
+	return c
+}
+
+// write はイベントを1つ書き出す
+func (c *Chrome) write(e chromeEvent) {
+	if c.err != nil {
+		return
+	}
+	// 段の名前の |> などが \u003e にならないように、HTML 向けのエスケープはしない
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(e); err != nil {
+		c.err = err
+		return
+	}
+	if c.events > 0 {
+		c.w.WriteString(",\n")
+	}
+	c.events++
+	_, c.err = c.w.Write(bytes.TrimSuffix(data.Bytes(), []byte("\n")))
+}
+
+// timestamp は開始からの経過時間をマイクロ秒で返す
+func (c *Chrome) timestamp() float64 {
+	return float64(c.now().Sub(c.start).Nanoseconds()) / 1000
+}
+
+// Enter は関数や段の開始イベントを、🍕 の値と位置を引数として書き出す
+func (c *Chrome) Enter(frame evaluator.ProfileFrame, input object.Object) {
+	c.stack = append(c.stack, frame)
+	args := make(map[string]string)
+	if input != nil {
+		args["🍕"] = formatValue(input)
+	}
+	if !frame.Builtin && frame.Line > 0 {
+		args["位置"] = fmt.Sprintf("%s:%d", c.file, frame.Line)
+	}
+	c.write(chromeEvent{Name: frame.Name, Cat: category(frame), Ph: "B", Ts: c.timestamp(), Pid: tracePID, Tid: mainThreadID, Args: args})
+}
+
+// Exit は最後に開始した関数や段の終了イベントを、💩 の結果を引数として書き出す
+func (c *Chrome) Exit(result object.Object) {
+	if len(c.stack) == 0 {
+		return
+	}
+	frame := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+	var args map[string]string
+	if result != nil {
+		args = map[string]string{"💩": formatValue(result)}
+	}
+	c.write(chromeEvent{Name: frame.Name, Cat: category(frame), Ph: "E", Ts: c.timestamp(), Pid: tracePID, Tid: mainThreadID, Args: args})
+}
+
+// Close は終了していない関数や段をこの時点で終了したものとして書き出し、トレースを閉じる
+func (c *Chrome) Close() error {
+	for len(c.stack) > 0 {
+		c.Exit(nil)
+	}
+	if c.err != nil {
+		return c.err
+	}
+	if _, err := c.w.WriteString("\n]}\n"); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+// category はイベントの分類を返す（トレースビューアでの絞り込みに使う）
+func category(frame evaluator.ProfileFrame) string {
+	switch {
+	case frame.Builtin:
+		return "builtin"
+	case frame.Stage:
+		return "stage"
+	}
+	return "function"
+}
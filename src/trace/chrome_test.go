@@ -0,0 +1,77 @@
+package trace
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uncode/evaluator"
+	"github.com/uncode/object"
+)
+
+// TestChrome は開始と終了のイベント、🍕 と 💩 の引数、終了していないフレームの扱いをテストする
+func TestChrome(t *testing.T) {
+	clock := time.Unix(0, 0)
+	now := func() time.Time {
+		clock = clock.Add(1500 * time.Nanosecond)
+		return clock
+	}
+
+	var out strings.Builder
+	c := newChrome(&out, "main.poo", now)
+	stage := evaluator.ProfileFrame{Name: "|> sq", Stage: true, Line: 7, Column: 3}
+	sq := evaluator.ProfileFrame{Name: "sq", Line: 1}
+	print := evaluator.ProfileFrame{Name: "print", Builtin: true}
+	c.Enter(stage, &object.Integer{Value: 3})
+	c.Enter(sq, &object.Integer{Value: 3})
+	c.Exit(&object.ReturnValue{Value: &object.Integer{Value: 9}})
+	c.Exit(&object.Integer{Value: 9})
+	c.Enter(print, &object.Integer{Value: 9})
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var trace struct {
+		TraceEvents []chromeEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &trace); err != nil {
+		t.Fatalf("invalid JSON: %s\n%s", err, out.String())
+	}
+	expected := []struct {
+		name string
+		cat  string
+		ph   string
+		ts   float64
+		args map[string]string
+	}{
+		{"process_name", "", "M", 0, map[string]string{"name": "uncode main.poo"}},
+		{"thread_name", "", "M", 0, map[string]string{"name": "メイン"}},
+		{"|> sq", "stage", "B", 1.5, map[string]string{"🍕": "3", "位置": "main.poo:7"}},
+		{"sq", "function", "B", 3, map[string]string{"🍕": "3", "位置": "main.poo:1"}},
+		{"sq", "function", "E", 4.5, map[string]string{"💩": "9"}},
+		{"|> sq", "stage", "E", 6, map[string]string{"💩": "9"}},
+		{"print", "builtin", "B", 7.5, map[string]string{"🍕": "9"}},
+		{"print", "builtin", "E", 9, nil},
+	}
+	if len(trace.TraceEvents) != len(expected) {
+		t.Fatalf("wrong number of events. got=%+v", trace.TraceEvents)
+	}
+	for i, e := range expected {
+		got := trace.TraceEvents[i]
+		if got.Name != e.name || got.Cat != e.cat || got.Ph != e.ph || got.Ts != e.ts || got.Pid != tracePID || got.Tid != mainThreadID {
+			t.Errorf("event %d wrong. expected=%+v, got=%+v", i, e, got)
+		}
+		if len(got.Args) != len(e.args) {
+			t.Errorf("event %d args wrong. expected=%v, got=%v", i, e.args, got.Args)
+		}
+		for k, v := range e.args {
+			if got.Args[k] != v {
+				t.Errorf("event %d arg %s wrong. expected=%q, got=%q", i, k, v, got.Args[k])
+			}
+		}
+	}
+	if !strings.Contains(out.String(), `"name":"|> sq"`) {
+		t.Errorf("stage names should not be HTML-escaped.\n%s", out.String())
+	}
+}
@@ -0,0 +1,352 @@
+// Package trace は実行の流れを記録し、Mermaid のシーケンス図や Chrome Trace Event 形式で出力する
+package trace
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/uncode/ast"
+	"github.com/uncode/evaluator"
+	"github.com/uncode/object"
+)
+
+const (
+	// maxMessages はシーケンス図に書き出すメッセージ数の上限
+	// 長いループや深い再帰でも図を描画できる大きさに収めるため、これを超えたメッセージは省略する
+	maxMessages = 1000
+	// maxElementCalls は +> ?> /> の段で、要素ごとの呼び出しを図に書き出す回数の上限
+	maxElementCalls = 3
+	// maxArrayElements は値の表示で配列の要素を書き出す数の上限
+	maxArrayElements = 5
+	// maxValueLength は値の表示の長さ（文字数）の上限
+	maxValueLength = 60
+)
+
+// mainParticipant はプログラム全体を表すパーティシパントの名前
+const mainParticipant = "メイン"
+
+// entryKind は実行中の段や関数呼び出しの種類
+type entryKind int
+
+const (
+	entryStage   entryKind = iota // パイプラインの段
+	entryCall                     // 関数の呼び出し
+	entryElement                  // +> ?> /> の段での要素ごとの呼び出し
+	entryQuiet                    // |> の段が呼び出した関数（段の矢印で表すため図には書き出さない）
+)
+
+// entry は実行中の段や関数呼び出し
+type entry struct {
+	kind    entryKind
+	from    string // 呼び出し元のパーティシパント
+	to      string // 呼び出し先のパーティシパント
+	node    *ast.InfixExpression
+	called  bool // |> の段が関数を呼び出したか
+	calls   int  // +> ?> /> の段で要素ごとに呼び出した回数
+	omitted bool // 要素ごとの呼び出しの上限を超えたため図に書き出さない
+	looped  bool // loop を書き出したか（対応する end は上限を超えても書き出す）
+}
+
+// Mermaid は evaluator.Hook を実装し、実行の流れを Mermaid のシーケンス図として記録する
+// パイプラインの段ごとに渡した 🍕 の値、選ばれた条件付き関数や case 文、💩 の結果をメッセージにする
+type Mermaid struct {
+	file         string
+	participants []string          // 最初に登場した順に並べたパーティシパントの名前
+	ids          map[string]string // パーティシパントの名前から図の中での ID
+	lines        []string
+	messages     int
+	dropped      int    // 上限を超えたため省略したメッセージの数
+	pending      string // 次のメッセージの前に書き出す文のコメント
+	stack        []*entry
+	muted        int // 省略中の要素ごとの呼び出しの深さ（0 より大きい間は何も書き出さない）
+	indent       int // loop の入れ子の深さ
+}
+
+// NewMermaid はシーケンス図の記録を作成する
+func NewMermaid(file string) *Mermaid {
+	m := &Mermaid{file: file, ids: make(map[string]string)}
+	m.participant(mainParticipant)
+	return m
+}
+
+// current は現在実行しているパーティシパントの名前を返す
+func (m *Mermaid) current() string {
+	if len(m.stack) == 0 {
+		return mainParticipant
+	}
+	return m.stack[len(m.stack)-1].to
+}
+
+// participant はパーティシパントの ID を返す。まだ登場していなければ追加する
+func (m *Mermaid) participant(name string) string {
+	if id, ok := m.ids[name]; ok {
+		return id
+	}
+	id := "Main"
+	if name != mainParticipant {
+		id = fmt.Sprintf("Fn%d", len(m.participants))
+	}
+	m.ids[name] = id
+	m.participants = append(m.participants, name)
+	return id
+}
+
+// emit は1行を書き出し、書き出したかどうかを返す
+func (m *Mermaid) emit(line string) bool {
+	if m.muted > 0 {
+		return false
+	}
+	if m.messages >= maxMessages {
+		m.dropped++
+		return false
+	}
+	if m.pending != "" {
+		m.lines = append(m.lines, "", m.pending)
+		m.pending = ""
+	}
+	m.messages++
+	m.lines = append(m.lines, strings.Repeat("    ", m.indent)+line)
+	return true
+}
+
+// arrow は from から to へのメッセージを書き出す。reply が true なら戻りの矢印にする
+func (m *Mermaid) arrow(from string, to string, reply bool, text string) {
+	if m.muted > 0 {
+		return
+	}
+	kind := "->>"
+	if reply {
+		kind = "-->>"
+	}
+	m.emit(fmt.Sprintf("%s%s%s: %s", m.participant(from), kind, m.participant(to), escape(text)))
+}
+
+// note はパーティシパントの上に注記を書き出す
+func (m *Mermaid) note(over string, text string) {
+	if m.muted > 0 {
+		return
+	}
+	m.emit(fmt.Sprintf("Note over %s: %s", m.participant(over), escape(text)))
+}
+
+// push は実行中の段や関数呼び出しを追加する
+func (m *Mermaid) push(e *entry) {
+	if e.omitted {
+		m.muted++
+	}
+	m.stack = append(m.stack, e)
+}
+
+// pop は最後に追加した段や関数呼び出しを取り除く
+func (m *Mermaid) pop() *entry {
+	if len(m.stack) == 0 {
+		return nil
+	}
+	e := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	if e.omitted {
+		m.muted--
+	}
+	return e
+}
+
+// BeforeEval はトップレベルの文を、その文のメッセージの前に書き出すコメントとして記録する
+func (m *Mermaid) BeforeEval(node ast.Node, env *object.Environment) {
+	if len(m.stack) > 0 {
+		return
+	}
+	var line int
+	switch stmt := node.(type) {
+	case *ast.ExpressionStatement:
+		if _, ok := stmt.Expression.(*ast.FunctionLiteral); ok {
+			return
+		}
+		line = stmt.Token.Line
+	case *ast.AssignStatement:
+		line = stmt.Token.Line
+	default:
+		return
+	}
+	m.pending = fmt.Sprintf("%%%% %d行目: %s", line, strings.ReplaceAll(node.String(), "\n", " "))
+}
+
+// BeforeStage は段に 🍕 の値を渡すメッセージを書き出す
+func (m *Mermaid) BeforeStage(node *ast.InfixExpression, input object.Object, env *object.Environment) {
+	e := &entry{kind: entryStage, from: m.current(), to: stageTarget(node), node: node}
+	m.arrow(e.from, e.to, false, fmt.Sprintf("%s %s (🍕 = %s)", node.Operator, node.Right.String(), formatValue(input)))
+	m.push(e)
+}
+
+// AfterStage は段の 💩 の結果を返すメッセージを書き出す
+func (m *Mermaid) AfterStage(node *ast.InfixExpression, result object.Object) {
+	// 段の中で戻らなかった関数呼び出しがあれば、その段まで取り除く
+	var e *entry
+	for len(m.stack) > 0 {
+		if e = m.pop(); e.kind == entryStage && e.node == node {
+			break
+		}
+		e = nil
+	}
+	if e == nil {
+		return
+	}
+	if e.looped {
+		if e.calls > maxElementCalls {
+			m.note(e.to, fmt.Sprintf("残り %d 回の呼び出しは省略", e.calls-maxElementCalls))
+		}
+		m.indent--
+		m.lines = append(m.lines, strings.Repeat("    ", m.indent)+"end")
+	}
+	m.arrow(e.to, e.from, true, "💩 = "+formatValue(result))
+}
+
+// EnterFunction は関数の呼び出しを書き出す
+// 条件付き関数の場合は、一致した条件を注記する
+func (m *Mermaid) EnterFunction(fn *object.Function, env *object.Environment) {
+	name := evaluator.FunctionName(fn)
+	var top *entry
+	if len(m.stack) > 0 {
+		top = m.stack[len(m.stack)-1]
+	}
+	switch {
+	case top != nil && top.kind == entryStage && top.node.Operator == "|>" && !top.called:
+		top.called = true
+		m.push(&entry{kind: entryQuiet, from: top.to, to: top.to})
+	case top != nil && top.kind == entryStage && top.node.Operator != "|>":
+		// +> ?> /> の段では、要素ごとの呼び出しを loop で囲む
+		// 範囲などの遅延ストリームは段の中では呼び出さないので、最初の呼び出しで loop を始める
+		top.calls++
+		if top.calls == 1 && m.emit("loop 各要素") {
+			top.looped = true
+			m.indent++
+		}
+		e := &entry{kind: entryElement, from: top.to, to: top.to, omitted: top.calls > maxElementCalls}
+		m.push(e)
+		m.arrow(e.from, e.to, false, "🍕 = "+pizzaValue(env))
+	default:
+		e := &entry{kind: entryCall, from: m.current(), to: name}
+		m.push(e)
+		m.arrow(e.from, e.to, false, fmt.Sprintf("%s を呼び出し (🍕 = %s)", name, pizzaValue(env)))
+	}
+
+	if condition, ok := fn.Condition.(ast.Expression); ok && condition != nil {
+		m.note(m.current(), fmt.Sprintf("条件 %s に一致する %s を選択", condition.String(), name))
+	}
+}
+
+// ExitFunction は関数の 💩 の結果を返すメッセージを書き出す
+func (m *Mermaid) ExitFunction(fn *object.Function, result object.Object) {
+	e := m.pop()
+	if e == nil {
+		return
+	}
+	switch {
+	case e.omitted:
+		return
+	case e.kind == entryElement || e.kind == entryCall:
+		m.arrow(e.to, e.from, true, "💩 = "+formatValue(result))
+	}
+}
+
+// SelectCase は選ばれた case 文を注記する
+func (m *Mermaid) SelectCase(arm ast.Statement, env *object.Environment) {
+	switch arm := arm.(type) {
+	case *ast.CaseStatement:
+		m.note(m.current(), "case "+arm.Condition.String())
+	case *ast.DefaultCaseStatement:
+		m.note(m.current(), "default")
+	}
+}
+
+// Write はシーケンス図を書き出す
+func (m *Mermaid) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("sequenceDiagram\n")
+	fmt.Fprintf(bw, "    %%%% %s の実行から生成したシーケンス図\n", m.file)
+	for _, name := range m.participants {
+		fmt.Fprintf(bw, "    participant %s as %s\n", m.ids[name], escape(name))
+	}
+	for _, line := range m.lines {
+		if line == "" {
+			bw.WriteString("\n")
+			continue
+		}
+		bw.WriteString("    " + line + "\n")
+	}
+	if m.dropped > 0 {
+		fmt.Fprintf(bw, "\n    Note over Main: 以降の %d 件のメッセージは省略\n", m.dropped)
+	}
+	return bw.Flush()
+}
+
+// stageTarget は段の右辺が表す関数の名前を返す。ブロックなど名前のない右辺は "<無名関数>" にする
+func stageTarget(node *ast.InfixExpression) string {
+	switch right := node.Right.(type) {
+	case *ast.Identifier:
+		return right.Value
+	case *ast.CallExpression:
+		return right.Function.String()
+	}
+	return "<無名関数>"
+}
+
+// pizzaValue は関数の環境での 🍕 の値を表示用に変換する
+func pizzaValue(env *object.Environment) string {
+	if v, ok := env.Get("🍕"); ok {
+		return formatValue(v)
+	}
+	return "(なし)"
+}
+
+// formatValue は値を表示用に変換する。大きな配列は先頭の要素と要素数だけにする
+func formatValue(v object.Object) string {
+	switch v := v.(type) {
+	case nil:
+		return "(なし)"
+	case *object.ReturnValue:
+		return formatValue(v.Value)
+	case *object.Error:
+		return "エラー: " + truncate(v.Message)
+	case *object.Array:
+		if len(v.Elements) <= maxArrayElements {
+			return truncate(v.Inspect())
+		}
+		elements := make([]string, maxArrayElements)
+		for i := range elements {
+			elements[i] = formatValue(v.Elements[i])
+		}
+		return fmt.Sprintf("[%s, … 全 %d 要素]", strings.Join(elements, ", "), len(v.Elements))
+	}
+	return truncate(v.Inspect())
+}
+
+// truncate は長い文字列を上限の文字数で切り詰める
+func truncate(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	runes := []rune(s)
+	if len(runes) <= maxValueLength {
+		return s
+	}
+	return string(runes[:maxValueLength]) + "…"
+}
+
+// escape は Mermaid のメッセージで特別な意味を持つ文字を実体参照にする
+// < は HTML のタグとして取り除かれないようにする
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '#':
+			b.WriteString("#35;")
+		case ';':
+			b.WriteString("#59;")
+		case '<':
+			b.WriteString("#lt;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
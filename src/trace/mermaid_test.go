@@ -0,0 +1,118 @@
+package trace
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/uncode/evaluator"
+	"github.com/uncode/logger"
+	"github.com/uncode/runtime"
+)
+
+// runMermaid はプログラムを実行して生成したシーケンス図を返す
+func runMermaid(t *testing.T, source string) string {
+	t.Helper()
+	logger.SetLevel(logger.LevelOff)
+	evaluator.SetOutput(io.Discard)
+	t.Cleanup(func() {
+		evaluator.SetHook(nil)
+		evaluator.SetOutput(os.Stdout)
+	})
+
+	m := NewMermaid("main.poo")
+	evaluator.SetHook(m)
+	runtime.ExecuteSource("main.poo", source)
+	evaluator.SetHook(nil)
+
+	var out strings.Builder
+	if err := m.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+// TestMermaidPipeline は段ごとの 🍕 と 💩、選ばれた case 文と条件付き関数をテストする
+func TestMermaidPipeline(t *testing.T) {
+	source := strings.Join([]string{
+		"def sq(): int -> int {",
+		"  🍕 * 🍕 >> 💩;",
+		"}",
+		"def kind(): int -> str {",
+		"  case 🍕 % 2 == 0: {",
+		`    "even" >> 💩;`,
+		"  }",
+		"  default: {",
+		`    "odd" >> 💩;`,
+		"  }",
+		"}",
+		"def sign() if 🍕 > 0 {",
+		`  "plus" >> 💩;`,
+		"}",
+		"3 |> sq |> kind |> print;",
+		"5 |> sign;",
+	}, "\n")
+	expected := strings.Join([]string{
+		"sequenceDiagram",
+		"    %% main.poo の実行から生成したシーケンス図",
+		"    participant Main as メイン",
+		"    participant Fn1 as sq",
+		"    participant Fn2 as kind",
+		"    participant Fn3 as print",
+		"    participant Fn4 as sign",
+		"",
+		"    %% 15行目: (((3 |> sq) |> kind) |> print)",
+		"    Main->>Fn1: |> sq (🍕 = 3)",
+		"    Fn1-->>Main: 💩 = 9",
+		"    Main->>Fn2: |> kind (🍕 = 9)",
+		"    Note over Fn2: default",
+		"    Fn2-->>Main: 💩 = odd",
+		"    Main->>Fn3: |> print (🍕 = odd)",
+		"    Fn3-->>Main: 💩 = odd",
+		"",
+		"    %% 16行目: (5 |> sign)",
+		"    Main->>Fn4: |> sign (🍕 = 5)",
+		"    Note over Fn4: 条件 (🍕 > 0) に一致する sign を選択",
+		"    Fn4-->>Main: 💩 = plus",
+		"",
+	}, "\n")
+	if got := runMermaid(t, source); got != expected {
+		t.Errorf("wrong diagram.\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+// TestMermaidElements は +> の段の要素ごとの呼び出しと、大きな配列の表示を省略することをテストする
+func TestMermaidElements(t *testing.T) {
+	source := strings.Join([]string{
+		"[1, 2, 3, 4, 5, 6, 7] >> xs;",
+		"def sq(): int -> int {",
+		"  🍕 * 🍕 >> 💩;",
+		"}",
+		"xs +> sq;",
+	}, "\n")
+	got := runMermaid(t, source)
+	for _, line := range []string{
+		"    Main->>Fn1: +> sq (🍕 = [1, 2, 3, 4, 5, … 全 7 要素])",
+		"    loop 各要素",
+		"        Fn1->>Fn1: 🍕 = 3",
+		"        Fn1-->>Fn1: 💩 = 9",
+		"        Note over Fn1: 残り 4 回の呼び出しは省略",
+		"    end",
+		"    Fn1-->>Main: 💩 = [1, 4, 9, 16, 25, … 全 7 要素]",
+	} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("diagram should contain %q.\ngot:\n%s", line, got)
+		}
+	}
+	if strings.Contains(got, "🍕 = 4\n") {
+		t.Errorf("calls over the limit should be omitted.\ngot:\n%s", got)
+	}
+}
+
+// TestEscape は Mermaid で特別な意味を持つ文字を実体参照にすることをテストする
+func TestEscape(t *testing.T) {
+	if got := escape("sq#1; <無名関数>"); got != "sq#35;1#59; #lt;無名関数>" {
+		t.Errorf("wrong escape. got=%q", got)
+	}
+}